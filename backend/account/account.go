@@ -0,0 +1,231 @@
+package account
+
+import (
+	"context"
+	"log/slog"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"backend/config"
+	"backend/env"
+	"backend/lifecycle"
+	"backend/meetings"
+	"backend/models"
+	"backend/notifications"
+	"backend/risk"
+	"backend/storage"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// purgeBatch bounds how many accounts one purge run handles; the rest wait for the next tick
+const purgeBatch = 100
+
+var (
+	started sync.Once
+	stop    = make(chan struct{})
+	done    = make(chan struct{})
+
+	envOnce sync.Once
+	// Overridden from ACCOUNT_* environment variables on first use
+	gracePeriod   = 30 * 24 * time.Hour
+	purgeInterval = time.Hour
+)
+
+func loadEnv() {
+	envOnce.Do(func() {
+		gracePeriod = env.Duration("ACCOUNT_DELETION_GRACE", gracePeriod)
+		purgeInterval = env.Duration("ACCOUNT_PURGE_INTERVAL", purgeInterval)
+	})
+}
+
+// GracePeriod is how long a deleted account can still be restored by logging in
+func GracePeriod() time.Duration {
+	loadEnv()
+	return gracePeriod
+}
+
+// Start runs the purge job in the background. Every replica runs it; purging claims
+// each account first, so concurrent runs don't do the work twice.
+func Start() {
+	started.Do(func() {
+		loadEnv()
+		lifecycle.OnStop("account-purge", func(ctx context.Context) error {
+			close(stop)
+			select {
+			case <-done:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+		go run()
+	})
+}
+
+func run() {
+	defer close(done)
+
+	ticker := time.NewTicker(purgeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case now := <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+			purged, err := PurgeDue(ctx, now)
+			cancel()
+			if err != nil {
+				slog.Warn("Account purge failed, will retry", "error", err, "purged", purged)
+			} else if purged > 0 {
+				slog.Info("Deleted accounts purged", "count", purged)
+			}
+		}
+	}
+}
+
+// PurgeDue permanently deletes every account whose grace period ended before now
+func PurgeDue(ctx context.Context, now time.Time) (int, error) {
+	if config.UserCollectionRef == nil {
+		return 0, nil
+	}
+
+	opts := options.Find().
+		SetProjection(bson.M{"_id": 1, "profileImage": 1, "profileImages": 1}).
+		SetLimit(purgeBatch)
+	cursor, err := config.UserCollectionRef.Find(ctx, bson.M{"purgeAt": bson.M{"$lte": now}}, opts)
+	if err != nil {
+		return 0, err
+	}
+	var due []models.User
+	if err := cursor.All(ctx, &due); err != nil {
+		return 0, err
+	}
+
+	purged := 0
+	for _, user := range due {
+		if err := purge(ctx, user, now); err != nil {
+			return purged, err
+		}
+		purged++
+	}
+	return purged, nil
+}
+
+// purge anonymizes the user's check-ins, removes their notifications, the alerts about
+// them, their meeting attendance and their uploads and deletes the account.
+// The account is claimed first so a login can no longer restore it half way; a failed
+// purge is retried from the start on the next run since every step is idempotent.
+func purge(ctx context.Context, user models.User, now time.Time) error {
+	filter := bson.M{"$and": bson.A{config.UserIDFilter(user.ID), bson.M{"purgeAt": bson.M{"$lte": now}}}}
+	res, err := config.UserCollectionRef.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"purging": true}})
+	if err != nil || res.MatchedCount == 0 {
+		// Restored or purged by another replica in the meantime
+		return err
+	}
+
+	// Check-ins stay in team aggregates, but nothing links them back to the person
+	if objectID, err := primitive.ObjectIDFromHex(user.ID); err == nil {
+		_, err = config.DB.Collection("emotions").UpdateMany(ctx,
+			bson.M{"user_id": objectID},
+			bson.M{
+				"$set":   bson.M{"user_id": primitive.NilObjectID, "anonymized": true},
+				"$unset": bson.M{"user_name": "", "note": "", "tags": "", "day": "", "idempotency_key": ""},
+			},
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := notifications.DeleteAll(ctx, user.ID); err != nil {
+		return err
+	}
+	if err := risk.DeleteForUser(ctx, user.ID); err != nil {
+		return err
+	}
+	if err := meetings.DeleteForUser(ctx, user.ID); err != nil {
+		return err
+	}
+
+	keys, err := RemovableKeys(ctx, user)
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		if err := storage.Default().Delete(ctx, key); err != nil {
+			return err
+		}
+	}
+
+	if _, err := config.UserCollectionRef.DeleteOne(ctx, config.UserIDFilter(user.ID)); err != nil {
+		return err
+	}
+	slog.Info("Account purged", "user_id", user.ID)
+	return nil
+}
+
+// AvatarPrefix is the key prefix of the avatar files stored for userID
+func AvatarPrefix(userID string) string {
+	return "avatars/" + userID + "/"
+}
+
+// UploadKeys lists the keys of the avatar files user refers to, without duplicates
+func UploadKeys(user models.User) []string {
+	seen := map[string]bool{}
+	var keys []string
+	add := func(url string) {
+		if key, ok := storage.KeyFromURL(url); ok && !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+	for _, url := range user.ProfileImages {
+		add(url)
+	}
+	add(user.ProfileImage)
+	return keys
+}
+
+// RemovableKeys is UploadKeys without the files another account still refers to. Files
+// under the user's own prefix are theirs alone, but older uploads shared one prefix and
+// profileImage could once be set by clients, so any other file may be someone's avatar.
+func RemovableKeys(ctx context.Context, user models.User) ([]string, error) {
+	var keys []string
+	for _, key := range UploadKeys(user) {
+		if !strings.HasPrefix(key, AvatarPrefix(user.ID)) {
+			shared, err := sharedKey(ctx, user.ID, key)
+			if err != nil {
+				return nil, err
+			}
+			if shared {
+				continue
+			}
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// sharedKey reports whether an account other than userID shows the upload as its avatar
+func sharedKey(ctx context.Context, userID, key string) (bool, error) {
+	pattern := regexp.QuoteMeta(storage.URLPrefix+key) + "$"
+	images := bson.M{"$objectToArray": bson.M{"$ifNull": bson.A{"$profileImages", bson.M{}}}}
+	n, err := config.UserCollectionRef.CountDocuments(ctx, bson.M{
+		"$nor": bson.A{config.UserIDFilter(userID)},
+		"$or": bson.A{
+			bson.M{"profileImage": primitive.Regex{Pattern: pattern}},
+			bson.M{"$expr": bson.M{"$anyElementTrue": bson.A{bson.M{"$map": bson.M{
+				"input": images,
+				"in":    bson.M{"$regexMatch": bson.M{"input": "$$this.v", "regex": pattern}},
+			}}}}},
+		},
+	}, options.Count().SetLimit(1))
+	return n > 0, err
+}
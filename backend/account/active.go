@@ -0,0 +1,71 @@
+package account
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"backend/config"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// activeCacheTTL bounds how long another replica keeps accepting the tokens of an
+// account after it was deleted; the replica that deleted it stops at once
+const activeCacheTTL = 30 * time.Second
+
+type cachedActive struct {
+	active  bool
+	expires time.Time
+}
+
+var (
+	activeMu sync.Mutex
+	actives  = map[string]cachedActive{}
+)
+
+// Active reports whether the account exists and isn't scheduled for deletion. Every
+// authenticated request asks, so answers are cached for a short while.
+func Active(ctx context.Context, userID string) (bool, error) {
+	now := time.Now()
+
+	activeMu.Lock()
+	cached, ok := actives[userID]
+	activeMu.Unlock()
+	if ok && now.Before(cached.expires) {
+		return cached.active, nil
+	}
+
+	filter := config.UserIDFilter(userID)
+	filter["deletedAt"] = bson.M{"$exists": false}
+	err := config.UserCollectionRef.FindOne(ctx, filter,
+		options.FindOne().SetProjection(bson.M{"_id": 1}),
+	).Err()
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return false, err
+	}
+	active := err == nil
+
+	activeMu.Lock()
+	// Drop expired entries now and then so the cache doesn't grow with every user seen
+	if len(actives) > 1000 {
+		for id, entry := range actives {
+			if now.After(entry.expires) {
+				delete(actives, id)
+			}
+		}
+	}
+	actives[userID] = cachedActive{active: active, expires: now.Add(activeCacheTTL)}
+	activeMu.Unlock()
+	return active, nil
+}
+
+// Forget drops the cached answer of Active once the account was deleted or restored
+func Forget(userID string) {
+	activeMu.Lock()
+	delete(actives, userID)
+	activeMu.Unlock()
+}
@@ -0,0 +1,192 @@
+package apperror
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Code is a stable, machine-readable error identifier returned to clients
+type Code string
+
+// Error is an application error with a stable code, an HTTP status and an optional
+// internal cause. The cause is logged but never sent to the client.
+type Error struct {
+	Code    Code
+	Status  int
+	Params  map[string]interface{}
+	Details []FieldError
+	Cause   error
+}
+
+// FieldError describes why one input field was rejected. Code is the rule that
+// failed (e.g. "required", "email", "unique") and Param its argument, if any.
+type FieldError struct {
+	Field string
+	Code  string
+	Param string
+}
+
+func (e *Error) Error() string {
+	if e.Cause != nil {
+		return fmt.Sprintf("%s: %v", e.Code, e.Cause)
+	}
+	return string(e.Code)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Cause
+}
+
+// Is matches errors by code, so errors.Is(err, apperror.UserNotFound) works on wrapped copies
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// Wrap returns a copy of e carrying cause for logging
+func (e *Error) Wrap(cause error) *Error {
+	cp := *e
+	cp.Cause = cause
+	return &cp
+}
+
+// With returns a copy of e with a message parameter set, e.g. With("max", 5)
+func (e *Error) With(key string, value interface{}) *Error {
+	cp := *e
+	cp.Params = make(map[string]interface{}, len(e.Params)+1)
+	for k, v := range e.Params {
+		cp.Params[k] = v
+	}
+	cp.Params[key] = value
+	return &cp
+}
+
+// WithDetails returns a copy of e listing the offending fields
+func (e *Error) WithDetails(details ...FieldError) *Error {
+	cp := *e
+	cp.Details = append(append([]FieldError(nil), e.Details...), details...)
+	return &cp
+}
+
+func define(code Code, status int) *Error {
+	return &Error{Code: code, Status: status}
+}
+
+// Generic
+var (
+	InvalidRequest     = define("INVALID_REQUEST", fiber.StatusBadRequest)
+	ValidationFailed   = define("VALIDATION_FAILED", fiber.StatusUnprocessableEntity)
+	InvalidCursor      = define("INVALID_CURSOR", fiber.StatusBadRequest)
+	RouteNotFound      = define("ROUTE_NOT_FOUND", fiber.StatusNotFound)
+	MethodNotAllowed   = define("METHOD_NOT_ALLOWED", fiber.StatusMethodNotAllowed)
+	PayloadTooLarge    = define("PAYLOAD_TOO_LARGE", fiber.StatusRequestEntityTooLarge)
+	TooManyRequests    = define("TOO_MANY_REQUESTS", fiber.StatusTooManyRequests)
+	Internal           = define("INTERNAL_ERROR", fiber.StatusInternalServerError)
+	ServiceUnavailable = define("SERVICE_UNAVAILABLE", fiber.StatusServiceUnavailable)
+	UpgradeRequired    = define("UPGRADE_REQUIRED", fiber.StatusUpgradeRequired)
+)
+
+// Authentication and authorization
+var (
+	AuthInvalidCredentials = define("AUTH_INVALID_CREDENTIALS", fiber.StatusUnauthorized)
+	AuthTokenMissing       = define("AUTH_TOKEN_MISSING", fiber.StatusUnauthorized)
+	AuthTokenInvalid       = define("AUTH_TOKEN_INVALID", fiber.StatusUnauthorized)
+	AuthForbidden          = define("AUTH_FORBIDDEN", fiber.StatusForbidden)
+	// Roles and teams decide who sees whose wellbeing data, so users can't pick their own
+	RoleAssignmentForbidden = define("ROLE_ASSIGNMENT_FORBIDDEN", fiber.StatusForbidden)
+)
+
+// Users
+var (
+	UserNotFound            = define("USER_NOT_FOUND", fiber.StatusNotFound)
+	UserIDInvalid           = define("USER_ID_INVALID", fiber.StatusBadRequest)
+	EmailAlreadyRegistered  = define("EMAIL_ALREADY_REGISTERED", fiber.StatusConflict)
+	NoFieldsToUpdate        = define("NO_FIELDS_TO_UPDATE", fiber.StatusBadRequest)
+	CurrentPasswordMismatch = define("CURRENT_PASSWORD_INCORRECT", fiber.StatusBadRequest)
+	FileMissing             = define("FILE_MISSING", fiber.StatusBadRequest)
+)
+
+// Check-ins
+var (
+	AnonymousCheckinLimit   = define("ANONYMOUS_CHECKIN_LIMIT", fiber.StatusConflict)
+	EmotionNotFound         = define("EMOTION_NOT_FOUND", fiber.StatusNotFound)
+	EmotionIDInvalid        = define("EMOTION_ID_INVALID", fiber.StatusBadRequest)
+	CheckinEditWindowClosed = define("CHECKIN_EDIT_WINDOW_CLOSED", fiber.StatusForbidden)
+)
+
+// Moods
+var (
+	MoodKeyInvalid = define("MOOD_KEY_INVALID", fiber.StatusBadRequest)
+)
+
+// Notifications and meetings
+var (
+	NotificationNotFound  = define("NOTIFICATION_NOT_FOUND", fiber.StatusNotFound)
+	NotificationIDInvalid = define("NOTIFICATION_ID_INVALID", fiber.StatusBadRequest)
+	MeetingIDInvalid      = define("MEETING_ID_INVALID", fiber.StatusBadRequest)
+)
+
+// Wellbeing alerts
+var (
+	AlertNotFound  = define("ALERT_NOT_FOUND", fiber.StatusNotFound)
+	AlertIDInvalid = define("ALERT_ID_INVALID", fiber.StatusBadRequest)
+	AlertResolved  = define("ALERT_RESOLVED", fiber.StatusConflict)
+)
+
+// Uploads
+var (
+	ImageTooLarge          = define("IMAGE_TOO_LARGE", fiber.StatusRequestEntityTooLarge)
+	ImageTypeUnsupported   = define("IMAGE_TYPE_UNSUPPORTED", fiber.StatusUnsupportedMediaType)
+	ImageInvalid           = define("IMAGE_INVALID", fiber.StatusUnprocessableEntity)
+	ImageDimensionsInvalid = define("IMAGE_DIMENSIONS_INVALID", fiber.StatusUnprocessableEntity)
+	UploadNotFound         = define("UPLOAD_NOT_FOUND", fiber.StatusNotFound)
+)
+
+// StatusOf returns the HTTP status an error will be answered with
+func StatusOf(err error) int {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr.Status
+	}
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) {
+		return fiberErr.Code
+	}
+	return fiber.StatusInternalServerError
+}
+
+// From converts any error into an *Error; unknown errors become INTERNAL_ERROR
+func From(err error) *Error {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) {
+		switch fiberErr.Code {
+		case fiber.StatusBadRequest, fiber.StatusUnprocessableEntity:
+			return InvalidRequest.Wrap(err)
+		case fiber.StatusNotFound:
+			return RouteNotFound.Wrap(err)
+		case fiber.StatusMethodNotAllowed:
+			return MethodNotAllowed.Wrap(err)
+		case fiber.StatusRequestEntityTooLarge:
+			return PayloadTooLarge.Wrap(err)
+		case fiber.StatusTooManyRequests:
+			return TooManyRequests.Wrap(err)
+		case fiber.StatusServiceUnavailable:
+			return ServiceUnavailable.Wrap(err)
+		}
+		wrapped := Internal.Wrap(err)
+		if fiberErr.Code < fiber.StatusInternalServerError {
+			wrapped = InvalidRequest.Wrap(err)
+			wrapped.Status = fiberErr.Code
+		}
+		return wrapped
+	}
+
+	return Internal.Wrap(err)
+}
@@ -0,0 +1,66 @@
+package apperror
+
+import (
+	"backend/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Body is the error envelope every failed request is answered with
+type Body struct {
+	Error Payload `json:"error"`
+}
+
+// Payload describes one error to the client
+type Payload struct {
+	Code      Code           `json:"code"`
+	Message   string         `json:"message"`
+	RequestID string         `json:"requestId,omitempty"`
+	Details   []FieldPayload `json:"details,omitempty"`
+}
+
+// FieldPayload describes one rejected input field to the client
+type FieldPayload struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Locale picks the response language from Accept-Language
+func Locale(c *fiber.Ctx) string {
+	if locale := c.AcceptsLanguages(Locales...); locale != "" {
+		return locale
+	}
+	return DefaultLocale()
+}
+
+// Handler is the application's fiber.ErrorHandler: it maps any returned error to
+// the error envelope, localizes the message and logs the internal cause
+func Handler(c *fiber.Ctx, err error) error {
+	appErr := From(err)
+
+	logger := utils.Log(c)
+	if appErr.Status >= fiber.StatusInternalServerError {
+		logger.Error("request failed", "code", appErr.Code, "error", err)
+	} else {
+		logger.Debug("request rejected", "code", appErr.Code, "error", err)
+	}
+
+	requestID, _ := c.Locals(utils.RequestIDKey).(string)
+	locale := Locale(c)
+
+	payload := Payload{
+		Code:      appErr.Code,
+		Message:   Message(appErr.Code, locale, appErr.Params),
+		RequestID: requestID,
+	}
+	for _, fe := range appErr.Details {
+		payload.Details = append(payload.Details, FieldPayload{
+			Field:   fe.Field,
+			Code:    fe.Code,
+			Message: FieldMessage(fe, locale),
+		})
+	}
+
+	return c.Status(appErr.Status).JSON(Body{Error: payload})
+}
@@ -0,0 +1,323 @@
+package apperror
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Supported locales, in order of preference when the client expresses none
+var Locales = []string{"id", "en"}
+
+// DefaultLocale is used when Accept-Language matches nothing we support
+func DefaultLocale() string {
+	if v := strings.ToLower(os.Getenv("DEFAULT_LOCALE")); v == "en" || v == "id" {
+		return v
+	}
+	return "id"
+}
+
+// messages holds the client-facing text per code and locale; {name} is replaced by Params[name]
+var messages = map[Code]map[string]string{
+	InvalidRequest.Code: {
+		"id": "Request tidak valid",
+		"en": "Invalid request",
+	},
+	ValidationFailed.Code: {
+		"id": "Data yang dikirim tidak valid",
+		"en": "Some fields are invalid",
+	},
+	InvalidCursor.Code: {
+		"id": "Cursor halaman tidak valid, mulai lagi dari halaman pertama",
+		"en": "Invalid page cursor, start again from the first page",
+	},
+	RouteNotFound.Code: {
+		"id": "Endpoint tidak ditemukan",
+		"en": "Endpoint not found",
+	},
+	MethodNotAllowed.Code: {
+		"id": "Metode tidak diizinkan",
+		"en": "Method not allowed",
+	},
+	PayloadTooLarge.Code: {
+		"id": "Ukuran request terlalu besar",
+		"en": "Request payload too large",
+	},
+	TooManyRequests.Code: {
+		"id": "Terlalu banyak request, coba lagi nanti",
+		"en": "Too many requests, try again later",
+	},
+	Internal.Code: {
+		"id": "Terjadi kesalahan pada server",
+		"en": "Something went wrong on our side",
+	},
+	ServiceUnavailable.Code: {
+		"id": "Layanan sedang tidak tersedia",
+		"en": "Service temporarily unavailable",
+	},
+	UpgradeRequired.Code: {
+		"id": "Endpoint ini hanya menerima koneksi WebSocket",
+		"en": "This endpoint only accepts WebSocket connections",
+	},
+
+	AuthInvalidCredentials.Code: {
+		"id": "Email atau password salah",
+		"en": "Incorrect email or password",
+	},
+	AuthTokenMissing.Code: {
+		"id": "Token autentikasi tidak ada atau formatnya salah",
+		"en": "Missing or malformed authentication token",
+	},
+	AuthTokenInvalid.Code: {
+		"id": "Token autentikasi tidak valid atau kedaluwarsa",
+		"en": "Invalid or expired authentication token",
+	},
+	AuthForbidden.Code: {
+		"id": "Anda tidak memiliki akses ke sumber daya ini",
+		"en": "You are not allowed to access this resource",
+	},
+	RoleAssignmentForbidden.Code: {
+		"id": "Hanya admin yang dapat mengubah peran dan tim",
+		"en": "Only an administrator can change roles and teams",
+	},
+
+	UserNotFound.Code: {
+		"id": "Pengguna tidak ditemukan",
+		"en": "User not found",
+	},
+	UserIDInvalid.Code: {
+		"id": "Format ID pengguna tidak valid",
+		"en": "Invalid user ID format",
+	},
+	EmailAlreadyRegistered.Code: {
+		"id": "Email sudah terdaftar",
+		"en": "Email is already registered",
+	},
+	NoFieldsToUpdate.Code: {
+		"id": "Tidak ada data yang diubah",
+		"en": "No fields to update",
+	},
+	CurrentPasswordMismatch.Code: {
+		"id": "Password saat ini salah",
+		"en": "Current password is incorrect",
+	},
+	FileMissing.Code: {
+		"id": "File tidak ada atau tidak valid",
+		"en": "No file provided or invalid file",
+	},
+
+	AnonymousCheckinLimit.Code: {
+		"id": "Batas check-in anonim tercapai ({limit} per periode)",
+		"en": "You reached the limit of {limit} anonymous check-ins per {period}",
+	},
+	EmotionNotFound.Code: {
+		"id": "Check-in tidak ditemukan",
+		"en": "Check-in not found",
+	},
+	EmotionIDInvalid.Code: {
+		"id": "Format ID check-in tidak valid",
+		"en": "Invalid check-in ID format",
+	},
+	CheckinEditWindowClosed.Code: {
+		"id": "Check-in hanya bisa diubah atau dihapus dalam {window} setelah dibuat",
+		"en": "Check-ins can only be edited or deleted within {window} of being made",
+	},
+	NotificationNotFound.Code: {
+		"id": "Notifikasi tidak ditemukan",
+		"en": "Notification not found",
+	},
+	NotificationIDInvalid.Code: {
+		"id": "Format ID notifikasi tidak valid",
+		"en": "Invalid notification ID format",
+	},
+	MoodKeyInvalid.Code: {
+		"id": "Kunci mood hanya boleh berisi huruf kecil, angka dan _ (maksimal 32)",
+		"en": "Mood keys may only contain lowercase letters, digits and _ (at most 32)",
+	},
+	MeetingIDInvalid.Code: {
+		"id": "ID meeting hanya boleh berisi huruf, angka, - dan _ (maksimal 64)",
+		"en": "Meeting IDs may only contain letters, digits, - and _ (at most 64)",
+	},
+	AlertNotFound.Code: {
+		"id": "Peringatan tidak ditemukan",
+		"en": "Alert not found",
+	},
+	AlertIDInvalid.Code: {
+		"id": "Format ID peringatan tidak valid",
+		"en": "Invalid alert ID format",
+	},
+	AlertResolved.Code: {
+		"id": "Peringatan sudah diselesaikan",
+		"en": "The alert is already resolved",
+	},
+	ImageTooLarge.Code: {
+		"id": "Ukuran gambar maksimal {max}",
+		"en": "Images can be at most {max}",
+	},
+	ImageTypeUnsupported.Code: {
+		"id": "Format gambar harus JPEG, PNG, atau WebP",
+		"en": "Images must be JPEG, PNG or WebP",
+	},
+	ImageInvalid.Code: {
+		"id": "File gambar rusak atau tidak dapat dibaca",
+		"en": "The image is corrupt or could not be read",
+	},
+	ImageDimensionsInvalid.Code: {
+		"id": "Sisi terpendek gambar minimal {min} piksel dan ukurannya maksimal {maxMegapixels} megapiksel",
+		"en": "Images must be at least {min} pixels on the shorter side and at most {maxMegapixels} megapixels",
+	},
+	UploadNotFound.Code: {
+		"id": "File tidak ditemukan",
+		"en": "File not found",
+	},
+}
+
+// fieldMessages holds the text per validation rule; {param} is the rule's argument
+var fieldMessages = map[string]map[string]string{
+	"required": {
+		"id": "Wajib diisi",
+		"en": "This field is required",
+	},
+	"required_with": {
+		"id": "Wajib diisi bersama {param}",
+		"en": "Required together with {param}",
+	},
+	"required_without": {
+		"id": "Wajib diisi jika {param} tidak diisi",
+		"en": "Required when {param} is not set",
+	},
+	"email": {
+		"id": "Format email tidak valid",
+		"en": "Must be a valid email address",
+	},
+	"min": {
+		"id": "Minimal {param} karakter",
+		"en": "Must be at least {param} characters",
+	},
+	"max": {
+		"id": "Maksimal {param} karakter",
+		"en": "Must be at most {param} characters",
+	},
+	"gte": {
+		"id": "Minimal {param}",
+		"en": "Must be at least {param}",
+	},
+	"lte": {
+		"id": "Maksimal {param}",
+		"en": "Must be at most {param}",
+	},
+	"oneof": {
+		"id": "Harus salah satu dari: {param}",
+		"en": "Must be one of: {param}",
+	},
+	"datetime": {
+		"id": "Format harus {param}",
+		"en": "Must be in the format {param}",
+	},
+	"timezone": {
+		"id": "Zona waktu tidak dikenal",
+		"en": "Unknown time zone",
+	},
+	"http_url": {
+		"id": "Harus URL http atau https",
+		"en": "Must be an http or https URL",
+	},
+	"required_if": {
+		"id": "Wajib diisi",
+		"en": "This field is required",
+	},
+	"mongodb": {
+		"id": "Format ID tidak valid",
+		"en": "Must be a valid ID",
+	},
+	"mood": {
+		"id": "Mood tidak dikenal",
+		"en": "Unknown mood",
+	},
+	"future": {
+		"id": "Harus waktu di masa depan",
+		"en": "Must be a time in the future",
+	},
+	"role": {
+		"id": "Role tidak dikenal",
+		"en": "Unknown role",
+	},
+	"password_min": {
+		"id": "Password minimal {param} karakter",
+		"en": "Password must be at least {param} characters",
+	},
+	"password_letter": {
+		"id": "Password harus berisi huruf",
+		"en": "Password must contain a letter",
+	},
+	"password_upper": {
+		"id": "Password harus berisi huruf besar",
+		"en": "Password must contain an uppercase letter",
+	},
+	"password_lower": {
+		"id": "Password harus berisi huruf kecil",
+		"en": "Password must contain a lowercase letter",
+	},
+	"password_digit": {
+		"id": "Password harus berisi angka",
+		"en": "Password must contain a digit",
+	},
+	"password_symbol": {
+		"id": "Password harus berisi simbol",
+		"en": "Password must contain a symbol",
+	},
+	"password_personal": {
+		"id": "Password tidak boleh sama dengan email atau nama",
+		"en": "Password must not match your email or name",
+	},
+	"password_breached": {
+		"id": "Password ini pernah bocor di kebocoran data publik, gunakan password lain",
+		"en": "This password appeared in a public data breach, choose another one",
+	},
+	"password_reused": {
+		"id": "Password tidak boleh sama dengan {param} password terakhir",
+		"en": "Password must differ from your last {param} passwords",
+	},
+	"unique": {
+		"id": "Sudah digunakan oleh akun lain",
+		"en": "Already used by another account",
+	},
+	"webhook_host": {
+		"id": "Alamat webhook harus berupa host publik yang diizinkan",
+		"en": "The webhook must point at an allowed public host",
+	},
+}
+
+// FieldMessage renders the text for a failed field rule, falling back to a generic message
+func FieldMessage(fe FieldError, locale string) string {
+	texts, ok := fieldMessages[fe.Code]
+	if !ok {
+		return Message(ValidationFailed.Code, locale, nil)
+	}
+
+	text, ok := texts[locale]
+	if !ok {
+		text = texts[DefaultLocale()]
+	}
+	return strings.ReplaceAll(text, "{param}", fe.Param)
+}
+
+// Message renders the text for code in locale, falling back to the default locale and then the code itself
+func Message(code Code, locale string, params map[string]interface{}) string {
+	texts, ok := messages[code]
+	if !ok {
+		return string(code)
+	}
+
+	text, ok := texts[locale]
+	if !ok {
+		if text, ok = texts[DefaultLocale()]; !ok {
+			return string(code)
+		}
+	}
+
+	for k, v := range params {
+		text = strings.ReplaceAll(text, "{"+k+"}", fmt.Sprint(v))
+	}
+	return text
+}
@@ -0,0 +1,64 @@
+// Package checkin holds the rules for changing check-ins after they are made: how long
+// their author may edit or delete them, and whether a user keeps one check-in per day.
+package checkin
+
+import (
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"backend/env"
+)
+
+// Settings are read from CHECKIN_* environment variables on first use
+type Settings struct {
+	// EditWindow is how long after creation the author may edit or delete a check-in;
+	// zero disables editing and deleting
+	EditWindow time.Duration
+	// DailyUpsert keeps one named check-in per user per day: a later check-in the same
+	// day replaces the earlier one instead of adding another
+	DailyUpsert bool
+	// Location decides where days start for DailyUpsert
+	Location *time.Location
+}
+
+var (
+	settingsOnce sync.Once
+	settings     Settings
+)
+
+// Config returns the check-in settings from the environment
+func Config() Settings {
+	settingsOnce.Do(func() {
+		settings = Settings{
+			EditWindow:  env.NonNegativeDuration("CHECKIN_EDIT_WINDOW", 24*time.Hour),
+			DailyUpsert: env.Bool("CHECKIN_DAILY_UPSERT", false),
+			Location:    time.Local,
+		}
+		if v := os.Getenv("CHECKIN_TIMEZONE"); v != "" {
+			if loc, err := time.LoadLocation(v); err == nil {
+				settings.Location = loc
+			} else {
+				slog.Warn("Invalid CHECKIN_TIMEZONE, using the server's", "value", v)
+			}
+		}
+	})
+	return settings
+}
+
+// DayKey names the day containing t in the configured location, e.g. "2026-10-19"
+func (s Settings) DayKey(t time.Time) string {
+	return t.In(s.Location).Format("2006-01-02")
+}
+
+// DayStart returns midnight of the day containing t in the configured location
+func (s Settings) DayStart(t time.Time) time.Time {
+	t = t.In(s.Location)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, s.Location)
+}
+
+// Editable reports whether a check-in created at createdAt may still be changed at now
+func (s Settings) Editable(createdAt, now time.Time) bool {
+	return s.EditWindow > 0 && now.Sub(createdAt) <= s.EditWindow
+}
@@ -0,0 +1,76 @@
+// Command grant-admin makes a user an admin, who can then assign roles and teams through
+// PUT /api/v1/users/:id. Admin rights can't be granted through the API itself.
+//
+//	go run ./cmd/grant-admin -email someone@example.com [-revoke]
+//
+// It reads the same .env as the server.
+package main
+
+import (
+	"context"
+	"flag"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"backend/config"
+	"backend/utils"
+
+	"github.com/joho/godotenv"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func main() {
+	email := flag.String("email", "", "email address of the user")
+	revoke := flag.Bool("revoke", false, "take admin rights away instead")
+	flag.Parse()
+
+	envErr := godotenv.Load()
+	utils.InitLogger()
+	if envErr != nil {
+		slog.Warn("No .env file found, using environment variables")
+	}
+	if strings.TrimSpace(*email) == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	config.ConnectDB()
+	if err := waitForMongo(30 * time.Second); err != nil {
+		slog.Error("MongoDB not reachable", "error", err)
+		os.Exit(1)
+	}
+	defer config.Client.Disconnect(context.Background())
+
+	update := bson.M{"$set": bson.M{"admin": true}}
+	if *revoke {
+		update = bson.M{"$unset": bson.M{"admin": ""}}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	res, err := config.UserCollectionRef.UpdateOne(ctx, bson.M{"email": strings.TrimSpace(*email)}, update)
+	if err != nil {
+		slog.Error("Updating the user failed", "error", err)
+		os.Exit(1)
+	}
+	if res.MatchedCount == 0 {
+		slog.Error("No user with this email", "email", *email)
+		os.Exit(1)
+	}
+	slog.Info("Admin rights updated", "email", *email, "admin", !*revoke)
+}
+
+func waitForMongo(timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		err := config.Ping(ctx)
+		cancel()
+		if err == nil || time.Now().After(deadline) {
+			return err
+		}
+		time.Sleep(time.Second)
+	}
+}
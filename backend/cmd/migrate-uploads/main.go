@@ -0,0 +1,232 @@
+// Command migrate-uploads copies files from the old ./uploads directory into the
+// configured upload store and rewrites the profileImage paths that point at them.
+//
+//	go run ./cmd/migrate-uploads -src ./uploads [-dry-run] [-delete-source]
+//
+// It reads the same .env as the server. Running it again is safe: files are
+// overwritten with the same content and already rewritten paths no longer match.
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"flag"
+	"io"
+	"io/fs"
+	"log/slog"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"backend/config"
+	"backend/storage"
+	"backend/utils"
+
+	"github.com/joho/godotenv"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func main() {
+	src := flag.String("src", "./uploads", "directory holding the existing uploads")
+	dryRun := flag.Bool("dry-run", false, "only report what would be copied and rewritten")
+	deleteSource := flag.Bool("delete-source", false, "remove each source file once it is copied and referenced by its new path")
+	flag.Parse()
+
+	envErr := godotenv.Load()
+	utils.InitLogger()
+	if envErr != nil {
+		slog.Warn("No .env file found, using environment variables")
+	}
+
+	if err := storage.Init(); err != nil {
+		slog.Error("Failed to configure upload storage", "error", err)
+		os.Exit(1)
+	}
+
+	config.ConnectDB()
+	if err := waitForMongo(30 * time.Second); err != nil {
+		slog.Error("MongoDB not reachable", "error", err)
+		os.Exit(1)
+	}
+	defer config.Client.Disconnect(context.Background())
+
+	m := migrator{
+		src:          *src,
+		store:        storage.Default(),
+		inPlace:      sameDir(*src, storage.DefaultConfig()),
+		dryRun:       *dryRun,
+		deleteSource: *deleteSource,
+	}
+	if err := m.run(); err != nil {
+		slog.Error("Upload migration failed", "error", err, "copied", m.copied, "rewritten", m.rewritten)
+		os.Exit(1)
+	}
+	slog.Info("Upload migration finished", "files", m.files, "copied", m.copied,
+		"rewritten", m.rewritten, "failed", m.failed, "dryRun", m.dryRun)
+	if m.failed > 0 {
+		os.Exit(1)
+	}
+}
+
+type migrator struct {
+	src          string
+	store        storage.Store
+	inPlace      bool // the store is the local driver rooted at src
+	dryRun       bool
+	deleteSource bool
+
+	files, copied, rewritten, failed int
+}
+
+func (m *migrator) run() error {
+	return filepath.WalkDir(m.src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		// Skip hidden files, including the store's own temporary files
+		if strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() && path != m.src {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(m.src, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		m.files++
+
+		if err := m.migrate(path, rel); err != nil {
+			m.failed++
+			slog.Error("Could not migrate upload", "file", rel, "error", err)
+		}
+		return nil
+	})
+}
+
+// migrate copies one file and points the users referencing it at the new key
+func (m *migrator) migrate(path, rel string) error {
+	key := rel
+	if !storage.ValidKey(key) {
+		key = legacyKey(rel)
+	}
+	unchanged := m.inPlace && key == rel
+
+	log := slog.With("file", rel, "key", key)
+	if m.dryRun {
+		log.Info("Would migrate upload", "copy", !unchanged)
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	if !unchanged {
+		if err := m.put(ctx, path, key); err != nil {
+			return err
+		}
+		m.copied++
+	}
+
+	res, err := config.UserCollectionRef.UpdateMany(ctx,
+		bson.M{"profileImage": primitive.Regex{Pattern: `(^|/)uploads/` + regexp.QuoteMeta(rel) + `$`}},
+		bson.M{"$set": bson.M{"profileImage": storage.URL(key)}},
+	)
+	if err != nil {
+		return err
+	}
+	m.rewritten += int(res.ModifiedCount)
+	log.Info("Migrated upload", "copied", !unchanged, "usersUpdated", res.ModifiedCount)
+
+	if m.deleteSource && !unchanged {
+		if err := os.Remove(path); err != nil {
+			log.Warn("Could not remove source file", "error", err)
+		}
+	}
+	return nil
+}
+
+func (m *migrator) put(ctx context.Context, path, key string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	contentType, err := sniff(f, path)
+	if err != nil {
+		return err
+	}
+	return m.store.Put(ctx, key, f, info.Size(), contentType)
+}
+
+// sniff detects the content type from the first bytes and rewinds f
+func sniff(f *os.File, path string) (string, error) {
+	head := make([]byte, 512)
+	n, err := io.ReadFull(f, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+
+	contentType := http.DetectContentType(head[:n])
+	if contentType == "application/octet-stream" {
+		if byExt := mime.TypeByExtension(filepath.Ext(path)); byExt != "" {
+			contentType = byExt
+		}
+	}
+	return contentType, nil
+}
+
+var unsafeChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// legacyKey turns a file name from before names were generated (spaces, parentheses)
+// into a valid key. A short hash of the original path keeps distinct files apart.
+func legacyKey(rel string) string {
+	sum := sha256.Sum256([]byte(rel))
+	name := strings.Trim(unsafeChars.ReplaceAllString(filepath.Base(rel), "-"), "-.")
+	if name == "" {
+		name = "file"
+	}
+	return "legacy/" + hex.EncodeToString(sum[:4]) + "_" + name
+}
+
+// sameDir reports whether the configured store is the local directory being migrated
+func sameDir(src string, cfg storage.Config) bool {
+	if cfg.Driver != "local" {
+		return false
+	}
+	a, errA := filepath.Abs(src)
+	b, errB := filepath.Abs(cfg.LocalDir)
+	return errA == nil && errB == nil && a == b
+}
+
+func waitForMongo(timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		err := config.Ping(ctx)
+		cancel()
+		if err == nil || time.Now().After(deadline) {
+			return err
+		}
+		time.Sleep(time.Second)
+	}
+}
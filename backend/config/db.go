@@ -2,65 +2,131 @@ package config
 
 import (
 	"context"
-	"log"
+	"errors"
+	"log/slog"
+	"net/url"
 	"os"
 	"time"
 
+	"backend/lifecycle"
+	"backend/metrics"
+
 	"github.com/joho/godotenv"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"go.mongodb.org/mongo-driver/mongo/readpref"
 )
 
+var Client *mongo.Client
 var DB *mongo.Database
 var UserCollectionRef *mongo.Collection
 
+// Backoff bounds used while waiting for MongoDB at startup
+const (
+	initialBackoff = 500 * time.Millisecond
+	maxBackoff     = 30 * time.Second
+)
+
+// ConnectDB configures the client from the environment without waiting for the server.
+// Commands use it as is; the server also calls StartMigrations.
 func ConnectDB() {
 	// Load .env file
 	err := godotenv.Load()
 	if err != nil {
-		log.Println("⚠️ Couldn't load .env file, using environment variables")
+		slog.Debug("Couldn't load .env file, using environment variables")
 	}
 
 	mongoString := os.Getenv("MONGOSTRING")
 	dbName := os.Getenv("DB_NAME")
 	userCollection := os.Getenv("USER_COLLECTION")
-	log.Println("📚 Loading database configuration from environment variables", mongoString, dbName, userCollection)
+	slog.Info("Loading database configuration from environment variables",
+		"mongo", redactMongoURI(mongoString), "db", dbName, "userCollection", userCollection)
 
 	if mongoString == "" {
 		mongoString = "mongodb://localhost:27017"
-		log.Println("⚠️ MONGOSTRING not set, using default:", mongoString)
+		slog.Warn("MONGOSTRING not set, using default", "mongo", mongoString)
 	}
 
 	if dbName == "" {
 		dbName = "dbRPL"
-		log.Println("⚠️ DB_NAME not set, using default:", dbName)
+		slog.Warn("DB_NAME not set, using default", "db", dbName)
 	}
 
 	if userCollection == "" {
 		userCollection = "users"
-		log.Println("⚠️ USER_COLLECTION not set, using default:", userCollection)
+		slog.Warn("USER_COLLECTION not set, using default", "userCollection", userCollection)
 	}
 
-	// Set a shorter timeout for quicker feedback during development
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-
-	clientOptions := options.Client().ApplyURI(mongoString)
-
-	client, err := mongo.Connect(ctx, clientOptions)
-	if err != nil {
-		log.Fatal("❌ Failed to create MongoDB client:", err)
-	}
+	clientOptions := options.Client().ApplyURI(mongoString).SetMonitor(metrics.MongoMonitor())
 
-	// Ping the database to verify connection
-	err = client.Ping(ctx, readpref.Primary())
+	// mongo.Connect does not reach the server, it only validates options and starts the pool
+	client, err := mongo.Connect(context.Background(), clientOptions)
 	if err != nil {
-		log.Fatal("❌ Failed to connect to MongoDB. Is MongoDB running? Error:", err)
+		slog.Error("Failed to create MongoDB client", "error", err)
+		os.Exit(1)
 	}
 
+	Client = client
 	DB = client.Database(dbName)
 	UserCollectionRef = DB.Collection(userCollection)
 
-	log.Println("✅ MongoDB connected to database:", dbName)
+	lifecycle.OnStop("mongo", client.Disconnect)
+}
+
+// StartMigrations waits for the server in the background so a slow MongoDB start doesn't
+// crash the process, then applies the migrations. /readyz reports unavailable until the
+// first ping and migrations succeed. Call once after ConnectDB.
+func StartMigrations() {
+	go waitForMongo(DB.Name())
+}
+
+// Ping checks that the primary is reachable
+func Ping(ctx context.Context) error {
+	if Client == nil {
+		return errors.New("mongo client not initialised")
+	}
+	return Client.Ping(ctx, readpref.Primary())
+}
+
+// waitForMongo pings MongoDB with exponential backoff until it answers, then runs migrations
+func waitForMongo(dbName string) {
+	backoff := initialBackoff
+	for attempt := 1; ; attempt++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		err := Ping(ctx)
+		cancel()
+
+		if err == nil {
+			slog.Info("MongoDB connected", "db", dbName, "attempts", attempt)
+			break
+		}
+
+		slog.Warn("MongoDB not reachable yet, retrying", "attempt", attempt, "retryIn", backoff.String(), "error", err)
+		time.Sleep(backoff)
+		backoff = min(backoff*2, maxBackoff)
+	}
+
+	backoff = initialBackoff
+	for {
+		err := RunMigrations(context.Background())
+		if err == nil {
+			return
+		}
+
+		slog.Error("Migrations failed, retrying", "retryIn", backoff.String(), "error", err)
+		time.Sleep(backoff)
+		backoff = min(backoff*2, maxBackoff)
+	}
+}
+
+// redactMongoURI hides the password of a connection string before it is logged
+func redactMongoURI(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.User == nil {
+		return uri
+	}
+	if _, ok := u.User.Password(); ok {
+		u.User = url.UserPassword(u.User.Username(), "xxxxx")
+	}
+	return u.String()
 }
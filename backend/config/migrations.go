@@ -0,0 +1,372 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"backend/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Migration is a one-off schema change, applied once per database in ID order
+type Migration struct {
+	ID          string
+	Description string
+	Up          func(ctx context.Context, db *mongo.Database) error
+}
+
+// Migration states reported by MigrationStatus
+const (
+	MigrationsPending = "pending"
+	MigrationsRunning = "running"
+	MigrationsDone    = "done"
+	MigrationsFailed  = "failed"
+)
+
+const migrationsCollection = "schema_migrations"
+
+// migrations lists every schema change; append new entries, never reorder or edit applied ones
+var migrations = []Migration{
+	{
+		ID:          "0001_users_email_index",
+		Description: "index users by email for login and registration lookups",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			_, err := UserCollectionRef.Indexes().CreateOne(ctx, mongo.IndexModel{
+				Keys:    bson.D{{Key: "email", Value: 1}},
+				Options: options.Index().SetName("email_1"),
+			})
+			return err
+		},
+	},
+	{
+		ID:          "0002_emotions_indexes",
+		Description: "index emotions by user and creation time for history and stats",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			_, err := db.Collection("emotions").Indexes().CreateMany(ctx, []mongo.IndexModel{
+				{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}}},
+				{Keys: bson.D{{Key: "created_at", Value: -1}}},
+			})
+			return err
+		},
+	},
+	{
+		ID:          "0003_users_listing_indexes",
+		Description: "index users for sorted, filtered and paginated listings",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			_, err := UserCollectionRef.Indexes().CreateMany(ctx, []mongo.IndexModel{
+				{Keys: bson.D{{Key: "nama", Value: 1}, {Key: "_id", Value: 1}}},
+				{Keys: bson.D{{Key: "role", Value: 1}, {Key: "nama", Value: 1}, {Key: "_id", Value: 1}}},
+				{Keys: bson.D{{Key: "team", Value: 1}, {Key: "nama", Value: 1}, {Key: "_id", Value: 1}}},
+				{Keys: bson.D{{Key: "lastActive", Value: -1}, {Key: "_id", Value: -1}}},
+			})
+			return err
+		},
+	},
+	{
+		ID:          "0004_users_purge_index",
+		Description: "index accounts scheduled for deletion for the purge job",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			_, err := UserCollectionRef.Indexes().CreateOne(ctx, mongo.IndexModel{
+				Keys:    bson.D{{Key: "purgeAt", Value: 1}},
+				Options: options.Index().SetName("purgeAt_1").SetSparse(true),
+			})
+			return err
+		},
+	},
+	{
+		ID:          "0005_emotions_team",
+		Description: "copy each user's team onto their check-ins, index team aggregates and team names",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			cursor, err := UserCollectionRef.Find(ctx,
+				bson.M{"team": bson.M{"$nin": bson.A{nil, ""}}},
+				options.Find().SetProjection(bson.M{"_id": 1, "team": 1}))
+			if err != nil {
+				return err
+			}
+			defer cursor.Close(ctx)
+
+			emotions := db.Collection("emotions")
+			for cursor.Next(ctx) {
+				var user struct {
+					ID   interface{} `bson:"_id"`
+					Team string      `bson:"team"`
+				}
+				if err := cursor.Decode(&user); err != nil {
+					return err
+				}
+				// Check-ins reference users by ObjectID, even when the user's _id is a string
+				userID, ok := user.ID.(primitive.ObjectID)
+				if s, isString := user.ID.(string); isString {
+					userID, err = primitive.ObjectIDFromHex(s)
+					ok = err == nil
+				}
+				if !ok {
+					continue
+				}
+				_, err := emotions.UpdateMany(ctx,
+					bson.M{"user_id": userID, "team": bson.M{"$exists": false}},
+					bson.M{"$set": bson.M{"team": user.Team}})
+				if err != nil {
+					return err
+				}
+			}
+			if err := cursor.Err(); err != nil {
+				return err
+			}
+
+			if _, err := emotions.Indexes().CreateOne(ctx, mongo.IndexModel{
+				Keys: bson.D{{Key: "team", Value: 1}, {Key: "created_at", Value: -1}},
+			}); err != nil {
+				return err
+			}
+			_, err = db.Collection("teams").Indexes().CreateOne(ctx, mongo.IndexModel{
+				Keys:    bson.D{{Key: "name", Value: 1}},
+				Options: options.Index().SetName("name_1").SetUnique(true),
+			})
+			return err
+		},
+	},
+	{
+		ID:          "0006_emotions_pseudonym_index",
+		Description: "index anonymous check-ins by pseudonym and period for the submission limit",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			_, err := db.Collection("emotions").Indexes().CreateOne(ctx, mongo.IndexModel{
+				Keys:    bson.D{{Key: "pseudonym", Value: 1}, {Key: "period", Value: 1}},
+				Options: options.Index().SetSparse(true),
+			})
+			return err
+		},
+	},
+	{
+		ID:          "0007_mood_definitions",
+		Description: "seed the mood vocabulary and index it by key",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			collection := db.Collection("moods")
+			if _, err := collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+				Keys:    bson.D{{Key: "key", Value: 1}},
+				Options: options.Index().SetName("key_1").SetUnique(true),
+			}); err != nil {
+				return err
+			}
+
+			// $setOnInsert keeps definitions an organization already customised
+			writes := make([]mongo.WriteModel, 0, len(models.DefaultMoods))
+			for _, mood := range models.DefaultMoods {
+				writes = append(writes, mongo.NewUpdateOneModel().
+					SetFilter(bson.M{"key": mood.Key}).
+					SetUpdate(bson.M{"$setOnInsert": mood}).
+					SetUpsert(true))
+			}
+			_, err := collection.BulkWrite(ctx, writes)
+			return err
+		},
+	},
+	{
+		ID:          "0008_emotions_tags",
+		Description: "index emotion tags for the tag filter of the stats endpoints",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			_, err := db.Collection("emotions").Indexes().CreateOne(ctx, mongo.IndexModel{
+				Keys: bson.D{{Key: "tags", Value: 1}, {Key: "created_at", Value: -1}},
+			})
+			return err
+		},
+	},
+	{
+		ID:          "0009_emotions_dedupe",
+		Description: "unique indexes for check-in idempotency keys and one check-in per user per day",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			// Partial, so check-ins without a key or day are not constrained
+			_, err := db.Collection("emotions").Indexes().CreateMany(ctx, []mongo.IndexModel{
+				{
+					Keys: bson.D{{Key: "idempotency_key", Value: 1}},
+					Options: options.Index().SetName("idempotency_key_1").SetUnique(true).
+						SetPartialFilterExpression(bson.M{"idempotency_key": bson.M{"$exists": true}}),
+				},
+				{
+					Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "day", Value: 1}},
+					Options: options.Index().SetName("user_id_1_day_1").SetUnique(true).
+						SetPartialFilterExpression(bson.M{"day": bson.M{"$exists": true}}),
+				},
+			})
+			return err
+		},
+	},
+	{
+		ID:          "0010_reminders",
+		Description: "index users with reminders enabled and notifications by recipient",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			if _, err := UserCollectionRef.Indexes().CreateOne(ctx, mongo.IndexModel{
+				Keys:    bson.D{{Key: "reminder.enabled", Value: 1}},
+				Options: options.Index().SetPartialFilterExpression(bson.M{"reminder.enabled": true}),
+			}); err != nil {
+				return err
+			}
+			_, err := db.Collection("notifications").Indexes().CreateOne(ctx, mongo.IndexModel{
+				Keys: bson.D{{Key: "userId", Value: 1}, {Key: "createdAt", Value: -1}},
+			})
+			return err
+		},
+	},
+	{
+		ID:          "0011_notifications_unread",
+		Description: "index unread notifications per user for the badge count",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			_, err := db.Collection("notifications").Indexes().CreateOne(ctx, mongo.IndexModel{
+				Keys:    bson.D{{Key: "userId", Value: 1}},
+				Options: options.Index().SetName("userId_1_unread").SetPartialFilterExpression(bson.M{"read": false}),
+			})
+			return err
+		},
+	},
+	{
+		ID:          "0012_team_events_ttl",
+		Description: "expire team events an hour after they were streamed",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			_, err := db.Collection("team_events").Indexes().CreateOne(ctx, mongo.IndexModel{
+				Keys:    bson.D{{Key: "createdAt", Value: 1}},
+				Options: options.Index().SetName("createdAt_ttl").SetExpireAfterSeconds(3600),
+			})
+			return err
+		},
+	},
+	{
+		ID:          "0013_alerts",
+		Description: "index wellbeing alerts by episode, team and subject",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			// Partial, so any number of finished episodes can share a subject and rule
+			_, err := db.Collection("alerts").Indexes().CreateMany(ctx, []mongo.IndexModel{
+				{
+					Keys: bson.D{{Key: "key", Value: 1}},
+					Options: options.Index().SetName("key_1").SetUnique(true).
+						SetPartialFilterExpression(bson.M{"key": bson.M{"$exists": true}}),
+				},
+				{Keys: bson.D{{Key: "team", Value: 1}, {Key: "createdAt", Value: -1}}},
+				{Keys: bson.D{{Key: "team", Value: 1}, {Key: "status", Value: 1}, {Key: "createdAt", Value: -1}}},
+				{Keys: bson.D{{Key: "userId", Value: 1}}, Options: options.Index().SetSparse(true)},
+			})
+			return err
+		},
+	},
+	{
+		ID:          "0014_meeting_attendance",
+		Description: "index meeting attendance by user and time",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			_, err := db.Collection("meeting_attendance").Indexes().CreateOne(ctx, mongo.IndexModel{
+				Keys: bson.D{{Key: "userId", Value: 1}, {Key: "joinedAt", Value: -1}},
+			})
+			return err
+		},
+	},
+	{
+		ID:          "0015_meeting_attendees",
+		Description: "index meeting attendance by meeting, for who may be invited",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			_, err := db.Collection("meeting_attendance").Indexes().CreateOne(ctx, mongo.IndexModel{
+				Keys: bson.D{{Key: "meetingId", Value: 1}, {Key: "joinedAt", Value: -1}},
+			})
+			return err
+		},
+	},
+	{
+		ID:          "0016_anonymous_checkin_counts",
+		Description: "expire the per-pseudonym anonymous check-in counters after their period",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			_, err := db.Collection("anonymous_checkin_counts").Indexes().CreateOne(ctx, mongo.IndexModel{
+				Keys:    bson.D{{Key: "expiresAt", Value: 1}},
+				Options: options.Index().SetName("expiresAt_ttl").SetExpireAfterSeconds(0),
+			})
+			return err
+		},
+	},
+	{
+		ID:          "0017_users_email_unique",
+		Description: "make emails unique regardless of case; email_1 stays for exact lookups",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			_, err := UserCollectionRef.Indexes().CreateOne(ctx, mongo.IndexModel{
+				Keys:    bson.D{{Key: "email", Value: 1}},
+				Options: options.Index().SetName("email_unique").SetUnique(true).SetCollation(EmailCollation),
+			})
+			if err != nil {
+				return fmt.Errorf("accounts sharing an email must be merged first: %w", err)
+			}
+			return nil
+		},
+	},
+}
+
+var migrationState = struct {
+	sync.RWMutex
+	status  string
+	applied int
+	err     error
+}{status: MigrationsPending}
+
+// MigrationStatus reports the state of the startup migrations and how many are applied
+func MigrationStatus() (status string, applied int, err error) {
+	migrationState.RLock()
+	defer migrationState.RUnlock()
+	return migrationState.status, migrationState.applied, migrationState.err
+}
+
+func setMigrationState(status string, applied int, err error) {
+	migrationState.Lock()
+	defer migrationState.Unlock()
+	migrationState.status = status
+	migrationState.applied = applied
+	migrationState.err = err
+}
+
+// RunMigrations applies every migration not yet recorded in schema_migrations
+func RunMigrations(ctx context.Context) error {
+	setMigrationState(MigrationsRunning, 0, nil)
+
+	collection := DB.Collection(migrationsCollection)
+	applied := 0
+
+	for _, m := range migrations {
+		err := collection.FindOne(ctx, bson.M{"_id": m.ID}).Err()
+		if err == nil {
+			applied++
+			continue
+		}
+		if !errors.Is(err, mongo.ErrNoDocuments) {
+			setMigrationState(MigrationsFailed, applied, err)
+			return fmt.Errorf("checking migration %s: %w", m.ID, err)
+		}
+
+		start := time.Now()
+		stepCtx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+		err = m.Up(stepCtx, DB)
+		cancel()
+		if err != nil {
+			setMigrationState(MigrationsFailed, applied, err)
+			return fmt.Errorf("applying migration %s: %w", m.ID, err)
+		}
+
+		// Another replica may have recorded it concurrently; migrations are idempotent so that's fine
+		_, err = collection.InsertOne(ctx, bson.M{
+			"_id":         m.ID,
+			"description": m.Description,
+			"appliedAt":   time.Now(),
+		})
+		if err != nil && !mongo.IsDuplicateKeyError(err) {
+			setMigrationState(MigrationsFailed, applied, err)
+			return fmt.Errorf("recording migration %s: %w", m.ID, err)
+		}
+
+		applied++
+		slog.Info("Migration applied", "id", m.ID, "duration", time.Since(start).String())
+	}
+
+	setMigrationState(MigrationsDone, applied, nil)
+	slog.Info("Migrations up to date", "applied", applied)
+	return nil
+}
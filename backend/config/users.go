@@ -0,0 +1,27 @@
+package config
+
+import (
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// EmailCollation compares emails ignoring case, like the unique email index
+var EmailCollation = &options.Collation{Locale: "en", Strength: 2}
+
+// UserIDFilter matches a user whose _id is stored either as an ObjectID or a plain string
+func UserIDFilter(userID string) bson.M {
+	return UserIDsFilter([]string{userID})
+}
+
+// UserIDsFilter matches the users with any of the IDs, stored either way
+func UserIDsFilter(userIDs []string) bson.M {
+	ids := bson.A{}
+	for _, userID := range userIDs {
+		ids = append(ids, userID)
+		if objectID, err := primitive.ObjectIDFromHex(userID); err == nil {
+			ids = append(ids, objectID)
+		}
+	}
+	return bson.M{"_id": bson.M{"$in": ids}}
+}
@@ -0,0 +1,273 @@
+package controllers
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"path"
+	"time"
+
+	"backend/account"
+	"backend/apperror"
+	"backend/config"
+	"backend/meetings"
+	"backend/models"
+	"backend/notifications"
+	"backend/storage"
+	"backend/utils"
+	"backend/validation"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// exportedProfile is profile.json in a data export: everything stored about the user
+// except password hashes
+type exportedProfile struct {
+	ID            string                   `json:"id"`
+	Nama          string                   `json:"nama"`
+	Email         string                   `json:"email"`
+	Role          string                   `json:"role,omitempty"`
+	Team          string                   `json:"team,omitempty"`
+	Bio           string                   `json:"bio,omitempty"`
+	LastActive    time.Time                `json:"lastActive,omitempty"`
+	ProfileImage  string                   `json:"profileImage,omitempty"`
+	ProfileImages map[string]string        `json:"profileImages,omitempty"`
+	StatusMessage *models.StatusMessage    `json:"statusMessage,omitempty"`
+	Reminder      *models.ReminderSettings `json:"reminder,omitempty"`
+	AnonymousMode bool                     `json:"anonymousMode,omitempty"`
+	DeletedAt     *time.Time               `json:"deletedAt,omitempty"`
+	PurgeAt       *time.Time               `json:"purgeAt,omitempty"`
+	ExportedAt    time.Time                `json:"exportedAt"`
+}
+
+// ExportMyData returns a ZIP with the caller's profile, every check-in, their
+// notifications, their meeting attendance and their uploaded images
+func ExportMyData(c *fiber.Ctx) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var user models.User
+	if err := config.UserCollectionRef.FindOne(ctx, config.UserIDFilter(userID)).Decode(&user); err != nil {
+		return userLookupError(err)
+	}
+
+	emotions := []models.Emotion{}
+	if objectID, err := primitive.ObjectIDFromHex(user.ID); err == nil {
+		opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+		cursor, err := config.DB.Collection("emotions").Find(ctx, bson.M{"user_id": objectID}, opts)
+		if err != nil {
+			return apperror.Internal.Wrap(err)
+		}
+		if err := cursor.All(ctx, &emotions); err != nil {
+			return apperror.Internal.Wrap(err)
+		}
+	}
+
+	received := []models.Notification{}
+	opts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}})
+	cursor, err := config.DB.Collection(notifications.Collection).Find(ctx, bson.M{"userId": user.ID}, opts)
+	if err != nil {
+		return apperror.Internal.Wrap(err)
+	}
+	if err := cursor.All(ctx, &received); err != nil {
+		return apperror.Internal.Wrap(err)
+	}
+
+	attended, err := meetings.Attended(ctx, user.ID, time.Time{})
+	if err != nil {
+		return apperror.Internal.Wrap(err)
+	}
+
+	now := time.Now().UTC()
+	profile := exportedProfile{
+		ID:            user.ID,
+		Nama:          user.Nama,
+		Email:         user.Email,
+		Role:          user.Role,
+		Team:          user.Team,
+		Bio:           user.Bio,
+		LastActive:    user.LastActive,
+		ProfileImage:  user.ProfileImage,
+		ProfileImages: user.ProfileImages,
+		StatusMessage: user.StatusMessage,
+		Reminder:      user.Reminder,
+		AnonymousMode: user.AnonymousMode,
+		DeletedAt:     user.DeletedAt,
+		PurgeAt:       user.PurgeAt,
+		ExportedAt:    now,
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	if err := writeJSONEntry(zw, "profile.json", profile, now); err != nil {
+		return apperror.Internal.Wrap(err)
+	}
+	if err := writeJSONEntry(zw, "emotions.json", emotions, now); err != nil {
+		return apperror.Internal.Wrap(err)
+	}
+	if err := writeJSONEntry(zw, "notifications.json", received, now); err != nil {
+		return apperror.Internal.Wrap(err)
+	}
+	if err := writeJSONEntry(zw, "meetings.json", attended, now); err != nil {
+		return apperror.Internal.Wrap(err)
+	}
+
+	uploads := account.UploadKeys(user)
+	for _, key := range uploads {
+		if err := writeUploadEntry(ctx, zw, key); err != nil {
+			// A missing file shouldn't prevent exporting the rest
+			utils.Log(c).Warn("export: could not add upload", "key", key, "error", err)
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		return apperror.Internal.Wrap(err)
+	}
+
+	utils.Log(c).Info("personal data exported", "emotions", len(emotions), "images", len(uploads))
+
+	c.Set(fiber.HeaderContentType, "application/zip")
+	c.Set(fiber.HeaderContentDisposition, `attachment; filename="my-data-`+now.Format("2006-01-02")+`.zip"`)
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	return c.Send(buf.Bytes())
+}
+
+func writeJSONEntry(zw *zip.Writer, name string, v any, modified time.Time) error {
+	w, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: modified})
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// writeUploadEntry copies a stored object into images/ of the archive
+func writeUploadEntry(ctx context.Context, zw *zip.Writer, key string) error {
+	body, obj, err := storage.Default().Open(ctx, key)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	// Images are already compressed
+	w, err := zw.CreateHeader(&zip.FileHeader{Name: "images/" + path.Base(key), Method: zip.Store, Modified: obj.ModTime})
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, body)
+	return err
+}
+
+// GetMyPrivacy returns the caller's privacy preferences
+func GetMyPrivacy(c *fiber.Ctx) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var user models.User
+	err = config.UserCollectionRef.FindOne(ctx, config.UserIDFilter(userID),
+		options.FindOne().SetProjection(bson.M{"anonymousMode": 1}),
+	).Decode(&user)
+	if err != nil {
+		return userLookupError(err)
+	}
+	return c.JSON(models.PrivacyPreferences{AnonymousMode: user.AnonymousMode})
+}
+
+// SetMyPrivacy saves the caller's privacy preferences. Anonymous check-ins are never
+// linked back to anyone, so anonymous mode is how users who use them opt out of missing
+// check-in alerts.
+func SetMyPrivacy(c *fiber.Ctx) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+
+	var input models.PrivacyPreferences
+	if err := validation.ParseBody(c, &input); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	update := bson.M{"$unset": bson.M{"anonymousMode": ""}}
+	if input.AnonymousMode {
+		update = bson.M{"$set": bson.M{"anonymousMode": true}}
+	}
+	res, err := config.UserCollectionRef.UpdateOne(ctx, config.UserIDFilter(userID), update)
+	if err != nil {
+		return apperror.Internal.Wrap(err)
+	}
+	if res.MatchedCount == 0 {
+		return apperror.UserNotFound
+	}
+	return c.JSON(input)
+}
+
+// DeleteMyAccount schedules the caller's account for deletion. It disappears from the
+// app right away; logging in during the grace period restores it, afterwards the purge
+// job deletes it and anonymizes its check-ins.
+func DeleteMyAccount(c *fiber.Ctx) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+
+	var input models.DeleteAccountRequest
+	if err := validation.ParseBody(c, &input); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var user models.User
+	if err := config.UserCollectionRef.FindOne(ctx, config.UserIDFilter(userID)).Decode(&user); err != nil {
+		return userLookupError(err)
+	}
+
+	ok, _, err := utils.VerifyPassword(input.Password, user.Password)
+	if err != nil && !errors.Is(err, utils.ErrUnknownHash) {
+		return apperror.Internal.Wrap(err)
+	}
+	if !ok {
+		return apperror.CurrentPasswordMismatch
+	}
+
+	now := time.Now()
+	purgeAt := now.Add(account.GracePeriod())
+	if user.PurgeAt == nil {
+		_, err = config.UserCollectionRef.UpdateOne(ctx, config.UserIDFilter(userID),
+			bson.M{"$set": bson.M{"deletedAt": now, "purgeAt": purgeAt}})
+		if err != nil {
+			return apperror.Internal.Wrap(err)
+		}
+		account.Forget(userID)
+		utils.Log(c).Info("account deletion scheduled", "purgeAt", purgeAt)
+	} else {
+		// Deleting twice keeps the original schedule
+		purgeAt = *user.PurgeAt
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"message": "Account scheduled for deletion",
+		"purgeAt": purgeAt,
+	})
+}
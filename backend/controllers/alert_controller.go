@@ -0,0 +1,200 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"backend/apperror"
+	"backend/config"
+	"backend/models"
+	"backend/pagination"
+	"backend/risk"
+	"backend/utils"
+	"backend/validation"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const defaultAlertPageSize = 20
+
+// GetAlerts lists the wellbeing alerts of the caller's team, newest first. Only team
+// leaders may see them, and never the alerts about themselves.
+func GetAlerts(c *fiber.Ctx) error {
+	leader, err := teamLeader(c)
+	if err != nil {
+		return err
+	}
+
+	var query models.AlertListQuery
+	if err := validation.ParseQuery(c, &query); err != nil {
+		return err
+	}
+	if query.Limit == 0 {
+		query.Limit = defaultAlertPageSize
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	page := pagination.Page[models.Alert]{
+		Data: []models.Alert{},
+		Page: pagination.Info{Limit: query.Limit},
+	}
+
+	collection := config.DB.Collection(risk.Collection)
+	filter := alertsOf(leader)
+	if query.Status != "" {
+		filter["status"] = query.Status
+	}
+
+	if page.Page.Total, err = collection.CountDocuments(ctx, filter); err != nil {
+		return apperror.Internal.Wrap(err)
+	}
+
+	sort := pagination.Sort{Field: "createdAt", Desc: true}
+	after, err := sort.After(query.Cursor)
+	if err != nil {
+		return err
+	}
+	if after != nil {
+		filter = bson.M{"$and": bson.A{filter, after}}
+	}
+
+	// One extra document tells whether another page follows
+	opts := options.Find().SetSort(sort.Order()).SetLimit(int64(query.Limit + 1))
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		return apperror.Internal.Wrap(err)
+	}
+	defer cursor.Close(ctx)
+
+	var last bson.Raw
+	for cursor.Next(ctx) {
+		if len(page.Data) == query.Limit {
+			page.Page.NextCursor = pagination.CursorAfter(last, sort.Field)
+			break
+		}
+		// Current is only valid until the next call to Next
+		last = append(last[:0], cursor.Current...)
+
+		var a models.Alert
+		if err := cursor.Decode(&a); err != nil {
+			return apperror.Internal.Wrap(err)
+		}
+		page.Data = append(page.Data, a)
+	}
+	if err := cursor.Err(); err != nil {
+		return apperror.Internal.Wrap(err)
+	}
+
+	return c.JSON(page)
+}
+
+// AcknowledgeAlert records that a team leader is looking into an open alert.
+// Acknowledging it again changes nothing; a resolved alert can't be acknowledged.
+func AcknowledgeAlert(c *fiber.Ctx) error {
+	return changeAlert(c, models.AlertAcknowledged, bson.A{models.AlertOpen})
+}
+
+// ResolveAlert closes an alert. The rules won't raise it again until they stop matching
+// for its subject first.
+func ResolveAlert(c *fiber.Ctx) error {
+	return changeAlert(c, models.AlertResolved, bson.A{models.AlertOpen, models.AlertAcknowledged})
+}
+
+// changeAlert moves the alert named by :id from one of the statuses in from to status
+func changeAlert(c *fiber.Ctx, status string, from bson.A) error {
+	leader, err := teamLeader(c)
+	if err != nil {
+		return err
+	}
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return apperror.AlertIDInvalid
+	}
+
+	// The note is optional, and so is the body
+	var input models.AlertActionRequest
+	if len(c.Body()) > 0 {
+		if err := validation.ParseBody(c, &input); err != nil {
+			return err
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	set := bson.M{"status": status}
+	if status == models.AlertAcknowledged {
+		set["acknowledgedAt"], set["acknowledgedBy"] = now, leader.ID
+	} else {
+		set["resolvedAt"], set["resolvedBy"] = now, leader.ID
+	}
+	if input.Note != "" {
+		set["note"] = input.Note
+	}
+
+	filter := alertsOf(leader)
+	filter["_id"] = id
+	filter["status"] = bson.M{"$in": from}
+
+	collection := config.DB.Collection(risk.Collection)
+	var alert models.Alert
+	err = collection.FindOneAndUpdate(ctx, filter, bson.M{"$set": set},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&alert)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		// Unknown, not the caller's team, or already past the requested status
+		delete(filter, "status")
+		err = collection.FindOne(ctx, filter).Decode(&alert)
+		switch {
+		case errors.Is(err, mongo.ErrNoDocuments):
+			return apperror.AlertNotFound
+		case err != nil:
+			return apperror.Internal.Wrap(err)
+		case alert.Status == models.AlertResolved && status != models.AlertResolved:
+			return apperror.AlertResolved
+		}
+		return c.JSON(alert)
+	}
+	if err != nil {
+		return apperror.Internal.Wrap(err)
+	}
+
+	utils.Log(c).Info("alert updated", "alert", id.Hex(), "status", status)
+	return c.JSON(alert)
+}
+
+// teamLeader loads the caller and checks that they lead a team
+func teamLeader(c *fiber.Ctx) (models.User, error) {
+	var user models.User
+	userID, err := currentUserID(c)
+	if err != nil {
+		return user, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err = config.UserCollectionRef.FindOne(ctx, config.UserIDFilter(userID),
+		options.FindOne().SetProjection(bson.M{"role": 1, "team": 1}),
+	).Decode(&user)
+	if err != nil {
+		return user, userLookupError(err)
+	}
+	if user.Role != teamLeaderRole || user.Team == "" {
+		return user, apperror.AuthForbidden
+	}
+	return user, nil
+}
+
+// alertsOf matches the alerts a leader may see: their team's, except those about them
+func alertsOf(leader models.User) bson.M {
+	return bson.M{"team": leader.Team, "userId": bson.M{"$ne": leader.ID}}
+}
@@ -2,42 +2,64 @@ package controllers
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 
+	"backend/account"
+	"backend/apperror"
 	"backend/config"
+	"backend/metrics"
 	"backend/models"
 	"backend/utils"
+	"backend/validation"
 )
 
 func Register(c *fiber.Ctx) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	var user models.User
-	if err := c.BodyParser(&user); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Request tidak valid"})
+	var input models.RegisterRequest
+	if err := validation.ParseBody(c, &input); err != nil {
+		return err
 	}
 
 	// Check if email already exists
 	var existingUser models.User
-	err := config.UserCollectionRef.FindOne(ctx, bson.M{"email": user.Email}).Decode(&existingUser)
+	err := config.UserCollectionRef.FindOne(ctx, bson.M{"email": input.Email},
+		options.FindOne().SetCollation(config.EmailCollation),
+	).Decode(&existingUser)
 	if err == nil {
-		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "Email sudah terdaftar"})
+		return emailTaken
+	}
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return apperror.Internal.Wrap(err)
 	}
 
 	// Hash password using our utility function
-	hashedPassword, err := utils.HashPassword(user.Password)
+	hashedPassword, err := utils.HashPassword(input.Password)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Gagal hash password"})
+		return apperror.Internal.Wrap(err)
+	}
+
+	user := models.User{
+		Nama:            input.Nama,
+		Email:           input.Email,
+		Password:        hashedPassword,
+		Bio:             input.Bio,
+		PasswordHistory: []string{hashedPassword},
 	}
-	user.Password = hashedPassword
 
 	_, err = config.UserCollectionRef.InsertOne(ctx, user)
+	if mongo.IsDuplicateKeyError(err) {
+		return emailTaken
+	}
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Gagal menyimpan user"})
+		return apperror.Internal.Wrap(err)
 	}
 
 	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
@@ -53,32 +75,57 @@ func Login(c *fiber.Ctx) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	var input struct {
-		Email    string `json:"email"`
-		Password string `json:"password"`
-	}
-
-	if err := c.BodyParser(&input); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Request tidak valid"})
+	var input models.LoginRequest
+	if err := validation.ParseBody(c, &input); err != nil {
+		return err
 	}
 
 	var user models.User
 	err := config.UserCollectionRef.FindOne(ctx, bson.M{"email": input.Email}).Decode(&user)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		utils.VerifyDummy(input.Password)
+		utils.Log(c).Info("login failed", "reason", "unknown_email")
+		metrics.LoginFailed("unknown_email")
+		return apperror.AuthInvalidCredentials
+	}
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Email tidak ditemukan"})
+		return apperror.Internal.Wrap(err)
 	}
 
-	// Check password using our utility function
-	if !utils.CheckPasswordHash(input.Password, user.Password) {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Password salah"})
+	ok, needsRehash, err := utils.VerifyPassword(input.Password, user.Password)
+	if err != nil && !errors.Is(err, utils.ErrUnknownHash) {
+		return apperror.Internal.Wrap(err)
+	}
+	if !ok {
+		utils.Log(c).Info("login failed", "reason", "wrong_password", "user_id", user.ID)
+		metrics.LoginFailed("wrong_password")
+		return apperror.AuthInvalidCredentials
+	}
+
+	if user.Purging {
+		utils.Log(c).Info("login failed", "reason", "account_purging", "user_id", user.ID)
+		metrics.LoginFailed("account_purging")
+		return apperror.AuthInvalidCredentials
+	}
+	if user.DeletedAt != nil {
+		if err := restoreAccount(ctx, user); err != nil {
+			return err
+		}
+		utils.Log(c).Info("account deletion cancelled by login", "user_id", user.ID)
+	}
+
+	if needsRehash {
+		upgradePasswordHash(ctx, c, user, input.Password)
 	}
 
 	// Generate JWT using our utility function
 	tokenString, err := utils.GenerateJWT(user.ID, user.Email, user.Nama)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Gagal membuat token"})
+		return apperror.Internal.Wrap(err)
 	}
 
+	metrics.LoginSucceeded()
+
 	return c.JSON(fiber.Map{
         "message": "Login berhasil",
         "token":   tokenString,
@@ -91,3 +138,49 @@ func Login(c *fiber.Ctx) error {
         },
     })
 }
+
+// restoreAccount cancels a pending deletion, unless the purge job has already claimed it
+func restoreAccount(ctx context.Context, user models.User) error {
+	res, err := config.UserCollectionRef.UpdateOne(ctx,
+		bson.M{"$and": bson.A{config.UserIDFilter(user.ID), bson.M{"purging": bson.M{"$ne": true}}}},
+		bson.M{"$unset": bson.M{"deletedAt": "", "purgeAt": ""}},
+	)
+	if err != nil {
+		return apperror.Internal.Wrap(err)
+	}
+	if res.MatchedCount == 0 {
+		return apperror.AuthInvalidCredentials
+	}
+	account.Forget(user.ID)
+	return nil
+}
+
+// upgradePasswordHash re-hashes a verified password with the current algorithm and
+// parameters. Failures are only logged: the old hash still works and the next login retries.
+func upgradePasswordHash(ctx context.Context, c *fiber.Ctx, user models.User, password string) {
+	hash, err := utils.HashPassword(password)
+	if err != nil {
+		utils.Log(c).Warn("password rehash failed", "user_id", user.ID, "error", err)
+		return
+	}
+
+	// The current hash is the newest history entry; swap it so reuse checks keep matching
+	history := append([]string(nil), passwordHistory(user)...)
+	if n := len(history); n > 0 && history[n-1] == user.Password {
+		history[n-1] = hash
+	} else {
+		history = append(history, hash)
+	}
+
+	filter := config.UserIDFilter(user.ID)
+	filter["password"] = user.Password // skip if the password changed meanwhile
+	if _, err := config.UserCollectionRef.UpdateOne(ctx, filter, bson.M{"$set": bson.M{
+		"password":        hash,
+		"passwordHistory": history,
+	}}); err != nil {
+		utils.Log(c).Warn("password rehash failed", "user_id", user.ID, "error", err)
+		return
+	}
+
+	utils.Log(c).Info("password hash upgraded", "user_id", user.ID)
+}
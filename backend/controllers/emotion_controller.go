@@ -2,66 +2,625 @@ package controllers
 
 import (
     "context"
+    "crypto/sha256"
+    "encoding/hex"
+    "errors"
+    "slices"
+    "strconv"
+    "strings"
     "time"
     
     "github.com/gofiber/fiber/v2"
     "go.mongodb.org/mongo-driver/bson"
     "go.mongodb.org/mongo-driver/bson/primitive"
+    "go.mongodb.org/mongo-driver/mongo"
     "go.mongodb.org/mongo-driver/mongo/options"
     
+    "backend/apperror"
+    "backend/checkin"
     "backend/config"
+    "backend/events"
+    "backend/metrics"
     "backend/models"
+    "backend/moods"
+    "backend/privacy"
+    "backend/utils"
+    "backend/validation"
 )
 
-// SaveEmotion menyimpan data emosi pengguna
+// SaveEmotion menyimpan data emosi pengguna. Dengan header Idempotency-Key, request
+// yang diulang (misalnya klik ganda) mengembalikan check-in pertama tanpa menyimpan lagi.
 func SaveEmotion(c *fiber.Ctx) error {
     ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
     defer cancel()
 
-    var emotion models.Emotion
-    if err := c.BodyParser(&emotion); err != nil {
-        return c.Status(400).JSON(fiber.Map{"error": "Format request tidak valid"})
+    var input models.EmotionRequest
+    if err := validation.ParseBody(c, &input); err != nil {
+        return err
+    }
+    if err := validateIdempotencyKey(c); err != nil {
+        return err
+    }
+    if input.Anonymous {
+        return saveAnonymousEmotion(ctx, c, input)
     }
 
-    // Set waktu pembuatan
-    emotion.CreatedAt = time.Now()
-    
-    // Generate ID baru jika tidak disediakan
-    if emotion.ID.IsZero() {
-        emotion.ID = primitive.NewObjectID()
+    // Check-in selalu milik pemilik token; user_id di body hanya boleh menyebut dirinya sendiri
+    caller, err := currentUserID(c)
+    if err != nil {
+        return err
+    }
+    if input.UserID != "" && input.UserID != caller {
+        return apperror.AuthForbidden
+    }
+    userID, err := primitive.ObjectIDFromHex(caller)
+    if err != nil {
+        return apperror.UserIDInvalid.Wrap(err)
     }
 
-    // Masukkan ke database
-    result, err := config.DB.Collection("emotions").InsertOne(ctx, emotion)
+    // Idempotency keys are scoped to the caller, so clients can't collide with each other
+    idempotencyKey := idempotencyHash(c, caller)
+    if replayed, err := replayCheckin(ctx, c, idempotencyKey); replayed || err != nil {
+        return err
+    }
+
+    // Check-ins keep the team the user belonged to at the time, for team aggregates
+    team, err := userTeam(ctx, caller)
     if err != nil {
-        return c.Status(500).JSON(fiber.Map{"error": "Gagal menyimpan data emosi"})
+        return apperror.Internal.Wrap(err)
     }
 
+    now := time.Now()
+    settings := checkin.Config()
+    emotion := models.Emotion{
+        ID:             primitive.NewObjectID(),
+        UserID:         userID,
+        UserName:       input.UserName,
+        Team:           team,
+        Mood:           input.Mood,
+        Note:           input.Note,
+        CreatedAt:      now,
+        Energy:         input.Energy,
+        Stress:         input.Stress,
+        Workload:       input.Workload,
+        Sleep:          input.Sleep,
+        Tags:           normalizeTags(input.Tags),
+        IdempotencyKey: idempotencyKey,
+    }
+    if settings.DailyUpsert {
+        emotion.Day = settings.DayKey(now)
+    }
+
+    // Masukkan ke database; dengan DailyUpsert check-in hari ini diganti, bukan ditambah
+    replaced, err := storeCheckin(ctx, emotion)
+    if mongo.IsDuplicateKeyError(err) && idempotencyKey != "" {
+        // A concurrent request with the same key won the race
+        if replayed, err := replayCheckin(ctx, c, idempotencyKey); replayed || err != nil {
+            return err
+        }
+    }
+    if err != nil {
+        return apperror.Internal.Wrap(err)
+    }
+
+    metrics.CheckinsSaved.WithLabelValues(emotion.Mood).Inc()
+    announceCheckins(c, team)
+
+    if replaced != nil {
+        return c.JSON(fiber.Map{
+            "message": "Check-in hari ini diperbarui",
+            "id":      replaced.ID,
+            "updated": true,
+        })
+    }
     return c.Status(201).JSON(fiber.Map{
         "message": "Emosi berhasil dicatat",
-        "id": result.InsertedID,
+        "id": emotion.ID,
+    })
+}
+
+// storeCheckin inserts emotion, or with a Day set replaces the user's check-in of that
+// day and returns it. The unique {user_id, day} index turns a concurrent insert for the
+// same day into a duplicate key error, after which the replace is tried once more.
+func storeCheckin(ctx context.Context, emotion models.Emotion) (*models.Emotion, error) {
+    emotions := config.DB.Collection("emotions")
+    if emotion.Day == "" {
+        _, err := emotions.InsertOne(ctx, emotion)
+        return nil, err
+    }
+
+    for attempt := 0; ; attempt++ {
+        var existing models.Emotion
+        err := emotions.FindOne(ctx, bson.M{"user_id": emotion.UserID, "day": emotion.Day}).Decode(&existing)
+        if errors.Is(err, mongo.ErrNoDocuments) {
+            _, err = emotions.InsertOne(ctx, emotion)
+            if mongo.IsDuplicateKeyError(err) && attempt == 0 && !sameIdempotencyKey(err) {
+                continue
+            }
+            return nil, err
+        }
+        if err != nil {
+            return nil, err
+        }
+
+        // Keep the identity and creation time of the day's first check-in
+        now := emotion.CreatedAt
+        emotion.ID = existing.ID
+        emotion.CreatedAt = existing.CreatedAt
+        emotion.UpdatedAt = &now
+        if _, err := emotions.ReplaceOne(ctx, bson.M{"_id": existing.ID}, emotion); err != nil {
+            return nil, err
+        }
+        return &emotion, nil
+    }
+}
+
+// duplicateKeyCode is MongoDB's E11000 duplicate key error
+const duplicateKeyCode = 11000
+
+// sameIdempotencyKey tells a duplicate idempotency key apart from a duplicate day by the
+// key pattern of the index that was violated
+func sameIdempotencyKey(err error) bool {
+    var we mongo.WriteException
+    if !errors.As(err, &we) {
+        return false
+    }
+    for _, writeErr := range we.WriteErrors {
+        if writeErr.Code != duplicateKeyCode {
+            continue
+        }
+        if _, lookupErr := writeErr.Raw.LookupErr("keyPattern", "idempotency_key"); lookupErr == nil {
+            return true
+        }
+    }
+    return false
+}
+
+// validateIdempotencyKey rejects Idempotency-Key headers that are too long to be a key
+func validateIdempotencyKey(c *fiber.Ctx) error {
+    if len(c.Get("Idempotency-Key")) > 255 {
+        return apperror.ValidationFailed.WithDetails(apperror.FieldError{
+            Field: "Idempotency-Key", Code: "max", Param: "255",
+        })
+    }
+    return nil
+}
+
+// idempotencyHash combines the request's Idempotency-Key with who sent it; it is "" when
+// the header is absent. Only the hash is stored, so it reveals neither.
+func idempotencyHash(c *fiber.Ctx, scope string) string {
+    key := c.Get("Idempotency-Key")
+    if key == "" {
+        return ""
+    }
+    sum := sha256.Sum256([]byte(scope + "\x00" + key))
+    return hex.EncodeToString(sum[:])
+}
+
+// replayCheckin answers with the check-in an earlier request with the same idempotency
+// key created. It reports false when there is no key or no such check-in.
+func replayCheckin(ctx context.Context, c *fiber.Ctx, idempotencyKey string) (bool, error) {
+    if idempotencyKey == "" {
+        return false, nil
+    }
+
+    var existing models.Emotion
+    err := config.DB.Collection("emotions").FindOne(ctx, bson.M{"idempotency_key": idempotencyKey}).Decode(&existing)
+    if errors.Is(err, mongo.ErrNoDocuments) {
+        return false, nil
+    }
+    if err != nil {
+        return true, apperror.Internal.Wrap(err)
+    }
+
+    c.Set("Idempotent-Replayed", "true")
+    return true, c.JSON(fiber.Map{
+        "message":   "Emosi berhasil dicatat",
+        "id":        existing.ID,
+        "anonymous": existing.Anonymous,
+    })
+}
+
+// UpdateEmotion lets users correct their own check-in within the edit window
+func UpdateEmotion(c *fiber.Ctx) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    var input models.EmotionUpdateRequest
+    if err := validation.ParseBody(c, &input); err != nil {
+        return err
+    }
+
+    emotion, err := ownCheckin(ctx, c)
+    if err != nil {
+        return err
+    }
+
+    set := bson.M{}
+    unset := bson.M{}
+    if input.Mood != nil {
+        set["mood"] = *input.Mood
+    }
+    if input.Note != nil {
+        set["note"] = *input.Note
+    }
+    for dimension, value := range map[string]*int{
+        "energy":   input.Energy,
+        "stress":   input.Stress,
+        "workload": input.Workload,
+        "sleep":    input.Sleep,
+    } {
+        switch {
+        case value == nil:
+        case *value == 0:
+            unset[dimension] = ""
+        default:
+            set[dimension] = *value
+        }
+    }
+    if input.Tags != nil {
+        if tags := normalizeTags(*input.Tags); len(tags) > 0 {
+            set["tags"] = tags
+        } else {
+            unset["tags"] = ""
+        }
+    }
+    if len(set) == 0 && len(unset) == 0 {
+        return apperror.NoFieldsToUpdate
+    }
+
+    set["updated_at"] = time.Now()
+    update := bson.M{"$set": set}
+    if len(unset) > 0 {
+        update["$unset"] = unset
+    }
+
+    var updated models.Emotion
+    err = config.DB.Collection("emotions").FindOneAndUpdate(ctx,
+        bson.M{"_id": emotion.ID},
+        update,
+        options.FindOneAndUpdate().SetReturnDocument(options.After),
+    ).Decode(&updated)
+    if errors.Is(err, mongo.ErrNoDocuments) {
+        return apperror.EmotionNotFound
+    }
+    if err != nil {
+        return apperror.Internal.Wrap(err)
+    }
+
+    // Team dashboards refresh their stats on this, since the mood may have changed
+    announceCheckins(c, updated.Team)
+
+    return c.JSON(updated)
+}
+
+// DeleteEmotion lets users remove their own check-in within the edit window
+func DeleteEmotion(c *fiber.Ctx) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    emotion, err := ownCheckin(ctx, c)
+    if err != nil {
+        return err
+    }
+
+    result, err := config.DB.Collection("emotions").DeleteOne(ctx, bson.M{"_id": emotion.ID})
+    if err != nil {
+        return apperror.Internal.Wrap(err)
+    }
+    if result.DeletedCount == 0 {
+        return apperror.EmotionNotFound
+    }
+
+    announceCheckins(c, emotion.Team)
+
+    return c.JSON(fiber.Map{"message": "Check-in berhasil dihapus"})
+}
+
+// ownCheckin loads the check-in named by :id if the caller made it and it is still
+// editable. Other users' check-ins are reported as not found so their ids leak nothing;
+// anonymous and anonymized check-ins have no user_id and are never editable.
+func ownCheckin(ctx context.Context, c *fiber.Ctx) (*models.Emotion, error) {
+    id, err := primitive.ObjectIDFromHex(c.Params("id"))
+    if err != nil {
+        return nil, apperror.EmotionIDInvalid
+    }
+    caller, err := currentUserID(c)
+    if err != nil {
+        return nil, err
+    }
+    userID, err := primitive.ObjectIDFromHex(caller)
+    if err != nil {
+        return nil, apperror.EmotionNotFound
+    }
+
+    var emotion models.Emotion
+    err = config.DB.Collection("emotions").FindOne(ctx, bson.M{"_id": id, "user_id": userID}).Decode(&emotion)
+    if errors.Is(err, mongo.ErrNoDocuments) {
+        return nil, apperror.EmotionNotFound
+    }
+    if err != nil {
+        return nil, apperror.Internal.Wrap(err)
+    }
+
+    settings := checkin.Config()
+    if !settings.Editable(emotion.CreatedAt, time.Now()) {
+        return nil, apperror.CheckinEditWindowClosed.With("window", settings.EditWindow.String())
+    }
+    return &emotion, nil
+}
+
+// saveAnonymousEmotion stores a check-in with only the team and a rotating pseudonym.
+// The pseudonym is derived from the token's user, not the body, so the per-period limit
+// can't be sidestepped by sending another user_id.
+func saveAnonymousEmotion(ctx context.Context, c *fiber.Ctx, input models.EmotionRequest) error {
+    userID, err := currentUserID(c)
+    if err != nil {
+        return err
+    }
+
+    team, err := userTeam(ctx, userID)
+    if err != nil {
+        return apperror.Internal.Wrap(err)
+    }
+
+    now := time.Now()
+    settings := privacy.Anonymous()
+    period := settings.PeriodKey(now)
+    pseudonym := settings.Pseudonym(userID, period)
+
+    // Scoping the key to the pseudonym keeps it from linking the check-in to the user
+    idempotencyKey := idempotencyHash(c, pseudonym)
+    if replayed, err := replayCheckin(ctx, c, idempotencyKey); replayed || err != nil {
+        return err
+    }
+
+    reserved, err := reserveAnonymousCheckin(ctx, settings, pseudonym, now)
+    if err != nil {
+        return apperror.Internal.Wrap(err)
+    }
+    if !reserved {
+        return apperror.AnonymousCheckinLimit.With("limit", settings.Limit).With("period", settings.Period)
+    }
+
+    emotion := models.Emotion{
+        ID:             primitive.NewObjectID(),
+        Team:           team,
+        Mood:           input.Mood,
+        CreatedAt:      now,
+        Energy:         input.Energy,
+        Stress:         input.Stress,
+        Workload:       input.Workload,
+        Sleep:          input.Sleep,
+        Anonymous:      true,
+        Pseudonym:      pseudonym,
+        Period:         period,
+        IdempotencyKey: idempotencyKey,
+    }
+    if _, err := config.DB.Collection("emotions").InsertOne(ctx, emotion); err != nil {
+        releaseAnonymousCheckin(ctx, c, pseudonym)
+        if mongo.IsDuplicateKeyError(err) && idempotencyKey != "" {
+            if replayed, err := replayCheckin(ctx, c, idempotencyKey); replayed || err != nil {
+                return err
+            }
+        }
+        return apperror.Internal.Wrap(err)
+    }
+
+    metrics.CheckinsSaved.WithLabelValues(emotion.Mood).Inc()
+    // Announced with the rest of its batch, so its timing doesn't give anyone away
+    events.AnonymousCheckin(team)
+
+    return c.Status(201).JSON(fiber.Map{
+        "message":   "Emosi berhasil dicatat secara anonim",
+        "id":        emotion.ID,
+        "anonymous": true,
     })
 }
 
-// GetEmotionStats mengambil statistik emosi untuk visualisasi
+// anonymousCheckinCounts holds how many anonymous check-ins each pseudonym has made
+const anonymousCheckinCounts = "anonymous_checkin_counts"
+
+// reserveAnonymousCheckin counts a check-in against the pseudonym's limit in one atomic
+// update, so concurrent submissions can't both pass a separate count. It reports false
+// once the limit is reached.
+func reserveAnonymousCheckin(ctx context.Context, settings privacy.AnonymousSettings, pseudonym string, now time.Time) (bool, error) {
+    // Counters outlive their period, so the limit holds until the pseudonym has changed
+    keep := 2 * 24 * time.Hour
+    if settings.Period == "week" {
+        keep = 8 * 24 * time.Hour
+    }
+
+    counts := config.DB.Collection(anonymousCheckinCounts)
+    for attempt := 0; ; attempt++ {
+        _, err := counts.UpdateOne(ctx,
+            bson.M{"_id": pseudonym, "count": bson.M{"$lt": settings.Limit}},
+            bson.M{"$inc": bson.M{"count": 1}, "$setOnInsert": bson.M{"expiresAt": now.Add(keep)}},
+            options.Update().SetUpsert(true),
+        )
+        if err == nil {
+            return true, nil
+        }
+        // A duplicate _id means the counter exists but is at the limit, or that a
+        // concurrent first check-in created it; the retry tells them apart
+        if !mongo.IsDuplicateKeyError(err) {
+            return false, err
+        }
+        if attempt > 0 {
+            return false, nil
+        }
+    }
+}
+
+// releaseAnonymousCheckin gives back a reservation whose check-in wasn't stored
+func releaseAnonymousCheckin(ctx context.Context, c *fiber.Ctx, pseudonym string) {
+    _, err := config.DB.Collection(anonymousCheckinCounts).UpdateOne(ctx,
+        bson.M{"_id": pseudonym, "count": bson.M{"$gt": 0}},
+        bson.M{"$inc": bson.M{"count": -1}},
+    )
+    if err != nil {
+        utils.Log(c).Warn("could not release an anonymous check-in reservation", "error", err)
+    }
+}
+
+// GetEmotionStats mengambil statistik emosi untuk visualisasi. Mood yang dipilih oleh
+// kurang dari k pengguna digabung ke "other" (k-anonymity) agar tidak ada yang bisa
+// ditebak dari jumlahnya.
 func GetEmotionStats(c *fiber.Ctx) error {
     ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
     defer cancel()
 
-    // Tentukan pipeline agregasi
+    var query models.EmotionStatsQuery
+    if err := validation.ParseQuery(c, &query); err != nil {
+        return err
+    }
+
+    match := statsMatch(query.Period, query.Team, query.Tag)
+
+    policy, err := privacy.TeamPolicy(ctx, query.Team)
+    if err != nil {
+        return apperror.Internal.Wrap(err)
+    }
+    policy.Query = statsQueryKey("moods", query.Period, query.Team, query.Tag)
+
+    // Tentukan pipeline agregasi; distinct users per mood decide what may be shown
     pipeline := []bson.M{
+        {"$match": match},
         {
             "$group": bson.M{
-                "_id": "$mood",
+                "_id":   "$mood",
                 "count": bson.M{"$sum": 1},
+                "users": bson.M{"$addToSet": privacy.ContributorExpr},
             },
         },
     }
 
+    cursor, err := config.DB.Collection("emotions").Aggregate(ctx, pipeline)
+    if err != nil {
+        return apperror.Internal.Wrap(err)
+    }
+    defer cursor.Close(ctx)
+
+    var groups []struct {
+        Mood  string   `bson:"_id"`
+        Count int      `bson:"count"`
+        Users []string `bson:"users"`
+    }
+    if err = cursor.All(ctx, &groups); err != nil {
+        return apperror.Internal.Wrap(err)
+    }
+
+    buckets := make([]privacy.Bucket, 0, len(groups))
+    for _, g := range groups {
+        buckets = append(buckets, privacy.Bucket{Key: g.Mood, Count: g.Count, Users: g.Users})
+    }
+
+    result := privacy.Apply(buckets, policy)
+
+    results := moodStats(result.Buckets, apperror.Locale(c))
+
+    setPrivacyHeaders(c, policy, result)
+    // The body stays a list for existing clients, so the overall score travels in a header
+    if mean, ok := valenceMean(results); ok {
+        c.Set("X-Valence-Mean", strconv.FormatFloat(mean, 'f', 2, 64))
+    }
+    return c.Status(200).JSON(results)
+}
+
+// GetDimensionStats summarises the 1–5 ratings (energy, stress, workload, sleep) of the
+// check-ins matching the filters: mean, min, max and percentiles per dimension. A
+// dimension rated by fewer than k users is reported as suppressed.
+func GetDimensionStats(c *fiber.Ctx) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    var query models.DimensionStatsQuery
+    if err := validation.ParseQuery(c, &query); err != nil {
+        return err
+    }
+
+    dimensions := models.Dimensions
+    if query.Dimensions != "" {
+        dimensions = nil
+        for _, d := range strings.Split(query.Dimensions, ",") {
+            d = strings.TrimSpace(d)
+            if !slices.Contains(models.Dimensions, d) {
+                return apperror.ValidationFailed.WithDetails(apperror.FieldError{
+                    Field: "dimensions", Code: "oneof", Param: strings.Join(models.Dimensions, " "),
+                })
+            }
+            if !slices.Contains(dimensions, d) {
+                dimensions = append(dimensions, d)
+            }
+        }
+    }
+
+    policy, err := privacy.TeamPolicy(ctx, query.Team)
+    if err != nil {
+        return apperror.Internal.Wrap(err)
+    }
+    policy.Query = statsQueryKey("dimensions", query.Period, query.Team, query.Tag)
+
+    // One pass builds a value histogram per dimension; 1–5 scales keep it tiny
+    group := bson.M{"_id": nil}
+    for _, d := range dimensions {
+        group[d] = bson.M{"$push": bson.M{"$cond": bson.A{
+            bson.M{"$gt": bson.A{"$" + d, nil}},
+            bson.M{"v": "$" + d, "u": privacy.ContributorExpr},
+            "$$REMOVE",
+        }}}
+    }
+    pipeline := []bson.M{
+        {"$match": statsMatch(query.Period, query.Team, query.Tag)},
+        {"$group": group},
+    }
+
+    cursor, err := config.DB.Collection("emotions").Aggregate(ctx, pipeline)
+    if err != nil {
+        return apperror.Internal.Wrap(err)
+    }
+    defer cursor.Close(ctx)
+
+    type rating struct {
+        Value int    `bson:"v"`
+        User  string `bson:"u"`
+    }
+    var rows []map[string][]rating
+    if err = cursor.All(ctx, &rows); err != nil {
+        return apperror.Internal.Wrap(err)
+    }
+
+    results := make([]models.DimensionStats, 0, len(dimensions))
+    suppressed := false
+    for _, d := range dimensions {
+        h := privacy.Histogram{Counts: map[int]int{}}
+        seen := map[string]bool{}
+        if len(rows) > 0 {
+            for _, r := range rows[0][d] {
+                h.Counts[r.Value]++
+                if !seen[r.User] {
+                    seen[r.User] = true
+                    h.Users = append(h.Users, r.User)
+                }
+            }
+        }
+        stats := privacy.Summarize(d, h, policy)
+        suppressed = suppressed || stats.Suppressed
+        results = append(results, stats)
+    }
+
+    setPrivacyHeaders(c, policy, privacy.Result{Suppressed: suppressed})
+    return c.JSON(results)
+}
+
+// statsMatch builds the $match of the stats endpoints from their shared filters
+func statsMatch(period, team, tags string) bson.M {
+    match := bson.M{}
+
     // Filter opsional berdasarkan periode waktu
-    if period := c.Query("period"); period != "" {
+    if period != "" {
         var timeFilter time.Time
-        
+
         switch period {
         case "day":
             timeFilter = time.Now().AddDate(0, 0, -1)
@@ -72,54 +631,161 @@ func GetEmotionStats(c *fiber.Ctx) error {
         default:
             timeFilter = time.Now().AddDate(0, 0, -7) // Default ke minggu
         }
-        
-        pipeline = append([]bson.M{{
-            "$match": bson.M{
-                "created_at": bson.M{"$gte": timeFilter},
-            },
-        }}, pipeline...)
+
+        match["created_at"] = bson.M{"$gte": timeFilter}
+    }
+    if team != "" {
+        match["team"] = team
+    }
+    if tags != "" {
+        if list := normalizeTags(strings.Split(tags, ",")); len(list) > 0 {
+            match["tags"] = bson.M{"$all": list}
+        }
     }
+    return match
+}
 
-    cursor, err := config.DB.Collection("emotions").Aggregate(ctx, pipeline)
-    if err != nil {
-        return c.Status(500).JSON(fiber.Map{"error": "Gagal mengambil statistik emosi"})
+// statsQueryKey identifies a stats query for its noise. Filters are put in the form
+// statsMatch uses them in, so spelling the same query differently gives the same noise.
+func statsQueryKey(endpoint, period, team, tags string) string {
+    switch period {
+    case "", "day", "week", "month":
+    default:
+        period = "week"
     }
-    defer cursor.Close(ctx)
+    list := normalizeTags(strings.Split(tags, ","))
+    slices.Sort(list)
+    return privacy.QueryKey(endpoint, period, team, strings.Join(list, ","))
+}
 
-    var results []models.EmotionStats
-    if err = cursor.All(ctx, &results); err != nil {
-        return c.Status(500).JSON(fiber.Map{"error": "Gagal memproses data emosi"})
+// normalizeTags lowercases tags and collapses their whitespace, so "Deadline " and
+// "deadline" are the same tag; empty and duplicate tags are dropped
+func normalizeTags(tags []string) []string {
+    var out []string
+    for _, t := range tags {
+        t = strings.ToLower(strings.Join(strings.Fields(t), " "))
+        if t != "" && !slices.Contains(out, t) {
+            out = append(out, t)
+        }
     }
+    return out
+}
 
-    return c.Status(200).JSON(results)
+// valenceMean is the mean valence of the listed check-ins, each mood weighted by its
+// count. "other" and moods without a definition have no valence and don't count.
+func valenceMean(stats []models.EmotionStats) (float64, bool) {
+    var sum float64
+    n := 0
+    for _, s := range stats {
+        if s.Valence != nil {
+            sum += *s.Valence * float64(s.Count)
+            n += s.Count
+        }
+    }
+    if n == 0 {
+        return 0, false
+    }
+    return sum / float64(n), true
+}
+
+// moodStats labels and scores buckets from the mood definitions and orders them like
+// the definitions; "other" and moods without a definition come last
+func moodStats(buckets []privacy.Bucket, locale string) []models.EmotionStats {
+    results := make([]models.EmotionStats, 0, len(buckets))
+    for _, b := range buckets {
+        stat := models.EmotionStats{Mood: b.Key, Count: b.Count}
+        if def, ok := moods.Get(b.Key); ok {
+            stat.Label = def.Label(locale)
+            stat.Emoji = def.Emoji
+            stat.Color = def.Color
+            stat.Valence = &def.Valence
+            stat.Arousal = &def.Arousal
+        }
+        results = append(results, stat)
+    }
+
+    position := map[string]int{}
+    for i, def := range moods.All() {
+        position[def.Key] = i
+    }
+    slices.SortStableFunc(results, func(a, b models.EmotionStats) int {
+        pa, okA := position[a.Mood]
+        pb, okB := position[b.Mood]
+        switch {
+        case okA && okB:
+            return pa - pb
+        case okA:
+            return -1
+        case okB:
+            return 1
+        }
+        return strings.Compare(a.Mood, b.Mood)
+    })
+    return results
+}
+
+// setPrivacyHeaders tells clients how an aggregate was protected, so they can explain
+// an "other" bucket or missing moods
+func setPrivacyHeaders(c *fiber.Ctx, policy privacy.Policy, result privacy.Result) {
+    c.Set("X-Privacy-K", strconv.Itoa(policy.K))
+    c.Set("X-Privacy-Noise", strconv.FormatBool(policy.Noise))
+    if result.Merged > 0 {
+        c.Set("X-Privacy-Merged", strconv.Itoa(result.Merged))
+    }
+    if result.Suppressed {
+        c.Set("X-Privacy-Suppressed", "true")
+    }
+}
+
+// userTeam returns the team the user belongs to, or "" if none or the user is unknown
+func userTeam(ctx context.Context, userID string) (string, error) {
+    var user models.User
+    opts := options.FindOne().SetProjection(bson.M{"team": 1})
+    err := config.UserCollectionRef.FindOne(ctx, config.UserIDFilter(userID), opts).Decode(&user)
+    if errors.Is(err, mongo.ErrNoDocuments) {
+        return "", nil
+    }
+    return user.Team, err
 }
 
 // GetUserEmotions mengambil emosi untuk pengguna tertentu
 func GetUserEmotions(c *fiber.Ctx) error {
     userID := c.Params("id")
     if userID == "" {
-        return c.Status(400).JSON(fiber.Map{"error": "ID pengguna diperlukan"})
+        return apperror.UserIDInvalid
     }
 
     objectID, err := primitive.ObjectIDFromHex(userID)
     if err != nil {
-        return c.Status(400).JSON(fiber.Map{"error": "Format ID pengguna tidak valid"})
+        return apperror.UserIDInvalid.Wrap(err)
     }
 
     ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
     defer cancel()
 
+    // Named check-ins are personal: only their owner and admins may read them
+    callerID, err := currentUserID(c)
+    if err != nil {
+        return err
+    }
+    if callerID != userID {
+        if err := requireAdmin(ctx, c); err != nil {
+            return err
+        }
+    }
+
     var emotions []models.Emotion
 
     opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}).SetLimit(50)
-    cursor, err := config.DB.Collection("emotions").Find(ctx, bson.M{"user_id": objectID}, opts)
+    // Anonymous check-ins have no user_id; the explicit filter keeps it that way
+    cursor, err := config.DB.Collection("emotions").Find(ctx, bson.M{"user_id": objectID, "anonymous": bson.M{"$ne": true}}, opts)
     if err != nil {
-        return c.Status(500).JSON(fiber.Map{"error": "Gagal mengambil data emosi"})
+        return apperror.Internal.Wrap(err)
     }
     defer cursor.Close(ctx)
 
     if err = cursor.All(ctx, &emotions); err != nil {
-        return c.Status(500).JSON(fiber.Map{"error": "Gagal memproses data emosi"})
+        return apperror.Internal.Wrap(err)
     }
 
     return c.Status(200).JSON(emotions)
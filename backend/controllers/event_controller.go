@@ -0,0 +1,210 @@
+package controllers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+
+	"backend/apperror"
+	"backend/config"
+	"backend/events"
+	"backend/meetings"
+	"backend/metrics"
+	"backend/models"
+	"backend/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// eventStreamRetry tells EventSource clients how long to wait before reconnecting
+const eventStreamRetry = 5 * time.Second
+
+// TeamEvents streams a team's events as Server-Sent Events. Only members of the team may
+// listen. The stream opens with a "snapshot" event carrying today's check-in count, then
+// sends one event per change: "checkins", "presence", "meeting.started" and "meeting.ended".
+// Comment lines keep idle connections open through proxies.
+func TeamEvents(c *fiber.Ctx) error {
+	team, err := callerTeam(c)
+	if err != nil {
+		return err
+	}
+
+	// Subscribe before counting so no check-in falls between the two
+	stream, unsubscribe := events.Subscribe(team)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	today, err := events.CheckinsToday(ctx, team)
+	cancel()
+	if err != nil {
+		unsubscribe()
+		return apperror.Internal.Wrap(err)
+	}
+
+	snapshot := fiber.Map{
+		"team":  team,
+		"today": today,
+		"live":  events.Streaming(),
+	}
+
+	c.Set(fiber.HeaderContentType, "text/event-stream")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set(fiber.HeaderConnection, "keep-alive")
+	// Stops nginx from buffering the stream
+	c.Set("X-Accel-Buffering", "no")
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer unsubscribe()
+
+		gauge := metrics.ActiveConnections.WithLabelValues("team-events")
+		gauge.Inc()
+		defer gauge.Dec()
+
+		fmt.Fprintf(w, "retry: %d\n\n", eventStreamRetry.Milliseconds())
+		if err := writeEvent(w, "", "snapshot", snapshot); err != nil {
+			return
+		}
+
+		ping := time.NewTicker(feedPingInterval)
+		defer ping.Stop()
+
+		for {
+			select {
+			case ev, ok := <-stream:
+				if !ok {
+					// Server shutting down; the client reconnects to another replica
+					return
+				}
+				if err := writeEvent(w, ev.ID.Hex(), ev.Type, ev); err != nil {
+					return
+				}
+			case <-ping.C:
+				// A failed flush is how a closed connection shows up
+				fmt.Fprint(w, ": ping\n\n")
+				if err := w.Flush(); err != nil {
+					return
+				}
+			}
+		}
+	})
+	return nil
+}
+
+// writeEvent writes one Server-Sent Event and flushes it to the client
+func writeEvent(w *bufio.Writer, id, event string, v interface{}) error {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if id != "" {
+		fmt.Fprintf(w, "id: %s\n", id)
+	}
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, payload)
+	return w.Flush()
+}
+
+// StartMeeting announces to the caller's team that a meeting started. Meetings live in
+// the browser, so the meeting room reports it when the caller opens it.
+func StartMeeting(c *fiber.Ctx) error {
+	return announceMeeting(c, events.TypeMeetingStarted)
+}
+
+// EndMeeting announces to the caller's team that a meeting ended
+func EndMeeting(c *fiber.Ctx) error {
+	return announceMeeting(c, events.TypeMeetingEnded)
+}
+
+// announceMeeting records the caller's attendance, for their insights, and tells their
+// team about it
+func announceMeeting(c *fiber.Ctx, eventType string) error {
+	meetingID := c.Params("id")
+	if !meetingIDPattern.MatchString(meetingID) {
+		return apperror.MeetingIDInvalid
+	}
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var user models.User
+	err = config.UserCollectionRef.FindOne(ctx, config.UserIDFilter(userID),
+		options.FindOne().SetProjection(bson.M{"nama": 1, "team": 1}),
+	).Decode(&user)
+	if err != nil {
+		return userLookupError(err)
+	}
+	recordAttendance(ctx, c, userID, meetingID, eventType)
+	if user.Team == "" {
+		return c.SendStatus(fiber.StatusNoContent)
+	}
+
+	err = events.Publish(ctx, events.Event{Type: eventType, Team: user.Team, Data: map[string]interface{}{
+		"meetingId": meetingID,
+		"link":      meetingLink(meetingID),
+		"byId":      user.ID,
+		"byName":    user.Nama,
+	}})
+	if err != nil {
+		return apperror.Internal.Wrap(err)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// recordAttendance stores that the caller joined or left the meeting. Attendance only
+// feeds insights, so a failure is only logged.
+func recordAttendance(ctx context.Context, c *fiber.Ctx, userID, meetingID, eventType string) {
+	var err error
+	if eventType == events.TypeMeetingStarted {
+		err = meetings.Joined(ctx, userID, meetingID, time.Now())
+	} else {
+		err = meetings.Left(ctx, userID, meetingID, time.Now())
+	}
+	if err != nil {
+		utils.Log(c).Warn("recording meeting attendance failed", "meeting", meetingID, "error", err)
+	}
+}
+
+// callerTeam returns the team named by :name if the caller belongs to it
+func callerTeam(c *fiber.Ctx) (string, error) {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return "", err
+	}
+	team, err := url.PathUnescape(c.Params("name"))
+	if err != nil || team == "" {
+		return "", apperror.InvalidRequest.Wrap(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var user models.User
+	err = config.UserCollectionRef.FindOne(ctx, config.UserIDFilter(userID),
+		options.FindOne().SetProjection(bson.M{"team": 1}),
+	).Decode(&user)
+	if err != nil {
+		return "", userLookupError(err)
+	}
+	if user.Team != team {
+		utils.Log(c).Warn("team event stream refused", "team", team)
+		return "", apperror.AuthForbidden
+	}
+	return team, nil
+}
+
+// announceCheckins tells the team's event streams that its check-ins changed. The
+// check-in is already stored, so a failure is only logged.
+func announceCheckins(c *fiber.Ctx, team string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := events.Checkins(ctx, team); err != nil {
+		utils.Log(c).Warn("announcing check-in to team events failed", "team", team, "error", err)
+	}
+}
@@ -0,0 +1,64 @@
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"backend/config"
+	"backend/lifecycle"
+	"backend/utils"
+)
+
+// Livez reports that the process is up and serving; it never checks dependencies
+// so an unavailable MongoDB doesn't get the container restarted
+func Livez(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "ok"})
+}
+
+// Readyz reports whether the instance can serve traffic: MongoDB answers a ping
+// and all migrations have been applied
+func Readyz(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	ready := true
+
+	start := time.Now()
+	mongoCheck := fiber.Map{"status": "up"}
+	if err := config.Ping(ctx); err != nil {
+		ready = false
+		mongoCheck["status"] = "down"
+		utils.Log(c).Warn("readiness: mongo ping failed", "error", err)
+	}
+	mongoCheck["latencyMs"] = time.Since(start).Milliseconds()
+
+	status, applied, err := config.MigrationStatus()
+	migrationCheck := fiber.Map{"status": status, "applied": applied}
+	if status != config.MigrationsDone {
+		ready = false
+	}
+	if err != nil {
+		migrationCheck["error"] = "migration failed, see logs"
+	}
+
+	// Fail readiness while draining so load balancers stop sending new traffic
+	if lifecycle.ShuttingDown() {
+		ready = false
+	}
+
+	body := fiber.Map{
+		"status": "ok",
+		"checks": fiber.Map{
+			"mongo":      mongoCheck,
+			"migrations": migrationCheck,
+		},
+	}
+
+	if !ready {
+		body["status"] = "unavailable"
+		return c.Status(fiber.StatusServiceUnavailable).JSON(body)
+	}
+	return c.Status(fiber.StatusOK).JSON(body)
+}
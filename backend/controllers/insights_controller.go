@@ -0,0 +1,98 @@
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"backend/apperror"
+	"backend/config"
+	"backend/insights"
+	"backend/meetings"
+	"backend/models"
+	"backend/moods"
+	"backend/validation"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const defaultInsightsDays = 90
+
+// GetMyInsights computes statistics over the caller's own named check-ins: streaks,
+// averages per weekday and hour, top tags, best and worst days, the change against the
+// previous month and how their mood relates to the meetings they attended
+func GetMyInsights(c *fiber.Ctx) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+
+	var query models.InsightsQuery
+	if err := validation.ParseQuery(c, &query); err != nil {
+		return err
+	}
+	if query.Days == 0 {
+		query.Days = defaultInsightsDays
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var user models.User
+	err = config.UserCollectionRef.FindOne(ctx, config.UserIDFilter(userID),
+		options.FindOne().SetProjection(bson.M{"reminder": 1}),
+	).Decode(&user)
+	if err != nil {
+		return userLookupError(err)
+	}
+
+	timezone := query.Timezone
+	if timezone == "" {
+		timezone = reminderOrDefault(user.Reminder).Timezone
+	}
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return apperror.Internal.Wrap(err)
+	}
+
+	now := time.Now()
+	entries := []insights.Entry{}
+	if objectID, err := primitive.ObjectIDFromHex(userID); err == nil {
+		opts := options.Find().
+			SetSort(bson.D{{Key: "created_at", Value: 1}}).
+			SetProjection(bson.M{"mood": 1, "tags": 1, "created_at": 1})
+		cursor, err := config.DB.Collection("emotions").Find(ctx, bson.M{
+			"user_id":    objectID,
+			"anonymous":  bson.M{"$ne": true},
+			"created_at": bson.M{"$gte": insights.Since(query.Days, now, loc)},
+		}, opts)
+		if err != nil {
+			return apperror.Internal.Wrap(err)
+		}
+		defer cursor.Close(ctx)
+
+		for cursor.Next(ctx) {
+			var e models.Emotion
+			if err := cursor.Decode(&e); err != nil {
+				return apperror.Internal.Wrap(err)
+			}
+			entry := insights.Entry{At: e.CreatedAt, Tags: e.Tags}
+			if mood, ok := moods.Get(e.Mood); ok {
+				entry.Valence = &mood.Valence
+			}
+			entries = append(entries, entry)
+		}
+		if err := cursor.Err(); err != nil {
+			return apperror.Internal.Wrap(err)
+		}
+	}
+
+	attended, err := meetings.Attended(ctx, userID, insights.Since(query.Days, now, loc))
+	if err != nil {
+		return apperror.Internal.Wrap(err)
+	}
+
+	return c.JSON(insights.Compute(entries, attended, query.Days, now, loc))
+}
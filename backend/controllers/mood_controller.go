@@ -0,0 +1,127 @@
+package controllers
+
+import (
+	"context"
+	"regexp"
+	"time"
+
+	"backend/apperror"
+	"backend/config"
+	"backend/models"
+	"backend/moods"
+	"backend/utils"
+	"backend/validation"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// moodKeyPattern matches the keys check-ins store, e.g. "very_happy"
+var moodKeyPattern = regexp.MustCompile(`^[a-z0-9_]{1,32}$`)
+
+// moodResponse is a mood definition with its label in the request's locale
+type moodResponse struct {
+	models.MoodDefinition
+	Label string `json:"label"`
+}
+
+// GetMoods returns the mood vocabulary in display order. Retired moods are only
+// included with ?all=true, for labelling old check-ins.
+func GetMoods(c *fiber.Ctx) error {
+	defs := moods.Active()
+	if c.QueryBool("all") {
+		defs = moods.All()
+	}
+
+	locale := apperror.Locale(c)
+	result := make([]moodResponse, 0, len(defs))
+	for _, d := range defs {
+		result = append(result, moodResponse{MoodDefinition: d, Label: d.Label(locale)})
+	}
+	return c.JSON(result)
+}
+
+// MatchExpression maps face-api expression probabilities to the mood a check-in
+// should use. mood is null when no mapped expression is confident enough.
+func MatchExpression(c *fiber.Ctx) error {
+	var input models.ExpressionMatchRequest
+	if err := validation.ParseBody(c, &input); err != nil {
+		return err
+	}
+
+	minConfidence := moods.MinExpressionConfidence()
+	def, expression, confidence, ok := moods.FromExpressions(input.Expressions, minConfidence)
+	if !ok {
+		return c.JSON(fiber.Map{"mood": nil, "minConfidence": minConfidence})
+	}
+	return c.JSON(fiber.Map{
+		"mood":          moodResponse{MoodDefinition: def, Label: def.Label(apperror.Locale(c))},
+		"expression":    expression,
+		"confidence":    confidence,
+		"minConfidence": minConfidence,
+	})
+}
+
+// PutMood creates or replaces the mood definition of :key. Only admins may edit the
+// vocabulary; the change applies to new check-ins and to the labels of old ones.
+func PutMood(c *fiber.Ctx) error {
+	key := c.Params("key")
+	if !moodKeyPattern.MatchString(key) {
+		return apperror.MoodKeyInvalid
+	}
+
+	var input models.MoodDefinitionRequest
+	if err := validation.ParseBody(c, &input); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := requireAdmin(ctx, c); err != nil {
+		return err
+	}
+
+	def := models.MoodDefinition{
+		Key:         key,
+		Labels:      input.Labels,
+		Emoji:       input.Emoji,
+		Color:       input.Color,
+		Valence:     input.Valence,
+		Arousal:     input.Arousal,
+		Order:       input.Order,
+		Expressions: input.Expressions,
+		Inactive:    input.Inactive,
+	}
+	created, err := moods.Save(ctx, def)
+	if err != nil {
+		return apperror.Internal.Wrap(err)
+	}
+	utils.Log(c).Info("mood definition saved", "mood", key, "created", created, "inactive", def.Inactive)
+
+	status := fiber.StatusOK
+	if created {
+		status = fiber.StatusCreated
+	}
+	return c.Status(status).JSON(moodResponse{MoodDefinition: def, Label: def.Label(apperror.Locale(c))})
+}
+
+// requireAdmin fails with AUTH_FORBIDDEN unless the caller is an admin
+func requireAdmin(ctx context.Context, c *fiber.Ctx) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+	var user models.User
+	err = config.UserCollectionRef.FindOne(ctx, config.UserIDFilter(userID),
+		options.FindOne().SetProjection(bson.M{"admin": 1}),
+	).Decode(&user)
+	if err != nil {
+		return userLookupError(err)
+	}
+	if !user.Admin {
+		return apperror.AuthForbidden
+	}
+	return nil
+}
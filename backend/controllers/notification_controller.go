@@ -0,0 +1,365 @@
+package controllers
+
+import (
+	"context"
+	"net/url"
+	"regexp"
+	"slices"
+	"time"
+
+	"backend/apperror"
+	"backend/config"
+	"backend/meetings"
+	"backend/metrics"
+	"backend/models"
+	"backend/notifications"
+	"backend/pagination"
+	"backend/validation"
+
+	"github.com/gofiber/contrib/websocket"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	defaultNotificationPageSize = 20
+	// mentionExcerptLength bounds the chat line shown in a mention notification
+	mentionExcerptLength = 140
+	// meetingAttendeeWindow is how recently someone must have been in a meeting room to be
+	// invited to or mentioned in it from outside the caller's team
+	meetingAttendeeWindow = 24 * time.Hour
+)
+
+// meetingIDPattern matches the room names the meeting pages generate and accept
+var meetingIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// NotificationPage is a page of notifications with the caller's unread count for the badge
+type NotificationPage struct {
+	pagination.Page[models.Notification]
+	Unread int64 `json:"unread"`
+}
+
+// GetNotifications lists the caller's notifications, newest first
+func GetNotifications(c *fiber.Ctx) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+
+	var query models.NotificationListQuery
+	if err := validation.ParseQuery(c, &query); err != nil {
+		return err
+	}
+	if query.Limit == 0 {
+		query.Limit = defaultNotificationPageSize
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	page := NotificationPage{Page: pagination.Page[models.Notification]{
+		Data: []models.Notification{},
+		Page: pagination.Info{Limit: query.Limit},
+	}}
+
+	collection := config.DB.Collection(notifications.Collection)
+	filter := bson.M{"userId": userID}
+	if query.Unread {
+		filter["read"] = false
+	}
+
+	if page.Page.Page.Total, err = collection.CountDocuments(ctx, filter); err != nil {
+		return apperror.Internal.Wrap(err)
+	}
+	if page.Unread, err = notifications.UnreadCount(ctx, userID); err != nil {
+		return apperror.Internal.Wrap(err)
+	}
+
+	sort := pagination.Sort{Field: "createdAt", Desc: true}
+	after, err := sort.After(query.Cursor)
+	if err != nil {
+		return err
+	}
+	if after != nil {
+		filter = bson.M{"$and": bson.A{filter, after}}
+	}
+
+	// One extra document tells whether another page follows
+	opts := options.Find().SetSort(sort.Order()).SetLimit(int64(query.Limit + 1))
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		return apperror.Internal.Wrap(err)
+	}
+	defer cursor.Close(ctx)
+
+	var last bson.Raw
+	for cursor.Next(ctx) {
+		if len(page.Data) == query.Limit {
+			page.Page.Page.NextCursor = pagination.CursorAfter(last, sort.Field)
+			break
+		}
+		// Current is only valid until the next call to Next
+		last = append(last[:0], cursor.Current...)
+
+		var n models.Notification
+		if err := cursor.Decode(&n); err != nil {
+			return apperror.Internal.Wrap(err)
+		}
+		page.Data = append(page.Data, n)
+	}
+	if err := cursor.Err(); err != nil {
+		return apperror.Internal.Wrap(err)
+	}
+
+	return c.JSON(page)
+}
+
+// MarkNotificationRead marks one of the caller's notifications read
+func MarkNotificationRead(c *fiber.Ctx) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return apperror.NotificationIDInvalid
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	changed, err := notifications.MarkRead(ctx, userID, id)
+	if err != nil {
+		return apperror.Internal.Wrap(err)
+	}
+	if changed == 0 {
+		// Already read is fine; someone else's or unknown is not
+		n, err := config.DB.Collection(notifications.Collection).CountDocuments(ctx, bson.M{"_id": id, "userId": userID})
+		if err != nil {
+			return apperror.Internal.Wrap(err)
+		}
+		if n == 0 {
+			return apperror.NotificationNotFound
+		}
+	}
+
+	unread, err := notifications.UnreadCount(ctx, userID)
+	if err != nil {
+		return apperror.Internal.Wrap(err)
+	}
+	return c.JSON(fiber.Map{"unread": unread})
+}
+
+// MarkAllNotificationsRead marks every notification of the caller read
+func MarkAllNotificationsRead(c *fiber.Ctx) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	changed, err := notifications.MarkRead(ctx, userID)
+	if err != nil {
+		return apperror.Internal.Wrap(err)
+	}
+	return c.JSON(fiber.Map{"updated": changed, "unread": 0})
+}
+
+// NotificationFeedUpgrade rejects plain HTTP requests to the notification feed
+func NotificationFeedUpgrade(c *fiber.Ctx) error {
+	if !websocket.IsWebSocketUpgrade(c) {
+		return apperror.UpgradeRequired
+	}
+	return c.Next()
+}
+
+// NotificationFeed pushes the caller's new notifications over a WebSocket. The server
+// first sends {"type":"snapshot","unread":n}, then {"type":"notification",...} for each
+// new notification and {"type":"read",...} when notifications are marked read elsewhere.
+var NotificationFeed = websocket.New(func(conn *websocket.Conn) {
+	claims, _ := conn.Locals("user").(jwt.MapClaims)
+	userID, _ := claims["id"].(string)
+
+	gauge := metrics.ActiveConnections.WithLabelValues("notifications")
+	gauge.Inc()
+	defer gauge.Dec()
+
+	// Subscribe before counting so no notification falls between the two
+	events, unsubscribe := notifications.Subscribe(userID)
+	defer unsubscribe()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	unread, err := notifications.UnreadCount(ctx, userID)
+	cancel()
+	if err != nil {
+		closeFeed(conn, websocket.CloseInternalServerErr, "snapshot failed")
+		return
+	}
+	if err := writeFeed(conn, fiber.Map{"type": "snapshot", "unread": unread}); err != nil {
+		return
+	}
+
+	// Reader: the client sends nothing but pongs; reading notices when it goes away
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		conn.SetReadDeadline(time.Now().Add(feedReadTimeout))
+		conn.SetPongHandler(func(string) error {
+			return conn.SetReadDeadline(time.Now().Add(feedReadTimeout))
+		})
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	ping := time.NewTicker(feedPingInterval)
+	defer ping.Stop()
+
+	for {
+		select {
+		case ev, ok := <-events:
+			if !ok {
+				closeFeed(conn, websocket.CloseGoingAway, "server shutting down")
+				return
+			}
+			if err := writeFeed(conn, ev); err != nil {
+				return
+			}
+		case <-ping.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(feedWriteTimeout)); err != nil {
+				return
+			}
+		case <-done:
+			return
+		}
+	}
+})
+
+// InviteToMeeting notifies users that the caller invited them to a meeting. Meetings
+// live in the browser, so the meeting ID is only checked for shape.
+func InviteToMeeting(c *fiber.Ctx) error {
+	var input models.MeetingInviteRequest
+	if err := validation.ParseBody(c, &input); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	meetingID, from, to, err := meetingRecipients(ctx, c, input.UserIDs)
+	if err != nil {
+		return err
+	}
+
+	if err := notifications.MeetingInvite(ctx, from, to, meetingID, meetingLink(meetingID)); err != nil {
+		return apperror.Internal.Wrap(err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"sent": len(to)})
+}
+
+// MentionInMeeting notifies users that the caller mentioned them in a meeting's chat
+func MentionInMeeting(c *fiber.Ctx) error {
+	var input models.MentionRequest
+	if err := validation.ParseBody(c, &input); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	meetingID, from, to, err := meetingRecipients(ctx, c, input.UserIDs)
+	if err != nil {
+		return err
+	}
+
+	excerpt := []rune(input.Message)
+	if len(excerpt) > mentionExcerptLength {
+		excerpt = append(excerpt[:mentionExcerptLength-1], '…')
+	}
+	if err := notifications.Mention(ctx, from, to, meetingID, meetingLink(meetingID), string(excerpt)); err != nil {
+		return apperror.Internal.Wrap(err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"sent": len(to)})
+}
+
+// noRecipients is returned when none of the requested users may be notified
+var noRecipients = apperror.ValidationFailed.WithDetails(apperror.FieldError{
+	Field: "userIds", Code: "required",
+})
+
+// meetingRecipients validates :id and resolves the caller and the users to notify:
+// existing accounts other than the caller, each once, that are in the caller's team or,
+// if the caller was in the meeting room recently, were there too. Anyone else is left out,
+// so the endpoints can't be used to message arbitrary users.
+func meetingRecipients(ctx context.Context, c *fiber.Ctx, userIDs []string) (string, models.User, []string, error) {
+	var from models.User
+
+	meetingID := c.Params("id")
+	if !meetingIDPattern.MatchString(meetingID) {
+		return "", from, nil, apperror.MeetingIDInvalid
+	}
+
+	callerID, err := currentUserID(c)
+	if err != nil {
+		return "", from, nil, err
+	}
+	err = config.UserCollectionRef.FindOne(ctx, config.UserIDFilter(callerID),
+		options.FindOne().SetProjection(bson.M{"nama": 1, "team": 1}),
+	).Decode(&from)
+	if err != nil {
+		return "", from, nil, userLookupError(err)
+	}
+
+	attendees, err := meetings.Attendees(ctx, meetingID, time.Now().Add(-meetingAttendeeWindow))
+	if err != nil {
+		return "", from, nil, apperror.Internal.Wrap(err)
+	}
+	allowed := bson.A{}
+	if slices.Contains(attendees, callerID) {
+		allowed = append(allowed, config.UserIDsFilter(attendees))
+	}
+	if from.Team != "" {
+		allowed = append(allowed, bson.M{"team": from.Team})
+	}
+	if len(allowed) == 0 {
+		return "", from, nil, noRecipients
+	}
+
+	filter := config.UserIDsFilter(slices.DeleteFunc(slices.Clone(userIDs), func(id string) bool { return id == callerID }))
+	filter["deletedAt"] = bson.M{"$exists": false}
+	filter["$or"] = allowed
+
+	cursor, err := config.UserCollectionRef.Find(ctx, filter,
+		options.Find().SetProjection(bson.M{"_id": 1}),
+	)
+	if err != nil {
+		return "", from, nil, apperror.Internal.Wrap(err)
+	}
+	var users []models.User
+	if err := cursor.All(ctx, &users); err != nil {
+		return "", from, nil, apperror.Internal.Wrap(err)
+	}
+
+	to := make([]string, 0, len(users))
+	for _, u := range users {
+		if !slices.Contains(to, u.ID) {
+			to = append(to, u.ID)
+		}
+	}
+	if len(to) == 0 {
+		return "", from, nil, noRecipients
+	}
+	return meetingID, from, to, nil
+}
+
+// meetingLink is the app path of a meeting room
+func meetingLink(meetingID string) string {
+	return "/meeting-room/" + url.PathEscape(meetingID)
+}
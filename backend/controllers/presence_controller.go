@@ -0,0 +1,257 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"backend/apperror"
+	"backend/config"
+	"backend/metrics"
+	"backend/models"
+	"backend/presence"
+	"backend/utils"
+	"backend/validation"
+
+	"github.com/gofiber/contrib/websocket"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// feedPingInterval keeps idle connections open through proxies; the client answers with pongs
+	feedPingInterval = 30 * time.Second
+	// feedReadTimeout closes connections whose client stopped answering pings
+	feedReadTimeout  = 2 * feedPingInterval
+	feedWriteTimeout = 10 * time.Second
+)
+
+// GetPresence returns the current presence of the caller's team
+func GetPresence(c *fiber.Ctx) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	states, err := presenceSnapshot(ctx, userID)
+	if err != nil {
+		return apperror.Internal.Wrap(err)
+	}
+	return c.JSON(states)
+}
+
+// SetStatusMessage stores the caller's status message and announces it on the presence feed
+func SetStatusMessage(c *fiber.Ctx) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+
+	var input models.StatusMessageRequest
+	if err := validation.ParseBody(c, &input); err != nil {
+		return err
+	}
+
+	msg := &models.StatusMessage{
+		Text:      input.Text,
+		Emoji:     input.Emoji,
+		InMeeting: input.InMeeting,
+		ExpiresAt: input.ExpiresAt,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var user models.User
+	err = config.UserCollectionRef.FindOneAndUpdate(ctx, config.UserIDFilter(userID),
+		bson.M{"$set": bson.M{"statusMessage": msg}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&user)
+	if err != nil {
+		return userLookupError(err)
+	}
+
+	presence.SetMessage(userID, msg)
+	utils.Log(c).Info("status message set", "inMeeting", msg.InMeeting)
+
+	return c.JSON(presence.Of(user))
+}
+
+// ClearStatusMessage removes the caller's status message
+func ClearStatusMessage(c *fiber.Ctx) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var user models.User
+	err = config.UserCollectionRef.FindOneAndUpdate(ctx, config.UserIDFilter(userID),
+		bson.M{"$unset": bson.M{"statusMessage": ""}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&user)
+	if err != nil {
+		return userLookupError(err)
+	}
+
+	presence.SetMessage(userID, nil)
+
+	return c.JSON(presence.Of(user))
+}
+
+// PresenceFeedUpgrade rejects plain HTTP requests to the presence feed
+func PresenceFeedUpgrade(c *fiber.Ctx) error {
+	if !websocket.IsWebSocketUpgrade(c) {
+		return apperror.UpgradeRequired
+	}
+	return c.Next()
+}
+
+// PresenceFeed streams the presence of the caller's team over a WebSocket. The server first
+// sends {"type":"snapshot","members":[...]} and then one {"type":"presence",...} per change
+// of those members.
+// Clients send {"type":"heartbeat"} while the user is active; any message counts.
+var PresenceFeed = websocket.New(func(conn *websocket.Conn) {
+	claims, _ := conn.Locals("user").(jwt.MapClaims)
+	userID, _ := claims["id"].(string)
+
+	gauge := metrics.ActiveConnections.WithLabelValues("presence")
+	gauge.Inc()
+	defer gauge.Dec()
+
+	// Subscribe before the snapshot so no change falls between the two
+	events, unsubscribe := presence.Subscribe()
+	defer unsubscribe()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	members, err := presenceSnapshot(ctx, userID)
+	cancel()
+	if err != nil {
+		closeFeed(conn, websocket.CloseInternalServerErr, "snapshot failed")
+		return
+	}
+	if err := writeFeed(conn, fiber.Map{"type": "snapshot", "members": members}); err != nil {
+		return
+	}
+	inSnapshot := make(map[string]bool, len(members))
+	for _, m := range members {
+		inSnapshot[m.UserID] = true
+	}
+
+	// Reader: heartbeats mark the user active; pongs only keep the connection open
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		conn.SetReadDeadline(time.Now().Add(feedReadTimeout))
+		conn.SetPongHandler(func(string) error {
+			return conn.SetReadDeadline(time.Now().Add(feedReadTimeout))
+		})
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+			conn.SetReadDeadline(time.Now().Add(feedReadTimeout))
+			presence.Touch(userID)
+		}
+	}()
+
+	ping := time.NewTicker(feedPingInterval)
+	defer ping.Stop()
+
+	for {
+		select {
+		case ev, ok := <-events:
+			if !ok {
+				closeFeed(conn, websocket.CloseGoingAway, "server shutting down")
+				return
+			}
+			if !inSnapshot[ev.UserID] {
+				continue
+			}
+			if err := writeFeed(conn, ev); err != nil {
+				return
+			}
+		case <-ping.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(feedWriteTimeout)); err != nil {
+				return
+			}
+		case <-done:
+			return
+		}
+	}
+})
+
+func writeFeed(conn *websocket.Conn, v interface{}) error {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	conn.SetWriteDeadline(time.Now().Add(feedWriteTimeout))
+	return conn.WriteMessage(websocket.TextMessage, payload)
+}
+
+func closeFeed(conn *websocket.Conn, code int, reason string) {
+	_ = conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason),
+		time.Now().Add(feedWriteTimeout))
+}
+
+// presenceSnapshot computes the presence of the members of the user's team, or only the
+// user's own if they aren't in one
+func presenceSnapshot(ctx context.Context, userID string) ([]presence.State, error) {
+	team, err := userTeam(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	filter := config.UserIDFilter(userID)
+	if team != "" {
+		filter = bson.M{"team": team}
+	}
+	filter["deletedAt"] = bson.M{"$exists": false}
+
+	opts := options.Find().SetProjection(bson.M{"lastActive": 1, "statusMessage": 1})
+	cursor, err := config.UserCollectionRef.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	states := []presence.State{}
+	for cursor.Next(ctx) {
+		var user models.User
+		if err := cursor.Decode(&user); err != nil {
+			return nil, err
+		}
+		states = append(states, presence.Of(user))
+	}
+	return states, cursor.Err()
+}
+
+// currentUserID returns the authenticated user's ID from the JWT claims set by Protected
+func currentUserID(c *fiber.Ctx) (string, error) {
+	claims, ok := c.Locals("user").(jwt.MapClaims)
+	if !ok {
+		return "", apperror.AuthTokenInvalid
+	}
+	userID, ok := claims["id"].(string)
+	if !ok || userID == "" {
+		return "", apperror.AuthTokenInvalid
+	}
+	return userID, nil
+}
+
+// userLookupError maps a failed single-user query to USER_NOT_FOUND or INTERNAL_ERROR
+func userLookupError(err error) error {
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return apperror.UserNotFound
+	}
+	return apperror.Internal.Wrap(err)
+}
@@ -0,0 +1,172 @@
+package controllers
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"backend/apperror"
+	"backend/config"
+	"backend/models"
+	"backend/notify"
+	"backend/reminders"
+	"backend/validation"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// GetReminderSettings returns the caller's check-in reminder settings, or the defaults
+func GetReminderSettings(c *fiber.Ctx) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var user models.User
+	err = config.UserCollectionRef.FindOne(ctx, config.UserIDFilter(userID),
+		options.FindOne().SetProjection(bson.M{"reminder": 1}),
+	).Decode(&user)
+	if err != nil {
+		return userLookupError(err)
+	}
+
+	return c.JSON(reminderOrDefault(user.Reminder))
+}
+
+// UpdateReminderSettings replaces the caller's reminder settings. Snoozes and the record
+// of today's reminder are kept, so saving doesn't send a second reminder the same day.
+func UpdateReminderSettings(c *fiber.Ctx) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+
+	var input models.ReminderRequest
+	if err := validation.ParseBody(c, &input); err != nil {
+		return err
+	}
+	// Stored as zero-padded "15:04" so the scheduler can compare times as strings
+	input.Time = clockTime(input.Time)
+	if input.QuietHours != nil {
+		input.QuietHours.Start = clockTime(input.QuietHours.Start)
+		input.QuietHours.End = clockTime(input.QuietHours.End)
+	}
+	if slices.Contains(input.Channels, "webhook") && input.WebhookURL == "" {
+		return apperror.ValidationFailed.WithDetails(apperror.FieldError{
+			Field: "webhookUrl", Code: "required",
+		})
+	}
+	if input.WebhookURL != "" {
+		checkCtx, checkCancel := context.WithTimeout(context.Background(), 5*time.Second)
+		err := notify.CheckWebhookURL(checkCtx, input.WebhookURL)
+		checkCancel()
+		if err != nil {
+			return apperror.ValidationFailed.WithDetails(apperror.FieldError{
+				Field: "webhookUrl", Code: "webhook_host",
+			})
+		}
+	}
+	if len(input.Workdays) == 0 {
+		input.Workdays = models.DefaultReminder("").Workdays
+	}
+
+	set := bson.M{
+		"reminder.enabled":    input.Enabled,
+		"reminder.time":       input.Time,
+		"reminder.timezone":   input.Timezone,
+		"reminder.workdays":   input.Workdays,
+		"reminder.channels":   input.Channels,
+		"reminder.webhookUrl": input.WebhookURL,
+		"reminder.quietHours": input.QuietHours,
+		"reminder.locale":     input.Locale,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var user models.User
+	err = config.UserCollectionRef.FindOneAndUpdate(ctx, config.UserIDFilter(userID),
+		bson.M{"$set": set},
+		options.FindOneAndUpdate().
+			SetProjection(bson.M{"reminder": 1}).
+			SetReturnDocument(options.After),
+	).Decode(&user)
+	if err != nil {
+		return userLookupError(err)
+	}
+
+	return c.JSON(reminderOrDefault(user.Reminder))
+}
+
+// SnoozeReminder holds reminders back for the given number of minutes. A reminder
+// already sent today is sent again once the snooze ends if the user still hasn't
+// checked in.
+func SnoozeReminder(c *fiber.Ctx) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+
+	var input models.SnoozeRequest
+	if err := validation.ParseBody(c, &input); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	until := time.Now().Add(time.Duration(input.Minutes) * time.Minute)
+
+	var user models.User
+	err = config.UserCollectionRef.FindOneAndUpdate(ctx, config.UserIDFilter(userID),
+		bson.M{
+			"$set":   bson.M{"reminder.snoozedUntil": until},
+			"$unset": bson.M{"reminder.lastSentDay": ""},
+		},
+		options.FindOneAndUpdate().
+			SetProjection(bson.M{"reminder": 1}).
+			SetReturnDocument(options.After),
+	).Decode(&user)
+	if err != nil {
+		return userLookupError(err)
+	}
+
+	return c.JSON(reminderOrDefault(user.Reminder))
+}
+
+// clockTime zero-pads a time of day already validated as "15:04", e.g. "9:05" to "09:05"
+func clockTime(v string) string {
+	t, err := time.Parse("15:04", v)
+	if err != nil {
+		return v
+	}
+	return t.Format("15:04")
+}
+
+// reminderOrDefault fills in the defaults for users who never saved reminder settings
+func reminderOrDefault(r *models.ReminderSettings) models.ReminderSettings {
+	def := models.DefaultReminder(reminders.DefaultTimezone())
+	if r == nil {
+		return def
+	}
+
+	settings := *r
+	if settings.Time == "" {
+		settings.Time = def.Time
+	}
+	if settings.Timezone == "" {
+		settings.Timezone = def.Timezone
+	}
+	if len(settings.Workdays) == 0 {
+		settings.Workdays = def.Workdays
+	}
+	if settings.Channels == nil {
+		settings.Channels = def.Channels
+	}
+	return settings
+}
@@ -0,0 +1,86 @@
+package controllers
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"time"
+
+	"backend/apperror"
+	"backend/config"
+	"backend/models"
+	"backend/privacy"
+	"backend/utils"
+	"backend/validation"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// teamLeaderRole may change the privacy settings of their own team
+const teamLeaderRole = "Team Leader"
+
+// teamPrivacyResponse is the effective policy of a team with the limits that apply to it
+type teamPrivacyResponse struct {
+	Team string `json:"team"`
+	privacy.Policy
+	MinK int `json:"minK"`
+}
+
+// GetTeamPrivacy returns the anonymity settings applied to a team's aggregates
+func GetTeamPrivacy(c *fiber.Ctx) error {
+	team, err := url.PathUnescape(c.Params("name"))
+	if err != nil || team == "" {
+		return apperror.InvalidRequest.Wrap(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	policy, err := privacy.TeamPolicy(ctx, team)
+	if err != nil {
+		return apperror.Internal.Wrap(err)
+	}
+	return c.JSON(teamPrivacyResponse{Team: team, Policy: policy, MinK: privacy.Config().MinK})
+}
+
+// SetTeamPrivacy changes k and the noise setting of a team. Only a team leader of that
+// team may do so, and k can't go below PRIVACY_MIN_K.
+func SetTeamPrivacy(c *fiber.Ctx) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+	team, err := url.PathUnescape(c.Params("name"))
+	if err != nil || team == "" {
+		return apperror.InvalidRequest.Wrap(err)
+	}
+
+	var input models.TeamPrivacyRequest
+	if err := validation.ParseBody(c, &input); err != nil {
+		return err
+	}
+	if minK := privacy.Config().MinK; input.K < minK {
+		return apperror.ValidationFailed.WithDetails(apperror.FieldError{
+			Field: "k", Code: "gte", Param: strconv.Itoa(minK),
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var user models.User
+	if err := config.UserCollectionRef.FindOne(ctx, config.UserIDFilter(userID)).Decode(&user); err != nil {
+		return userLookupError(err)
+	}
+	if user.Role != teamLeaderRole || user.Team != team {
+		return apperror.AuthForbidden
+	}
+
+	policy, err := privacy.SetTeamPolicy(ctx, team, input.K, input.Noise, userID)
+	if err != nil {
+		return apperror.Internal.Wrap(err)
+	}
+
+	utils.Log(c).Info("team privacy updated", "team", team, "k", policy.K, "noise", policy.Noise)
+	return c.JSON(teamPrivacyResponse{Team: team, Policy: policy, MinK: privacy.Config().MinK})
+}
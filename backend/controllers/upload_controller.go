@@ -0,0 +1,69 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"backend/apperror"
+	"backend/storage"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ServeUpload serves /uploads/* from the configured store. In signed mode it redirects
+// to a short-lived URL of the object store; otherwise the object is streamed through
+// the API, so every replica can serve files another one stored.
+func ServeUpload(c *fiber.Ctx) error {
+	key, err := url.PathUnescape(c.Params("*"))
+	if err != nil {
+		return apperror.UploadNotFound.Wrap(err)
+	}
+
+	store := storage.Default()
+	cfg := storage.DefaultConfig()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if cfg.URLMode == storage.Signed {
+		signed, err := store.SignedURL(ctx, key, cfg.SignedURLTTL)
+		switch {
+		case err == nil:
+			// Cache the redirect for less than the signature lives
+			c.Set(fiber.HeaderCacheControl, "private, max-age="+strconv.Itoa(int(cfg.SignedURLTTL.Seconds()/2)))
+			return c.Redirect(signed, fiber.StatusFound)
+		case errors.Is(err, storage.ErrNotFound):
+			return apperror.UploadNotFound.Wrap(err)
+		case !errors.Is(err, storage.ErrNotSupported):
+			return apperror.Internal.Wrap(err)
+		}
+	}
+
+	// The stream is read after the handler returns, so it must outlive ctx
+	body, obj, err := store.Open(context.Background(), key)
+	if errors.Is(err, storage.ErrNotFound) {
+		return apperror.UploadNotFound.Wrap(err)
+	}
+	if err != nil {
+		return apperror.Internal.Wrap(err)
+	}
+
+	c.Set(fiber.HeaderContentType, obj.ContentType)
+	// Browsers must not sniff a stored file into something more dangerous than its type
+	c.Set(fiber.HeaderXContentTypeOptions, "nosniff")
+	if !obj.ModTime.IsZero() {
+		c.Set(fiber.HeaderLastModified, obj.ModTime.UTC().Format(http.TimeFormat))
+	}
+	if strings.HasPrefix(key, "avatars/") {
+		// Avatar names are random and never reused, so they can be cached forever
+		c.Set(fiber.HeaderCacheControl, "public, max-age=31536000, immutable")
+	} else {
+		c.Set(fiber.HeaderCacheControl, "public, max-age=3600")
+	}
+	return c.SendStream(body, int(obj.Size))
+}
@@ -1,112 +1,218 @@
 package controllers
 
 import (
+	"bytes"
 	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
 	"fmt"
-	"os"
+	"regexp"
+	"strconv"
 	"time"
 
+	"backend/account"
+	"backend/apperror"
 	"backend/config"
+	"backend/imaging"
+	"backend/metrics"
 	// "backend/middleware"
 	"backend/models"
+	"backend/pagination"
+	"backend/presence"
+	"backend/storage"
 	"backend/utils"
+	"backend/validation"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/golang-jwt/jwt/v5"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 // Mendapatkan daftar pengguna dengan informasi tambahan
 func GetTeamMembers(c *fiber.Ctx) error {
-	var users []models.UserResponse
+	page, err := listUsersFromQuery(c, defaultUserPageSize)
+	if err != nil {
+		return err
+	}
+	withDefaultRole(page.Data)
+	return c.JSON(page)
+}
+
+// GetTeamMembersLegacy is GetTeamMembers as a bare array, see GetUsersLegacy
+func GetTeamMembersLegacy(c *fiber.Ctx) error {
+	page, err := listUsersFromQuery(c, maxUserPageSize)
+	if err != nil {
+		return err
+	}
+	withDefaultRole(page.Data)
+	return c.JSON(page.Data)
+}
+
+func withDefaultRole(users []models.UserResponse) {
+	for i := range users {
+		if users[i].Role == "" {
+			users[i].Role = "Team Member" // Default role
+		}
+	}
+}
+
+func GetUsers(c *fiber.Ctx) error {
+	page, err := listUsersFromQuery(c, defaultUserPageSize)
+	if err != nil {
+		return err
+	}
+	return c.JSON(page)
+}
+
+// GetUsersLegacy answers the unversioned listing routes with a bare array, as they always
+// did, capped at one page of maxUserPageSize unless the client pages with ?cursor=
+func GetUsersLegacy(c *fiber.Ctx) error {
+	page, err := listUsersFromQuery(c, maxUserPageSize)
+	if err != nil {
+		return err
+	}
+	return c.JSON(page.Data)
+}
+
+const (
+	defaultUserPageSize = 20
+	maxUserPageSize     = 100
+)
+
+func listUsersFromQuery(c *fiber.Ctx, defaultLimit int) (pagination.Page[models.UserResponse], error) {
+	var query models.UserListQuery
+	if err := validation.ParseQuery(c, &query); err != nil {
+		return pagination.Page[models.UserResponse]{}, err
+	}
+	if query.Limit == 0 {
+		query.Limit = defaultLimit
+	}
+	if query.Sort == "" {
+		query.Sort = "nama"
+	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	cursor, err := config.UserCollectionRef.Find(ctx, bson.M{})
+	return listUsers(ctx, query)
+}
+
+// listUsers returns one page of users matching the query, ordered by query.Sort with _id
+// breaking ties so the cursor position is unambiguous
+func listUsers(ctx context.Context, query models.UserListQuery) (pagination.Page[models.UserResponse], error) {
+	page := pagination.Page[models.UserResponse]{
+		Data: []models.UserResponse{},
+		Page: pagination.Info{Limit: query.Limit},
+	}
+
+	// Accounts scheduled for deletion are hidden during their grace period
+	filter := bson.M{"deletedAt": bson.M{"$exists": false}}
+	if query.Q != "" {
+		pattern := primitive.Regex{Pattern: regexp.QuoteMeta(query.Q), Options: "i"}
+		filter["$or"] = bson.A{
+			bson.M{"nama": pattern},
+			bson.M{"email": pattern},
+			bson.M{"bio": pattern},
+		}
+	}
+	if query.Role != "" {
+		filter["role"] = query.Role
+	}
+	if query.Team != "" {
+		filter["team"] = query.Team
+	}
+	if query.Status != "" {
+		filter = bson.M{"$and": bson.A{filter, presence.Filter(query.Status, time.Now())}}
+	}
+
+	total, err := config.UserCollectionRef.CountDocuments(ctx, filter)
+	if err != nil {
+		return page, apperror.Internal.Wrap(err)
+	}
+	page.Page.Total = total
+
+	sort := pagination.ParseSort(query.Sort)
+	after, err := sort.After(query.Cursor)
+	if err != nil {
+		return page, err
+	}
+	if after != nil {
+		filter = bson.M{"$and": bson.A{filter, after}}
+	}
+
+	// One extra document tells whether another page follows
+	opts := options.Find().
+		SetSort(sort.Order()).
+		SetLimit(int64(query.Limit + 1)).
+		SetProjection(bson.M{"password": 0, "passwordHistory": 0})
+	cursor, err := config.UserCollectionRef.Find(ctx, filter, opts)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		return page, apperror.Internal.Wrap(err)
 	}
 	defer cursor.Close(ctx)
 
+	var last bson.Raw
 	for cursor.Next(ctx) {
-		var user models.User
-		if err := cursor.Decode(&user); err != nil {
-			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		if len(page.Data) == query.Limit {
+			page.Page.NextCursor = pagination.CursorAfter(last, sort.Field)
+			break
 		}
+		// Current is only valid until the next call to Next
+		last = append(last[:0], cursor.Current...)
 
-		// Convert to user response without password
-		userResponse := models.UserResponse{
-			ID:         user.ID,
-			Nama:       user.Nama,
-			Email:      user.Email,
-			Role:       "Team Member", // Default role
-			Status:     "Online",      // Placeholder
-			LastActive: time.Now(),    // Placeholder
+		var user models.User
+		if err := cursor.Decode(&user); err != nil {
+			return page, apperror.Internal.Wrap(err)
 		}
-
-		users = append(users, userResponse)
+		page.Data = append(page.Data, userResponse(user))
+	}
+	if err := cursor.Err(); err != nil {
+		return page, apperror.Internal.Wrap(err)
 	}
 
-	return c.Status(200).JSON(users)
-}
-
-func GetUsers(c *fiber.Ctx) error {
-    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-    defer cancel()
-
-    cursor, err := config.UserCollectionRef.Find(ctx, bson.M{})
-    if err != nil {
-        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-            "error": "Failed to fetch users: " + err.Error(),
-        })
-    }
-    defer cursor.Close(ctx)
-
-    var users []models.User
-    if err := cursor.All(ctx, &users); err != nil {
-        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-            "error": "Failed to parse users data: " + err.Error(),
-        })
-    }
-
-    // Convert to safe response without passwords
-    var safeUsers []fiber.Map
-    for _, user := range users {
-        safeUsers = append(safeUsers, fiber.Map{
-            "id":           user.ID,
-            "nama":         user.Nama,
-            "email":        user.Email,
-            "role":         user.Role,
-            "bio":          user.Bio,
-            "profileImage": user.ProfileImage,
-        })
-    }
-
-    return c.JSON(safeUsers)
+	return page, nil
 }
 
 func CreateUser(c *fiber.Ctx) error {
-	var user models.User
-	if err := c.BodyParser(&user); err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	var input models.RegisterRequest
+	if err := validation.ParseBody(c, &input); err != nil {
+		return err
 	}
 
 	// Hash password
-	hashedPassword, err := utils.HashPassword(user.Password)
+	hashedPassword, err := utils.HashPassword(input.Password)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Gagal hash password"})
+		return apperror.Internal.Wrap(err)
 	}
-	user.Password = hashedPassword
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
+	taken, err := emailTakenByOther(ctx, input.Email, "")
+	if err != nil {
+		return apperror.Internal.Wrap(err)
+	}
+	if taken {
+		return emailTaken
+	}
+
+	user := models.User{
+		Nama:            input.Nama,
+		Email:           input.Email,
+		Password:        hashedPassword,
+		Bio:             input.Bio,
+		PasswordHistory: []string{hashedPassword},
+	}
+
 	result, err := config.UserCollectionRef.InsertOne(ctx, user)
+	if mongo.IsDuplicateKeyError(err) {
+		return emailTaken
+	}
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		return apperror.Internal.Wrap(err)
 	}
 
 	return c.Status(200).JSON(fiber.Map{"inserted_id": result.InsertedID})
@@ -121,148 +227,214 @@ func GetUserById(c *fiber.Ctx) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
+	filter := config.UserIDFilter(userID)
+	filter["deletedAt"] = bson.M{"$exists": false}
+
 	var user models.User
-	err := config.UserCollectionRef.FindOne(ctx, bson.M{"_id": userID}).Decode(&user)
+	if err := config.UserCollectionRef.FindOne(ctx, filter).Decode(&user); err != nil {
+		return userLookupError(err)
+	}
+
+	return c.JSON(userResponse(user))
+}
+
+// userResponse converts a stored user into the response without password, with live presence
+func userResponse(user models.User) models.UserResponse {
+	state := presence.Of(user)
+	return models.UserResponse{
+		ID:            user.ID,
+		Nama:          user.Nama,
+		Email:         user.Email,
+		Role:          user.Role,
+		Team:          user.Team,
+		Status:        state.Status,
+		LastActive:    state.LastActive,
+		Bio:           user.Bio,
+		ProfileImage:  user.ProfileImage,
+		ProfileImages: user.ProfileImages,
+		StatusMessage: state.StatusMessage,
+	}
+}
+
+// UploadProfileImage validates the caller's new avatar, stores square thumbnails in every
+// configured size and removes the files of the previous avatar
+func UploadProfileImage(c *fiber.Ctx) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+
+	file, err := c.FormFile("image")
 	if err != nil {
-		return c.Status(404).JSON(fiber.Map{"error": "User not found"})
+		return apperror.FileMissing.Wrap(err)
 	}
 
-	// Create response without password
-	userResponse := models.UserResponse{
-		ID:           user.ID,
-		Nama:         user.Nama,
-		Email:        user.Email,
-		Role:         user.Role,
-		Bio:          user.Bio,
-		ProfileImage: user.ProfileImage,
+	opts := imaging.AvatarConfig()
+	if file.Size > opts.MaxBytes {
+		return apperror.ImageTooLarge.With("max", humanBytes(opts.MaxBytes))
 	}
 
-	return c.JSON(userResponse)
+	src, err := file.Open()
+	if err != nil {
+		return apperror.FileMissing.Wrap(err)
+	}
+	defer src.Close()
+
+	renditions, err := imaging.ProcessAvatar(src, opts)
+	if err != nil {
+		return avatarError(err, opts)
+	}
+
+	storeCtx, storeCancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer storeCancel()
+
+	images, err := storeAvatar(storeCtx, userID, renditions)
+	if err != nil {
+		return apperror.Internal.Wrap(err)
+	}
+	imageURL := images[strconv.Itoa(mainAvatarSize(renditions))]
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Read the previous avatar in the same operation so its files can be removed
+	var previous models.User
+	err = config.UserCollectionRef.FindOneAndUpdate(ctx, config.UserIDFilter(userID),
+		bson.M{"$set": bson.M{"profileImage": imageURL, "profileImages": images}},
+		options.FindOneAndUpdate().
+			SetReturnDocument(options.Before).
+			SetProjection(bson.M{"profileImage": 1, "profileImages": 1}),
+	).Decode(&previous)
+	if err != nil {
+		removeUploads(c, account.UploadKeys(models.User{ID: userID, ProfileImages: images}))
+		return userLookupError(err)
+	}
+
+	previous.ID = userID
+	if keys, err := account.RemovableKeys(ctx, previous); err != nil {
+		utils.Log(c).Warn("could not check who else uses the old avatar", "error", err)
+	} else {
+		removeUploads(c, keys)
+	}
+
+	utils.Log(c).Info("profile image saved", "path", imageURL, "sizes", len(images))
+	metrics.UploadsStored.Inc()
+
+	return c.JSON(fiber.Map{
+		"message":  "Image uploaded successfully",
+		"imageUrl": imageURL,
+		"images":   images,
+	})
 }
 
-func UploadProfileImage(c *fiber.Ctx) error {
-    // Get user ID from JWT token
-    userClaims, ok := c.Locals("user").(jwt.MapClaims)
-    if !ok {
-        return c.Status(500).JSON(fiber.Map{"error": "Failed to parse user claims"})
-    }
-    
-    // Convert the ID to string safely
-    userID, ok := userClaims["id"].(string)
-    if !ok {
-        return c.Status(500).JSON(fiber.Map{"error": "Invalid user ID in token"})
-    }
-
-    fmt.Println("Processing upload for user:", userID)
-
-    // Get the file from request
-    file, err := c.FormFile("image")
-    if err != nil {
-        return c.Status(400).JSON(fiber.Map{"error": "No file provided or invalid file"})
-    }
-
-    // Create uploads directory if it doesn't exist
-    uploadDir := "./uploads"
-    if _, err := os.Stat(uploadDir); os.IsNotExist(err) {
-        err = os.MkdirAll(uploadDir, 0755)
-        if err != nil {
-            return c.Status(500).JSON(fiber.Map{"error": "Failed to create uploads directory"})
-        }
-    }
-
-    // Generate file name with timestamp to avoid duplicates
-    timeStamp := time.Now().UnixNano()
-    fileName := fmt.Sprintf("%d_%s", timeStamp, file.Filename)
-    filePath := fmt.Sprintf("%s/%s", uploadDir, fileName)
-
-    // Save the file
-    if err := c.SaveFile(file, filePath); err != nil {
-        fmt.Println("Error saving file:", err)
-        return c.Status(500).JSON(fiber.Map{"error": "Failed to save the file"})
-    }
-
-    // Create image URL - PENTING: path harus mulai dengan '/'
-    imageURL := fmt.Sprintf("/uploads/%s", fileName)
-    fmt.Println("Image saved at:", imageURL)
-
-    // Update user profile in database
-    var result *mongo.UpdateResult
-    var updateErr error
-    ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-    defer cancel()
-
-    // Try with ObjectID first
-    objectID, err := primitive.ObjectIDFromHex(userID)
-    if err == nil {
-        // Valid ObjectID
-        result, updateErr = config.UserCollectionRef.UpdateOne(
-            ctx,
-            bson.M{"_id": objectID},
-            bson.M{"$set": bson.M{"profileImage": imageURL}},
-        )
-        
-        if updateErr == nil && result.MatchedCount > 0 {
-            fmt.Println("Updated with ObjectID successfully:", result)
-            return c.JSON(fiber.Map{
-                "message": "Image uploaded successfully",
-                "imageUrl": imageURL,
-            })
-        }
-    }
-    
-    // Fall back to string ID
-    result, updateErr = config.UserCollectionRef.UpdateOne(
-        ctx,
-        bson.M{"_id": userID},
-        bson.M{"$set": bson.M{"profileImage": imageURL}},
-    )
-    
-    if updateErr != nil {
-        // Clean up file on error
-        os.Remove(filePath)
-        fmt.Println("Error updating profile:", updateErr)
-        return c.Status(500).JSON(fiber.Map{"error": "Failed to update profile"})
-    }
-    
-    if result.MatchedCount == 0 {
-        // No document matched, clean up file
-        os.Remove(filePath)
-        fmt.Println("No user found with ID:", userID)
-        return c.Status(404).JSON(fiber.Map{"error": "User not found"})
-    }
-
-    fmt.Println("Update successful with string ID:", result)
-    
-    return c.JSON(fiber.Map{
-        "message": "Image uploaded successfully",
-        "imageUrl": imageURL,
-    })
+// storeAvatar puts every rendition under the user's avatar prefix in the upload store with a
+// random name shared by the sizes, returning their URLs by size. Nothing of the client's file
+// name is used.
+func storeAvatar(ctx context.Context, userID string, renditions []imaging.Rendition) (map[string]string, error) {
+	name := make([]byte, 16)
+	if _, err := rand.Read(name); err != nil {
+		return nil, err
+	}
+	base := hex.EncodeToString(name)
+
+	store := storage.Default()
+	images := make(map[string]string, len(renditions))
+	for _, r := range renditions {
+		key := fmt.Sprintf("%s%s_%d%s", account.AvatarPrefix(userID), base, r.Size, r.Ext)
+		if err := store.Put(ctx, key, bytes.NewReader(r.Data), int64(len(r.Data)), r.ContentType); err != nil {
+			for _, url := range images {
+				if k, ok := storage.KeyFromURL(url); ok {
+					store.Delete(ctx, k)
+				}
+			}
+			return nil, err
+		}
+		images[strconv.Itoa(r.Size)] = storage.URL(key)
+	}
+	return images, nil
 }
 
-// UpdateUser function
-func UpdateUser(c *fiber.Ctx) error {
-	userID := c.Params("id")
-	fmt.Println("Updating user with ID:", userID)
+// mainAvatarSize is the size stored in profileImage for clients that show one image
+func mainAvatarSize(renditions []imaging.Rendition) int {
+	largest := 0
+	for _, r := range renditions {
+		if r.Size == 256 {
+			return r.Size
+		}
+		largest = max(largest, r.Size)
+	}
+	return largest
+}
 
-	var updateData struct {
-		Nama            string `json:"nama"`
-		Email           string `json:"email"`
-		Role            string `json:"role"`
-		Bio             string `json:"bio"`
-		ProfileImage    string `json:"profileImage"`
-		CurrentPassword string `json:"currentPassword"`
-		NewPassword     string `json:"newPassword"`
+// removeUploads deletes uploaded objects; failures are only logged
+func removeUploads(c *fiber.Ctx, keys []string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	for _, key := range keys {
+		if err := storage.Default().Delete(ctx, key); err != nil {
+			utils.Log(c).Warn("could not remove old upload", "key", key, "error", err)
+		}
 	}
+}
 
-	if err := c.BodyParser(&updateData); err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+// avatarError maps image processing failures to client-facing errors
+func avatarError(err error, opts imaging.AvatarOptions) error {
+	switch {
+	case errors.Is(err, imaging.ErrTooLarge):
+		return apperror.ImageTooLarge.With("max", humanBytes(opts.MaxBytes))
+	case errors.Is(err, imaging.ErrUnsupportedType):
+		return apperror.ImageTypeUnsupported.Wrap(err)
+	case errors.Is(err, imaging.ErrDimensions):
+		return apperror.ImageDimensionsInvalid.Wrap(err).
+			With("min", opts.MinDimension).
+			With("maxMegapixels", opts.MaxPixels/1_000_000)
+	case errors.Is(err, imaging.ErrInvalid):
+		return apperror.ImageInvalid.Wrap(err)
 	}
+	return apperror.Internal.Wrap(err)
+}
 
-	fmt.Printf("Update data: %+v\n", updateData)
+func humanBytes(n int64) string {
+	if n >= 1<<20 {
+		return fmt.Sprintf("%.0f MB", float64(n)/(1<<20))
+	}
+	return fmt.Sprintf("%d KB", n>>10)
+}
+
+// UpdateUser changes a user's profile. Users may only update themselves; roles and teams
+// are assigned by admins, who may update anyone.
+func UpdateUser(c *fiber.Ctx) error {
+	userID := c.Params("id")
+	utils.Log(c).Info("updating user", "target_user_id", userID)
+
+	var updateData models.UpdateUserRequest
+	if err := validation.ParseBody(c, &updateData); err != nil {
+		return err
+	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
+	callerID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+	var caller models.User
+	err = config.UserCollectionRef.FindOne(ctx, config.UserIDFilter(callerID),
+		options.FindOne().SetProjection(bson.M{"admin": 1}),
+	).Decode(&caller)
+	if err != nil {
+		return userLookupError(err)
+	}
+	if callerID != userID && !caller.Admin {
+		utils.Log(c).Warn("update of another user refused", "target_user_id", userID)
+		return apperror.AuthForbidden
+	}
+	if (updateData.Role != "" || updateData.Team != "") && !caller.Admin {
+		return apperror.RoleAssignmentForbidden
+	}
+
 	update := bson.M{}
 
 	if updateData.Nama != "" {
@@ -270,6 +442,14 @@ func UpdateUser(c *fiber.Ctx) error {
 	}
 
 	if updateData.Email != "" {
+		// The email must not belong to another account
+		taken, err := emailTakenByOther(ctx, updateData.Email, userID)
+		if err != nil {
+			return apperror.Internal.Wrap(err)
+		}
+		if taken {
+			return emailTaken
+		}
 		update["email"] = updateData.Email
 	}
 
@@ -277,12 +457,12 @@ func UpdateUser(c *fiber.Ctx) error {
 		update["role"] = updateData.Role
 	}
 
-	if updateData.Bio != "" {
-		update["bio"] = updateData.Bio
+	if updateData.Team != "" {
+		update["team"] = updateData.Team
 	}
 
-	if updateData.ProfileImage != "" {
-		update["profileImage"] = updateData.ProfileImage
+	if updateData.Bio != "" {
+		update["bio"] = updateData.Bio
 	}
 
 	if updateData.NewPassword != "" && updateData.CurrentPassword != "" {
@@ -301,30 +481,45 @@ func UpdateUser(c *fiber.Ctx) error {
 		}
 
 		if err != nil {
-			return c.Status(404).JSON(fiber.Map{"error": "User not found"})
+			return apperror.UserNotFound.Wrap(err)
 		}
 
 		// Verify current password
-		if !utils.ComparePasswords(user.Password, updateData.CurrentPassword) {
-			return c.Status(400).JSON(fiber.Map{"error": "Current password is incorrect"})
+		if !utils.CheckPasswordHash(updateData.CurrentPassword, user.Password) {
+			return apperror.CurrentPasswordMismatch
+		}
+
+		// New password must satisfy the policy, both for the current and the requested email/name
+		if err := validation.Password("newPassword", updateData.NewPassword,
+			user.Email, user.Nama, updateData.Email, updateData.Nama); err != nil {
+			return err
+		}
+
+		policy := utils.GetPasswordPolicy()
+		history := passwordHistory(user)
+		if isPasswordReused(updateData.NewPassword, history, policy.HistorySize) {
+			return apperror.ValidationFailed.WithDetails(apperror.FieldError{
+				Field: "newPassword",
+				Code:  "password_reused",
+				Param: strconv.Itoa(policy.HistorySize),
+			})
 		}
 
 		// Hash new password
 		hashedPassword, err := utils.HashPassword(updateData.NewPassword)
 		if err != nil {
-			return c.Status(500).JSON(fiber.Map{"error": "Failed to hash new password"})
+			return apperror.Internal.Wrap(err)
 		}
 
 		update["password"] = hashedPassword
+		update["passwordHistory"] = lastN(append(history, hashedPassword), policy.HistorySize)
 	}
 
 	if len(update) == 0 {
-		return c.Status(400).JSON(fiber.Map{"error": "No fields to update"})
+		return apperror.NoFieldsToUpdate
 	}
 
 	// Coba update dengan ObjectID
-	var err error
-
 	objectID, err := primitive.ObjectIDFromHex(userID)
 	if err == nil {
 		updateResult, err := config.UserCollectionRef.UpdateOne(
@@ -333,8 +528,11 @@ func UpdateUser(c *fiber.Ctx) error {
 			bson.M{"$set": update},
 		)
 
+		if mongo.IsDuplicateKeyError(err) {
+			return emailTaken
+		}
 		if err == nil && updateResult.MatchedCount > 0 {
-			fmt.Println("Updated with ObjectID successfully")
+			utils.Log(c).Debug("user updated", "idType", "objectId")
 			return c.Status(200).JSON(fiber.Map{"message": "User updated successfully"})
 		}
 	}
@@ -346,15 +544,68 @@ func UpdateUser(c *fiber.Ctx) error {
 		bson.M{"$set": update},
 	)
 
+	if mongo.IsDuplicateKeyError(err) {
+		return emailTaken
+	}
 	if err != nil {
-		fmt.Println("Error updating user:", err)
-		return c.Status(500).JSON(fiber.Map{"error": "Failed to update user"})
+		return apperror.Internal.Wrap(err)
 	}
 
 	if updateResult.MatchedCount == 0 {
-		return c.Status(404).JSON(fiber.Map{"error": "User not found"})
+		return apperror.UserNotFound
 	}
 
-	fmt.Println("Updated with string ID successfully")
+	utils.Log(c).Debug("user updated", "idType", "string")
 	return c.Status(200).JSON(fiber.Map{"message": "User updated successfully"})
 }
+
+// passwordHistory returns the user's previous password hashes, oldest first, seeding it
+// with the current hash for accounts created before history was recorded
+func passwordHistory(user models.User) []string {
+	if len(user.PasswordHistory) > 0 {
+		return user.PasswordHistory
+	}
+	if user.Password != "" {
+		return []string{user.Password}
+	}
+	return nil
+}
+
+// isPasswordReused reports whether plain matches any of the last n hashes in history
+func isPasswordReused(plain string, history []string, n int) bool {
+	for _, hash := range lastN(history, n) {
+		if utils.CheckPasswordHash(plain, hash) {
+			return true
+		}
+	}
+	return false
+}
+
+func lastN(hashes []string, n int) []string {
+	if n <= 0 {
+		return nil
+	}
+	if len(hashes) > n {
+		return hashes[len(hashes)-n:]
+	}
+	return hashes
+}
+
+// emailTaken is returned for an email that belongs to another account. The unique email
+// index has the final word, so writes map its duplicate key errors to this too.
+var emailTaken = apperror.EmailAlreadyRegistered.WithDetails(apperror.FieldError{Field: "email", Code: "unique"})
+
+// emailTakenByOther reports whether email is used by an account other than userID,
+// which may be stored as an ObjectID or a plain string. Case is ignored, like the index does.
+func emailTakenByOther(ctx context.Context, email, userID string) (bool, error) {
+	ownIDs := bson.A{userID}
+	if objectID, err := primitive.ObjectIDFromHex(userID); err == nil {
+		ownIDs = append(ownIDs, objectID)
+	}
+
+	count, err := config.UserCollectionRef.CountDocuments(ctx, bson.M{
+		"email": email,
+		"_id":   bson.M{"$nin": ownIDs},
+	}, options.Count().SetCollation(config.EmailCollation))
+	return count > 0, err
+}
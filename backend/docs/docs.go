@@ -0,0 +1,27 @@
+package docs
+
+import (
+	_ "embed"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Spec is the OpenAPI 3 document for every route in routes.SetupRoutes
+//
+//go:embed openapi.json
+var Spec []byte
+
+//go:embed swagger.html
+var swaggerUI []byte
+
+// OpenAPI serves the raw OpenAPI document
+func OpenAPI(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSONCharsetUTF8)
+	return c.Send(Spec)
+}
+
+// UI serves a Swagger UI page rendering /openapi.json
+func UI(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+	return c.Send(swaggerUI)
+}
@@ -0,0 +1,91 @@
+// Package env reads typed settings from environment variables. Invalid values are
+// logged and replaced by the default, so a typo never stops the server from starting.
+package env
+
+import (
+	"log/slog"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Duration returns the positive duration in key, such as "90s" or "1h", or def
+func Duration(key string, def time.Duration) time.Duration {
+	if v := os.Getenv(key); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			return d
+		}
+		invalid(key, v, "duration")
+	}
+	return def
+}
+
+// NonNegativeDuration is Duration for settings where zero switches something off
+func NonNegativeDuration(key string, def time.Duration) time.Duration {
+	if v := os.Getenv(key); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d >= 0 {
+			return d
+		}
+		invalid(key, v, "duration")
+	}
+	return def
+}
+
+// Int returns the non-negative integer in key, or def
+func Int(key string, def int) int {
+	if v := os.Getenv(key); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			return n
+		}
+		invalid(key, v, "integer")
+	}
+	return def
+}
+
+// PositiveInt returns the integer in key if it is above zero, or def
+func PositiveInt(key string, def int) int {
+	if v := os.Getenv(key); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			return n
+		}
+		invalid(key, v, "integer")
+	}
+	return def
+}
+
+// Float returns the number in key, or def
+func Float(key string, def float64) float64 {
+	if v := os.Getenv(key); v != "" {
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			return f
+		}
+		invalid(key, v, "number")
+	}
+	return def
+}
+
+// PositiveFloat returns the number in key if it is above zero, or def
+func PositiveFloat(key string, def float64) float64 {
+	if v := os.Getenv(key); v != "" {
+		if f, err := strconv.ParseFloat(v, 64); err == nil && f > 0 {
+			return f
+		}
+		invalid(key, v, "number")
+	}
+	return def
+}
+
+// Bool returns the boolean in key, such as "true" or "0", or def
+func Bool(key string, def bool) bool {
+	if v := os.Getenv(key); v != "" {
+		if b, err := strconv.ParseBool(v); err == nil {
+			return b
+		}
+		invalid(key, v, "boolean")
+	}
+	return def
+}
+
+func invalid(key, value, kind string) {
+	slog.Warn("Invalid "+kind+" in environment, using default", "key", key, "value", value)
+}
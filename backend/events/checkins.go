@@ -0,0 +1,138 @@
+package events
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"backend/checkin"
+	"backend/config"
+	"backend/env"
+	"backend/privacy"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+var (
+	batchOnce sync.Once
+	// anonymousBatch is how long anonymous check-ins wait before they are counted, so
+	// their timing can't be matched with the presence of the people online.
+	// Overridden from TEAM_EVENTS_ANONYMOUS_BATCH on first use.
+	anonymousBatch = 15 * time.Minute
+
+	pendingMu sync.Mutex
+	// pending holds the teams with anonymous check-ins not announced yet
+	pending = map[string]bool{}
+)
+
+func batchWindow() time.Duration {
+	batchOnce.Do(func() {
+		anonymousBatch = env.Duration("TEAM_EVENTS_ANONYMOUS_BATCH", anonymousBatch)
+	})
+	return anonymousBatch
+}
+
+// Checkins announces how many check-ins the team has made today, after a named one was
+// made, changed or removed
+func Checkins(ctx context.Context, team string) error {
+	if team == "" {
+		return nil
+	}
+	today, err := CheckinsToday(ctx, team)
+	if err != nil {
+		return err
+	}
+	return Publish(ctx, Event{Type: TypeCheckins, Team: team, Data: map[string]interface{}{
+		"today": today,
+		"day":   checkin.Config().DayKey(time.Now()),
+	}})
+}
+
+// AnonymousCheckin notes that the team got an anonymous check-in. It isn't announced on
+// its own: it is counted, together with the others of its batch, once the batch window
+// it fell in is over.
+func AnonymousCheckin(team string) {
+	if team == "" {
+		return
+	}
+	pendingMu.Lock()
+	pending[team] = true
+	pendingMu.Unlock()
+}
+
+// CheckinsToday counts the team's check-ins since midnight in CHECKIN_TIMEZONE. Anonymous
+// check-ins count from the end of their batch window. The count is nil while the
+// check-ins come from fewer people than the team's k, so nobody's check-in can be told
+// from it.
+func CheckinsToday(ctx context.Context, team string) (*int64, error) {
+	now := time.Now()
+	pipeline := []bson.M{
+		{"$match": bson.M{
+			"team":       team,
+			"created_at": bson.M{"$gte": checkin.Config().DayStart(now)},
+			"$or": bson.A{
+				bson.M{"anonymous": bson.M{"$ne": true}},
+				bson.M{"created_at": bson.M{"$lt": now.Truncate(batchWindow())}},
+			},
+		}},
+		{"$group": bson.M{
+			"_id":   nil,
+			"count": bson.M{"$sum": 1},
+			"users": bson.M{"$addToSet": privacy.ContributorExpr},
+		}},
+	}
+	cursor, err := config.DB.Collection("emotions").Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	var rows []struct {
+		Count int64    `bson:"count"`
+		Users []string `bson:"users"`
+	}
+	if err := cursor.All(ctx, &rows); err != nil {
+		return nil, err
+	}
+
+	var count int64
+	var users []string
+	if len(rows) > 0 {
+		count, users = rows[0].Count, rows[0].Users
+	}
+	policy, err := privacy.TeamPolicy(ctx, team)
+	if err != nil {
+		return nil, err
+	}
+	if privacy.DistinctUsers(users) < policy.K {
+		return nil, nil
+	}
+	return &count, nil
+}
+
+// flushAnonymous announces the check-in counts of teams with pending anonymous check-ins
+// whenever a batch window ends, until the server stops
+func flushAnonymous() {
+	for {
+		window := batchWindow()
+		wait := time.Until(time.Now().Truncate(window).Add(window))
+		select {
+		case <-stop:
+			return
+		case <-time.After(wait):
+		}
+
+		pendingMu.Lock()
+		teams := pending
+		pending = map[string]bool{}
+		pendingMu.Unlock()
+
+		for team := range teams {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			err := Checkins(ctx, team)
+			cancel()
+			if err != nil {
+				slog.Warn("Announcing anonymous check-ins to team events failed", "team", team, "error", err)
+			}
+		}
+	}
+}
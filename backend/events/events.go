@@ -0,0 +1,254 @@
+// Package events streams team-scoped activity (check-in counts, presence changes and
+// meetings starting or ending) to the open event streams of the team.
+//
+// With a replica set, events are written to the team_events collection and every replica
+// reads them back through a MongoDB change stream, so a stream sees events produced by
+// any replica. A standalone server has no change streams; events are then delivered in
+// process and only reach streams served by the replica that produced them.
+package events
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"backend/config"
+	"backend/env"
+	"backend/lifecycle"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Collection holds recent team events for the change stream; a TTL index expires them
+const Collection = "team_events"
+
+// Event types
+const (
+	TypeCheckins       = "checkins"
+	TypePresence       = "presence"
+	TypeMeetingStarted = "meeting.started"
+	TypeMeetingEnded   = "meeting.ended"
+)
+
+// Event is something that happened in a team
+type Event struct {
+	ID        primitive.ObjectID     `json:"id" bson:"_id"`
+	Type      string                 `json:"type" bson:"type"`
+	Team      string                 `json:"team" bson:"team"`
+	Data      map[string]interface{} `json:"data" bson:"data"`
+	CreatedAt time.Time              `json:"createdAt" bson:"createdAt"`
+}
+
+// Backoff bounds used while the change stream can't be opened
+const (
+	initialBackoff = time.Second
+	maxBackoff     = 30 * time.Second
+)
+
+// MongoDB error codes: $changeStream on a standalone server, and a resume token that
+// fell out of the oplog
+const (
+	errNoChangeStreams = 40573
+	errHistoryLost     = 286
+)
+
+var (
+	mu      sync.Mutex
+	subs    = map[string]map[chan Event]struct{}{}
+	started sync.Once
+	stop    = make(chan struct{})
+	done    = make(chan struct{})
+
+	// streaming is set once the change stream is open; Publish then writes to the collection
+	streaming atomic.Bool
+)
+
+// Start opens the change stream in the background, falling back to in-process delivery
+// when MongoDB doesn't support it, and forwards presence changes to the users' teams.
+// Call once after config.ConnectDB and presence.Start.
+func Start() {
+	started.Do(func() {
+		lifecycle.OnDrain("team-events", func(ctx context.Context) error {
+			closeSubscribers()
+			return nil
+		})
+		lifecycle.OnStop("team-events", func(ctx context.Context) error {
+			close(stop)
+			select {
+			case <-done:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+
+		go forwardPresence()
+		go flushAnonymous()
+
+		if !env.Bool("TEAM_EVENTS_CHANGE_STREAMS", true) {
+			slog.Info("Team events delivered in process", "reason", "TEAM_EVENTS_CHANGE_STREAMS=false")
+			close(done)
+			return
+		}
+		go run()
+	})
+}
+
+// Subscribe returns a channel of the team's events and a function to stop receiving them.
+// The channel is closed on unsubscribe and when the server starts shutting down.
+// Slow subscribers miss events rather than block the producers.
+func Subscribe(team string) (<-chan Event, func()) {
+	ch := make(chan Event, 32)
+
+	mu.Lock()
+	if subs[team] == nil {
+		subs[team] = map[chan Event]struct{}{}
+	}
+	subs[team][ch] = struct{}{}
+	mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			mu.Lock()
+			defer mu.Unlock()
+			if _, ok := subs[team][ch]; ok {
+				delete(subs[team], ch)
+				if len(subs[team]) == 0 {
+					delete(subs, team)
+				}
+				close(ch)
+			}
+		})
+	}
+}
+
+// Publish announces ev to the streams of ev.Team. When the event can't be stored for the
+// change stream it is still delivered to this replica's streams.
+func Publish(ctx context.Context, ev Event) error {
+	if ev.Team == "" {
+		return nil
+	}
+	ev.ID = primitive.NewObjectID()
+	if ev.CreatedAt.IsZero() {
+		ev.CreatedAt = time.Now()
+	}
+
+	if !streaming.Load() {
+		deliver(ev)
+		return nil
+	}
+	if _, err := config.DB.Collection(Collection).InsertOne(ctx, ev); err != nil {
+		deliver(ev)
+		return err
+	}
+	return nil
+}
+
+// Streaming reports whether events currently travel through the change stream
+func Streaming() bool {
+	return streaming.Load()
+}
+
+func deliver(ev Event) {
+	mu.Lock()
+	defer mu.Unlock()
+	for ch := range subs[ev.Team] {
+		select {
+		case ch <- ev:
+		default:
+		}
+	}
+}
+
+func closeSubscribers() {
+	mu.Lock()
+	defer mu.Unlock()
+	for team, chans := range subs {
+		for ch := range chans {
+			close(ch)
+		}
+		delete(subs, team)
+	}
+}
+
+// run keeps the change stream open, resuming after the last event it saw when the
+// connection drops, until the server stops or MongoDB turns out not to support it
+func run() {
+	defer close(done)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-stop
+		cancel()
+	}()
+
+	var resumeToken bson.Raw
+	backoff := initialBackoff
+	for {
+		err := watch(ctx, &resumeToken, &backoff)
+
+		var serverErr mongo.ServerError
+		switch {
+		case ctx.Err() != nil:
+			return
+		case errors.As(err, &serverErr) && serverErr.HasErrorCode(errNoChangeStreams):
+			streaming.Store(false)
+			slog.Info("Change streams unavailable, team events delivered in process", "error", err)
+			return
+		case errors.As(err, &serverErr) && serverErr.HasErrorCode(errHistoryLost):
+			resumeToken = nil
+		}
+
+		slog.Warn("Team event stream interrupted, retrying", "retryIn", backoff.String(), "error", err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, maxBackoff)
+	}
+}
+
+// watch delivers inserted events until the stream fails; it resets backoff once open
+func watch(ctx context.Context, resumeToken *bson.Raw, backoff *time.Duration) error {
+	if config.DB == nil {
+		return errors.New("mongo client not initialised")
+	}
+
+	opts := options.ChangeStream()
+	if *resumeToken != nil {
+		opts.SetResumeAfter(*resumeToken)
+	}
+	pipeline := mongo.Pipeline{{{Key: "$match", Value: bson.M{"operationType": "insert"}}}}
+	stream, err := config.DB.Collection(Collection).Watch(ctx, pipeline, opts)
+	if err != nil {
+		return err
+	}
+	defer stream.Close(context.Background())
+
+	if !streaming.Swap(true) {
+		slog.Info("Team events delivered through a MongoDB change stream")
+	}
+	*backoff = initialBackoff
+
+	for stream.Next(ctx) {
+		var change struct {
+			FullDocument Event `bson:"fullDocument"`
+		}
+		if err := stream.Decode(&change); err != nil {
+			slog.Warn("Skipping undecodable team event", "error", err)
+		} else {
+			deliver(change.FullDocument)
+		}
+		*resumeToken = stream.ResumeToken()
+	}
+	return stream.Err()
+}
@@ -0,0 +1,87 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"backend/config"
+	"backend/models"
+	"backend/presence"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// teamCacheTTL bounds how long a team change takes to reach presence events
+const teamCacheTTL = 5 * time.Minute
+
+type cachedTeam struct {
+	team    string
+	expires time.Time
+}
+
+var (
+	teamsMu sync.Mutex
+	teams   = map[string]cachedTeam{}
+)
+
+// forwardPresence republishes this replica's presence changes to each user's team.
+// Presence lives in the memory of the replica a user talks to, so every replica forwards
+// its own changes and the change stream shares them.
+func forwardPresence() {
+	changes, unsubscribe := presence.Subscribe()
+	defer unsubscribe()
+
+	for ev := range changes {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		team, err := teamOf(ctx, ev.UserID)
+		if err == nil && team != "" {
+			err = Publish(ctx, Event{Type: TypePresence, Team: team, Data: map[string]interface{}{
+				"userId":     ev.UserID,
+				"status":     ev.Status,
+				"lastActive": ev.LastActive,
+			}})
+		}
+		cancel()
+		if err != nil {
+			slog.Warn("Forwarding presence to team events failed", "user_id", ev.UserID, "error", err)
+		}
+	}
+}
+
+// teamOf returns the team of the user, or "" if none or the user is unknown
+func teamOf(ctx context.Context, userID string) (string, error) {
+	now := time.Now()
+
+	teamsMu.Lock()
+	cached, ok := teams[userID]
+	teamsMu.Unlock()
+	if ok && now.Before(cached.expires) {
+		return cached.team, nil
+	}
+
+	var user models.User
+	err := config.UserCollectionRef.FindOne(ctx, config.UserIDFilter(userID),
+		options.FindOne().SetProjection(bson.M{"team": 1}),
+	).Decode(&user)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return "", err
+	}
+
+	teamsMu.Lock()
+	// Drop expired entries now and then so the cache doesn't grow with every user seen
+	if len(teams) > 1000 {
+		for id, entry := range teams {
+			if now.After(entry.expires) {
+				delete(teams, id)
+			}
+		}
+	}
+	teams[userID] = cachedTeam{team: user.Team, expires: now.Add(teamCacheTTL)}
+	teamsMu.Unlock()
+	return user.Team, nil
+}
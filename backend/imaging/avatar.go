@@ -0,0 +1,159 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+	"slices"
+	"sync"
+
+	"backend/env"
+
+	xdraw "golang.org/x/image/draw"
+	_ "golang.org/x/image/webp" // registers the WebP decoder
+)
+
+// Errors returned by ProcessAvatar; callers map them to client-facing codes
+var (
+	ErrTooLarge        = errors.New("image exceeds the size limit")
+	ErrUnsupportedType = errors.New("image type is not supported")
+	ErrInvalid         = errors.New("image could not be decoded")
+	ErrDimensions      = errors.New("image dimensions are out of range")
+)
+
+// AllowedTypes are the content types accepted for avatars, detected from the bytes
+// rather than trusting the client's Content-Type or file name
+var AllowedTypes = []string{"image/jpeg", "image/png", "image/webp"}
+
+// AvatarOptions bound what ProcessAvatar accepts and produces
+type AvatarOptions struct {
+	MaxBytes     int64
+	MinDimension int // shorter side, in pixels
+	MaxPixels    int // width*height, guards against decompression bombs
+	Sizes        []int
+	JPEGQuality  int
+}
+
+var (
+	avatarOnce sync.Once
+	avatarOpts AvatarOptions
+)
+
+// AvatarConfig returns the options configured through AVATAR_* environment variables.
+// The default size limit stays under Fiber's 4 MiB request body limit.
+func AvatarConfig() AvatarOptions {
+	avatarOnce.Do(func() {
+		avatarOpts = AvatarOptions{
+			MaxBytes:     int64(env.PositiveInt("AVATAR_MAX_BYTES", 3<<20)),
+			MinDimension: env.PositiveInt("AVATAR_MIN_DIMENSION", 64),
+			MaxPixels:    env.PositiveInt("AVATAR_MAX_PIXELS", 40_000_000),
+			Sizes:        []int{64, 128, 256, 512},
+			JPEGQuality:  env.PositiveInt("AVATAR_JPEG_QUALITY", 85),
+		}
+	})
+	return avatarOpts
+}
+
+// Rendition is one square thumbnail of an avatar
+type Rendition struct {
+	Size        int
+	Data        []byte
+	ContentType string
+	Ext         string
+}
+
+// ProcessAvatar validates an uploaded image and renders square thumbnails at every
+// configured size. Re-encoding from decoded pixels drops EXIF and any other metadata;
+// the EXIF orientation is applied so phone photos stay upright.
+// Images with transparency (PNG) are kept as PNG, everything else becomes JPEG.
+func ProcessAvatar(r io.Reader, opts AvatarOptions) ([]Rendition, error) {
+	data, err := io.ReadAll(io.LimitReader(r, opts.MaxBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > opts.MaxBytes {
+		return nil, ErrTooLarge
+	}
+
+	contentType := http.DetectContentType(data)
+	if !slices.Contains(AllowedTypes, contentType) {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedType, contentType)
+	}
+
+	// Check dimensions before allocating the full bitmap
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	if cfg.Width*cfg.Height > opts.MaxPixels || min(cfg.Width, cfg.Height) < opts.MinDimension {
+		return nil, fmt.Errorf("%w: %dx%d", ErrDimensions, cfg.Width, cfg.Height)
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	orientation := 1
+	if contentType == "image/jpeg" {
+		orientation = jpegOrientation(data)
+	}
+	square := cropSquare(src)
+
+	keepPNG := contentType == "image/png"
+	renditions := make([]Rendition, 0, len(opts.Sizes))
+	for _, size := range opts.Sizes {
+		scaled := image.NewRGBA(image.Rect(0, 0, size, size))
+		xdraw.CatmullRom.Scale(scaled, scaled.Bounds(), square, square.Bounds(), xdraw.Src, nil)
+
+		// Rotating a centred square commutes with cropping, so orient the small thumbnail
+		thumb := applyOrientation(scaled, orientation)
+
+		var buf bytes.Buffer
+		rendition := Rendition{Size: size}
+		if keepPNG {
+			err = png.Encode(&buf, thumb)
+			rendition.ContentType, rendition.Ext = "image/png", ".png"
+		} else {
+			err = jpeg.Encode(&buf, flatten(thumb), &jpeg.Options{Quality: opts.JPEGQuality})
+			rendition.ContentType, rendition.Ext = "image/jpeg", ".jpg"
+		}
+		if err != nil {
+			return nil, err
+		}
+		rendition.Data = buf.Bytes()
+		renditions = append(renditions, rendition)
+	}
+	return renditions, nil
+}
+
+// cropSquare returns the centred square of img
+func cropSquare(img image.Image) image.Image {
+	b := img.Bounds()
+	side := min(b.Dx(), b.Dy())
+	x0 := b.Min.X + (b.Dx()-side)/2
+	y0 := b.Min.Y + (b.Dy()-side)/2
+
+	if sub, ok := img.(interface {
+		SubImage(r image.Rectangle) image.Image
+	}); ok {
+		return sub.SubImage(image.Rect(x0, y0, x0+side, y0+side))
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, side, side))
+	draw.Draw(dst, dst.Bounds(), img, image.Pt(x0, y0), draw.Src)
+	return dst
+}
+
+// flatten composites img onto white, since JPEG has no alpha channel
+func flatten(img image.Image) image.Image {
+	dst := image.NewRGBA(img.Bounds())
+	draw.Draw(dst, dst.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(dst, dst.Bounds(), img, img.Bounds().Min, draw.Over)
+	return dst
+}
@@ -0,0 +1,308 @@
+// Package insights computes a person's own check-in statistics: streaks, averages per
+// weekday and hour, tags, best and worst days, the change against the month before and
+// how their mood relates to the meetings they attended.
+//
+// Everything is computed in the caller's timezone from their named check-ins only;
+// anonymous check-ins can't be traced back to them.
+package insights
+
+import (
+	"cmp"
+	"math"
+	"slices"
+	"time"
+
+	"backend/models"
+)
+
+const (
+	// dayLayout formats day keys
+	dayLayout = "2006-01-02"
+	// monthDays is the length of the periods compared for the monthly change
+	monthDays = 30
+	// topTags and topDays cap the tag and best/worst day lists
+	topTags = 5
+	topDays = 3
+	// minCorrelationDays is how many days with check-ins a correlation needs
+	minCorrelationDays = 5
+)
+
+// Entry is one check-in as insights see it
+type Entry struct {
+	At time.Time
+	// Valence is the mood's score, from -1 to 1; nil for moods that are no longer defined
+	Valence *float64
+	Tags    []string
+}
+
+// Since returns where the check-ins Compute needs start: the window of days ending
+// today, or the two monthly periods if those reach further back
+func Since(days int, now time.Time, loc *time.Location) time.Time {
+	from := windowStart(days, now, loc)
+	if previous := now.AddDate(0, 0, -2*monthDays); previous.Before(from) {
+		return previous
+	}
+	return from
+}
+
+// Compute summarises entries, oldest first, over the window of days ending today.
+// attended is the meeting attendance within the window.
+func Compute(entries []Entry, attended []models.MeetingAttendance, days int, now time.Time, loc *time.Location) models.Insights {
+	from := windowStart(days, now, loc)
+	out := models.Insights{
+		From:      from,
+		To:        now.In(loc),
+		Timezone:  loc.String(),
+		Weekdays:  make([]models.SlotAverage, 7),
+		Hours:     make([]models.SlotAverage, 24),
+		Tags:      []models.TagCount{},
+		BestDays:  []models.DayAverage{},
+		WorstDays: []models.DayAverage{},
+	}
+
+	var total mean
+	weekdays := make([]mean, 7)
+	hours := make([]mean, 24)
+	tags := map[string]int{}
+	byDay := map[string]*mean{}
+	for _, e := range entries {
+		if e.At.Before(from) {
+			continue
+		}
+		at := e.At.In(loc)
+		day := at.Format(dayLayout)
+		out.Checkins++
+		if byDay[day] == nil {
+			byDay[day] = &mean{}
+		}
+		for _, tag := range e.Tags {
+			tags[tag]++
+		}
+		if e.Valence == nil {
+			continue
+		}
+		total.add(*e.Valence)
+		weekdays[at.Weekday()].add(*e.Valence)
+		hours[at.Hour()].add(*e.Valence)
+		byDay[day].add(*e.Valence)
+	}
+
+	out.Average = total.value()
+	for i := range weekdays {
+		out.Weekdays[i] = models.SlotAverage{Slot: i, Checkins: weekdays[i].n, Average: weekdays[i].value()}
+	}
+	for i := range hours {
+		out.Hours[i] = models.SlotAverage{Slot: i, Checkins: hours[i].n, Average: hours[i].value()}
+	}
+	out.Tags = topTagCounts(tags)
+	out.Streaks = streaks(byDay, now, loc)
+	out.BestDays, out.WorstDays = bestAndWorst(byDay)
+	out.Month = monthChange(entries, now)
+	out.Meetings = correlate(byDay, attended, loc)
+	return out
+}
+
+// windowStart is the start of the first of days days, today being the last
+func windowStart(days int, now time.Time, loc *time.Location) time.Time {
+	local := now.In(loc)
+	today := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+	return today.AddDate(0, 0, -(days - 1))
+}
+
+func topTagCounts(tags map[string]int) []models.TagCount {
+	counts := make([]models.TagCount, 0, len(tags))
+	for tag, n := range tags {
+		counts = append(counts, models.TagCount{Tag: tag, Count: n})
+	}
+	slices.SortFunc(counts, func(a, b models.TagCount) int {
+		if a.Count != b.Count {
+			return b.Count - a.Count
+		}
+		return cmp.Compare(a.Tag, b.Tag)
+	})
+	return counts[:min(len(counts), topTags)]
+}
+
+// streaks counts runs of consecutive days with a check-in
+func streaks(byDay map[string]*mean, now time.Time, loc *time.Location) models.StreakInsights {
+	var s models.StreakInsights
+	if len(byDay) == 0 {
+		return s
+	}
+
+	local := now.In(loc)
+	day := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+	if byDay[day.Format(dayLayout)] == nil {
+		// Today isn't over yet
+		day = day.AddDate(0, 0, -1)
+	}
+	for byDay[day.Format(dayLayout)] != nil {
+		s.Current++
+		day = day.AddDate(0, 0, -1)
+	}
+
+	keys := make([]string, 0, len(byDay))
+	for key := range byDay {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	run := 0
+	var previous time.Time
+	for _, key := range keys {
+		d, _ := time.ParseInLocation(dayLayout, key, loc)
+		if run > 0 && previous.AddDate(0, 0, 1).Equal(d) {
+			run++
+		} else {
+			run = 1
+		}
+		previous = d
+		if run >= s.Longest {
+			s.Longest, s.LongestEnd = run, key
+		}
+	}
+	return s
+}
+
+// bestAndWorst ranks the days with scored check-ins. With fewer than two days there is
+// nothing to compare; otherwise a day is never both among the best and the worst.
+func bestAndWorst(byDay map[string]*mean) (best, worst []models.DayAverage) {
+	days := []models.DayAverage{}
+	for key, m := range byDay {
+		if avg := m.value(); avg != nil {
+			days = append(days, models.DayAverage{Day: key, Checkins: m.n, Average: *avg})
+		}
+	}
+	n := min(topDays, len(days)/2)
+
+	// Ties go to the day with more check-ins, then the more recent one
+	slices.SortFunc(days, func(a, b models.DayAverage) int {
+		if c := cmp.Compare(b.Average, a.Average); c != 0 {
+			return c
+		}
+		if a.Checkins != b.Checkins {
+			return b.Checkins - a.Checkins
+		}
+		return cmp.Compare(b.Day, a.Day)
+	})
+	best = slices.Clone(days[:n])
+
+	// The worst come from the remaining days, so equal averages can't put a day in both
+	rest := days[n:]
+	slices.SortStableFunc(rest, func(a, b models.DayAverage) int { return cmp.Compare(a.Average, b.Average) })
+	worst = slices.Clone(rest[:n])
+	return best, worst
+}
+
+// monthChange compares the last 30 days with the 30 before them
+func monthChange(entries []Entry, now time.Time) models.MonthChange {
+	start := now.AddDate(0, 0, -monthDays)
+	previousStart := start.AddDate(0, 0, -monthDays)
+
+	var current, previous mean
+	for _, e := range entries {
+		if e.Valence == nil || e.At.Before(previousStart) || e.At.After(now) {
+			continue
+		}
+		if e.At.Before(start) {
+			previous.add(*e.Valence)
+		} else {
+			current.add(*e.Valence)
+		}
+	}
+
+	m := models.MonthChange{
+		Current:          current.value(),
+		Previous:         previous.value(),
+		CurrentCheckins:  current.n,
+		PreviousCheckins: previous.n,
+	}
+	if m.Current != nil && m.Previous != nil {
+		m.Change = rounded(*m.Current - *m.Previous)
+	}
+	return m
+}
+
+// correlate relates each day's average mood to the number of distinct meetings attended
+// that day
+func correlate(byDay map[string]*mean, attended []models.MeetingAttendance, loc *time.Location) models.MeetingCorrelation {
+	meetings := map[string]map[string]bool{}
+	for _, a := range attended {
+		day := a.JoinedAt.In(loc).Format(dayLayout)
+		if meetings[day] == nil {
+			meetings[day] = map[string]bool{}
+		}
+		meetings[day][a.MeetingID] = true
+	}
+
+	var c models.MeetingCorrelation
+	var xs, ys []float64
+	var with, without mean
+	for key, m := range byDay {
+		avg := m.value()
+		if avg == nil {
+			continue
+		}
+		x := float64(len(meetings[key]))
+		xs, ys = append(xs, x), append(ys, *avg)
+		if x > 0 {
+			with.add(*avg)
+		} else {
+			without.add(*avg)
+		}
+	}
+
+	c.Days = len(xs)
+	c.WithMeetings, c.WithoutMeetings = with.value(), without.value()
+	if c.Days >= minCorrelationDays {
+		c.Correlation = pearson(xs, ys)
+	}
+	return c
+}
+
+// pearson returns the correlation coefficient of xs and ys, or nil if either is constant
+func pearson(xs, ys []float64) *float64 {
+	n := float64(len(xs))
+	var sx, sy float64
+	for i := range xs {
+		sx += xs[i]
+		sy += ys[i]
+	}
+	mx, my := sx/n, sy/n
+
+	var cov, vx, vy float64
+	for i := range xs {
+		dx, dy := xs[i]-mx, ys[i]-my
+		cov += dx * dy
+		vx += dx * dx
+		vy += dy * dy
+	}
+	if vx == 0 || vy == 0 {
+		return nil
+	}
+	return rounded(cov / math.Sqrt(vx*vy))
+}
+
+// mean accumulates an average
+type mean struct {
+	sum float64
+	n   int
+}
+
+func (m *mean) add(v float64) {
+	m.sum += v
+	m.n++
+}
+
+// value is the rounded average, or nil without values
+func (m *mean) value() *float64 {
+	if m.n == 0 {
+		return nil
+	}
+	return rounded(m.sum / float64(m.n))
+}
+
+// rounded keeps two decimals, plenty for a mood score
+func rounded(f float64) *float64 {
+	r := math.Round(f*100) / 100
+	return &r
+}
@@ -0,0 +1,245 @@
+package insights
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"backend/models"
+)
+
+var (
+	jakarta = time.FixedZone("WIB", 7*3600)
+	newYork = time.FixedZone("EST", -5*3600)
+)
+
+func valence(v float64) *float64 { return &v }
+
+// checkinDays builds the per-day means Compute passes on, one check-in per day
+func checkinDays(averages map[string]float64) map[string]*mean {
+	byDay := map[string]*mean{}
+	for day, avg := range averages {
+		byDay[day] = &mean{sum: avg, n: 1}
+	}
+	return byDay
+}
+
+func TestComputeDayBoundaries(t *testing.T) {
+	// 23:30 and 00:30 in Jakarta, the same evening in UTC and New York
+	entries := []Entry{
+		{At: time.Date(2026, 10, 18, 16, 30, 0, 0, time.UTC), Valence: valence(0.5)},
+		{At: time.Date(2026, 10, 18, 17, 30, 0, 0, time.UTC), Valence: valence(0.5)},
+	}
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		loc      *time.Location
+		days     int
+		checkins int
+		current  int
+		weekday  time.Weekday
+		hour     int
+	}{
+		{"UTC", time.UTC, 7, 2, 1, time.Sunday, 16},
+		{"ahead of UTC", jakarta, 7, 2, 2, time.Sunday, 23},
+		{"behind UTC", newYork, 7, 2, 1, time.Sunday, 11},
+		// Only today: in Jakarta the second check-in is past midnight
+		{"window starts at local midnight", jakarta, 1, 1, 1, time.Monday, 0},
+		{"window excludes yesterday in UTC", time.UTC, 1, 0, 0, time.Sunday, 16},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Compute(entries, nil, tt.days, now, tt.loc)
+			if got.Checkins != tt.checkins {
+				t.Errorf("checkins = %d, want %d", got.Checkins, tt.checkins)
+			}
+			if got.Streaks.Current != tt.current {
+				t.Errorf("current streak = %d, want %d", got.Streaks.Current, tt.current)
+			}
+			if tt.checkins > 0 {
+				if got.Weekdays[tt.weekday].Checkins == 0 {
+					t.Errorf("no check-ins on %s", tt.weekday)
+				}
+				if got.Hours[tt.hour].Checkins == 0 {
+					t.Errorf("no check-ins at %02d:00", tt.hour)
+				}
+			}
+			if want := windowStart(tt.days, now, tt.loc); !got.From.Equal(want) || got.From.Hour() != 0 {
+				t.Errorf("from = %v, want local midnight %v", got.From, want)
+			}
+		})
+	}
+}
+
+func TestStreaks(t *testing.T) {
+	now := time.Date(2026, 10, 19, 20, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		days []string
+		want models.StreakInsights
+	}{
+		{"no check-ins", nil, models.StreakInsights{}},
+		{
+			"running through today",
+			[]string{"2026-10-17", "2026-10-18", "2026-10-19"},
+			models.StreakInsights{Current: 3, Longest: 3, LongestEnd: "2026-10-19"},
+		},
+		{
+			"today not checked in yet",
+			[]string{"2026-10-17", "2026-10-18"},
+			models.StreakInsights{Current: 2, Longest: 2, LongestEnd: "2026-10-18"},
+		},
+		{
+			"broken streak",
+			[]string{"2026-10-10", "2026-10-11", "2026-10-12", "2026-10-17"},
+			models.StreakInsights{Current: 0, Longest: 3, LongestEnd: "2026-10-12"},
+		},
+		{
+			"equal streaks end at the later one",
+			[]string{"2026-10-01", "2026-10-02", "2026-10-18", "2026-10-19"},
+			models.StreakInsights{Current: 2, Longest: 2, LongestEnd: "2026-10-19"},
+		},
+		{
+			"across a month end",
+			[]string{"2026-09-29", "2026-09-30", "2026-10-01"},
+			models.StreakInsights{Longest: 3, LongestEnd: "2026-10-01"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			byDay := map[string]*mean{}
+			for _, d := range tt.days {
+				byDay[d] = &mean{}
+			}
+			if got := streaks(byDay, now, time.UTC); got != tt.want {
+				t.Errorf("streaks() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBestAndWorst(t *testing.T) {
+	tests := []struct {
+		name      string
+		averages  map[string]float64
+		wantBest  []string
+		wantWorst []string
+	}{
+		{"one day", map[string]float64{"2026-10-19": 0.5}, []string{}, []string{}},
+		{
+			"ranked by average",
+			map[string]float64{"2026-10-15": -0.5, "2026-10-16": 0.9, "2026-10-17": 0.1, "2026-10-18": 0.7},
+			[]string{"2026-10-16", "2026-10-18"},
+			[]string{"2026-10-15", "2026-10-17"},
+		},
+		{
+			"constant series",
+			map[string]float64{"2026-10-15": 0.3, "2026-10-16": 0.3, "2026-10-17": 0.3, "2026-10-18": 0.3, "2026-10-19": 0.3},
+			[]string{"2026-10-19", "2026-10-18"},
+			[]string{"2026-10-17", "2026-10-16"},
+		},
+		{
+			"at most three each",
+			map[string]float64{
+				"2026-10-12": 0.1, "2026-10-13": 0.2, "2026-10-14": 0.3, "2026-10-15": 0.4,
+				"2026-10-16": 0.5, "2026-10-17": 0.6, "2026-10-18": 0.7, "2026-10-19": 0.8,
+			},
+			[]string{"2026-10-19", "2026-10-18", "2026-10-17"},
+			[]string{"2026-10-12", "2026-10-13", "2026-10-14"},
+		},
+	}
+	dayKeys := func(days []models.DayAverage) []string {
+		keys := []string{}
+		for _, d := range days {
+			keys = append(keys, d.Day)
+		}
+		return keys
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			best, worst := bestAndWorst(checkinDays(tt.averages))
+			if got := dayKeys(best); !reflect.DeepEqual(got, tt.wantBest) {
+				t.Errorf("best = %v, want %v", got, tt.wantBest)
+			}
+			if got := dayKeys(worst); !reflect.DeepEqual(got, tt.wantWorst) {
+				t.Errorf("worst = %v, want %v", got, tt.wantWorst)
+			}
+		})
+	}
+}
+
+func TestPearson(t *testing.T) {
+	tests := []struct {
+		name   string
+		xs, ys []float64
+		want   *float64
+	}{
+		{"constant meetings", []float64{2, 2, 2, 2, 2}, []float64{0.1, 0.5, -0.2, 0.3, 0.9}, nil},
+		{"constant mood", []float64{0, 1, 2, 3, 4}, []float64{0.4, 0.4, 0.4, 0.4, 0.4}, nil},
+		{"rising together", []float64{0, 1, 2, 3, 4}, []float64{-0.4, -0.2, 0, 0.2, 0.4}, valence(1)},
+		{"opposite", []float64{0, 1, 2, 3, 4}, []float64{0.4, 0.2, 0, -0.2, -0.4}, valence(-1)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := pearson(tt.xs, tt.ys); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("pearson() = %v, want %v", deref(got), deref(tt.want))
+			}
+		})
+	}
+}
+
+func TestMonthChange(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	// One check-in a day, from "from" up to "to" days ago (exclusive). The current period
+	// includes its start 30 days ago, so tests skip day 30 to give both periods 30 days.
+	daily := func(from, to int, v float64) []Entry {
+		var entries []Entry
+		for d := from; d < to; d++ {
+			entries = append(entries, Entry{At: now.AddDate(0, 0, -d), Valence: valence(v)})
+		}
+		return entries
+	}
+
+	tests := []struct {
+		name    string
+		entries []Entry
+		want    models.MonthChange
+	}{
+		{"no check-ins", nil, models.MonthChange{}},
+		{
+			"constant series",
+			append(daily(0, 30, 0.4), daily(31, 61, 0.4)...),
+			models.MonthChange{Current: valence(0.4), Previous: valence(0.4), Change: valence(0), CurrentCheckins: 30, PreviousCheckins: 30},
+		},
+		{
+			"nothing the month before",
+			daily(0, 10, 0.4),
+			models.MonthChange{Current: valence(0.4), CurrentCheckins: 10},
+		},
+		{
+			"improved",
+			append(daily(0, 30, 0.5), daily(31, 61, -0.25)...),
+			models.MonthChange{Current: valence(0.5), Previous: valence(-0.25), Change: valence(0.75), CurrentCheckins: 30, PreviousCheckins: 30},
+		},
+		{
+			"older and unscored check-ins are ignored",
+			[]Entry{{At: now.AddDate(0, 0, -61), Valence: valence(1)}, {At: now, Valence: nil}},
+			models.MonthChange{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := monthChange(tt.entries, now); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("monthChange() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func deref(f *float64) interface{} {
+	if f == nil {
+		return nil
+	}
+	return *f
+}
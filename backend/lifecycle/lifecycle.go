@@ -0,0 +1,97 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+)
+
+// Hook is a named step run during shutdown
+type Hook struct {
+	Name string
+	Fn   func(ctx context.Context) error
+}
+
+var (
+	mu           sync.Mutex
+	drainHooks   []Hook
+	stopHooks    []Hook
+	shuttingDown atomic.Bool
+)
+
+// OnDrain registers a hook that closes long-lived connections (WebSockets, event streams).
+// Drain hooks run concurrently with the HTTP drain, since those connections would otherwise
+// keep it waiting until the timeout.
+func OnDrain(name string, fn func(ctx context.Context) error) {
+	mu.Lock()
+	defer mu.Unlock()
+	drainHooks = append(drainHooks, Hook{Name: name, Fn: fn})
+}
+
+// OnStop registers a hook that runs once no request is in flight, e.g. flushing buffers.
+// Stop hooks run in reverse registration order, so dependencies registered early
+// (the database client) are released last.
+func OnStop(name string, fn func(ctx context.Context) error) {
+	mu.Lock()
+	defer mu.Unlock()
+	stopHooks = append(stopHooks, Hook{Name: name, Fn: fn})
+}
+
+// ShuttingDown reports whether Shutdown has started
+func ShuttingDown() bool {
+	return shuttingDown.Load()
+}
+
+// Shutdown stops the server and runs every registered hook before ctx expires
+func Shutdown(ctx context.Context, stopServer func(ctx context.Context) error) error {
+	shuttingDown.Store(true)
+
+	mu.Lock()
+	drain := append([]Hook(nil), drainHooks...)
+	stop := append([]Hook(nil), stopHooks...)
+	mu.Unlock()
+
+	var (
+		wg      sync.WaitGroup
+		errsMu  sync.Mutex
+		allErrs []error
+	)
+	record := func(phase string, h Hook, err error) {
+		if err == nil {
+			slog.Info("Shutdown step done", "phase", phase, "hook", h.Name)
+			return
+		}
+		slog.Error("Shutdown step failed", "phase", phase, "hook", h.Name, "error", err)
+		errsMu.Lock()
+		allErrs = append(allErrs, fmt.Errorf("%s: %w", h.Name, err))
+		errsMu.Unlock()
+	}
+
+	for _, h := range drain {
+		wg.Add(1)
+		go func(h Hook) {
+			defer wg.Done()
+			record("drain", h, h.Fn(ctx))
+		}(h)
+	}
+
+	// Stops accepting connections and waits for in-flight requests
+	serverErr := stopServer(ctx)
+	if serverErr != nil {
+		errsMu.Lock()
+		allErrs = append(allErrs, fmt.Errorf("http server: %w", serverErr))
+		errsMu.Unlock()
+	}
+	slog.Info("HTTP server drained", "error", serverErr)
+
+	wg.Wait()
+
+	for i := len(stop) - 1; i >= 0; i-- {
+		record("stop", stop[i], stop[i].Fn(ctx))
+	}
+
+	return errors.Join(allErrs...)
+}
@@ -1,22 +1,40 @@
 package main
 
 import (
-	"log"
+	"context"
+	"log/slog"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
+	"backend/account"
+	"backend/apperror"
 	"backend/config"
+	"backend/env"
+	"backend/events"
+	"backend/lifecycle"
+	"backend/middleware"
+	"backend/notifications"
+	"backend/presence"
+	"backend/reminders"
+	"backend/risk"
 	"backend/routes"
+	"backend/storage"
+	"backend/utils"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
-	"github.com/gofiber/fiber/v2/middleware/logger"
 	"github.com/joho/godotenv"
 )
 
 func main() {
-	err := godotenv.Load()
-	if err != nil {
-		log.Println("⚠️ No .env file found, using environment variables")
+	envErr := godotenv.Load()
+
+	// Logger needs LOG_LEVEL/LOG_FORMAT, so it is configured right after .env is read
+	utils.InitLogger()
+	if envErr != nil {
+		slog.Warn("No .env file found, using environment variables")
 	}
 
 	port := os.Getenv("PORT")
@@ -26,53 +44,80 @@ func main() {
 
 	// Check if JWT_SECRET is set
 	if os.Getenv("JWT_SECRET") == "" {
-		log.Println("⚠️ JWT_SECRET not set in environment variables, using default (insecure for production)")
+		slog.Warn("JWT_SECRET not set in environment variables, using default (insecure for production)")
+	}
+
+	// Uploads go to local disk or an S3-compatible bucket, see STORAGE_DRIVER
+	if err := storage.Init(); err != nil {
+		slog.Error("Failed to configure upload storage", "error", err)
+		os.Exit(1)
 	}
 
 	app := fiber.New(fiber.Config{
-		ErrorHandler: func(c *fiber.Ctx, err error) error {
-			// Log error secara detail
-			log.Printf("❌ ERROR: %v\nPath: %s, Method: %s", err, c.Path(), c.Method())
-
-			// Return error response
-			code := fiber.StatusInternalServerError
-			if e, ok := err.(*fiber.Error); ok {
-				code = e.Code
-			}
-
-			return c.Status(code).JSON(fiber.Map{
-				"error":  err.Error(),
-				"path":   c.Path(),
-				"method": c.Method(),
-			})
-		},
+		// Every returned error is mapped to the {"error": {code, message}} envelope
+		ErrorHandler: apperror.Handler,
 	})
 
 	// Konfigurasi CORS yang benar
 	app.Use(cors.New(cors.Config{
 		AllowOrigins:     "*", // Mengizinkan semua asal
 		AllowMethods:     "GET,POST,PUT,DELETE,OPTIONS",
-		AllowHeaders:     "Origin, Content-Type, Accept, Authorization",
+		AllowHeaders:     "Origin, Content-Type, Accept, Accept-Language, Authorization, X-Request-ID, Idempotency-Key",
 		AllowCredentials: false,
-		ExposeHeaders:    "Content-Length, Content-Disposition",
+		ExposeHeaders:    "Content-Length, Content-Disposition, X-Request-ID, Deprecation, Sunset, Link, X-Privacy-K, X-Privacy-Noise, X-Privacy-Merged, X-Privacy-Suppressed, X-Valence-Mean, Idempotent-Replayed",
 	}))
 
-	// Add Logger middleware
-	app.Use(logger.New())
-
-	// Serve static files
-	app.Static("/uploads", "./uploads")
+	// Request ID first so every later log line carries it
+	app.Use(middleware.RequestID())
+	app.Use(middleware.RequestLogger())
+	app.Use(middleware.Metrics())
 
-	// Connect to database
+	// Connect to database; the connection is retried in the background until MongoDB is up,
+	// then migrations run
 	config.ConnectDB()
-	log.Println("✅ Connected to database")
+	config.StartMigrations()
+
+	// Track presence in memory and flush lastActive in batches
+	presence.Start()
+
+	// Permanently delete accounts whose deletion grace period has ended
+	account.Start()
+
+	// Push new notifications to open feeds; remind users who haven't checked in yet
+	notifications.Start()
+	reminders.Start()
+
+	// Stream team activity to dashboards, through a change stream when MongoDB has one
+	events.Start()
+
+	// Look for wellbeing risks in the check-ins and alert team leaders
+	risk.Start()
 
 	// Setup routes
 	routes.SetupRoutes(app)
 
 	// PERBAIKAN: hanya satu app.Listen yang dijalankan
-	log.Printf("🚀 Server running at http://localhost:%s", port)
-	if err := app.Listen(":" + port); err != nil {
-		log.Fatal("❌ Failed to start server:", err)
+	go func() {
+		slog.Info("Server running", "addr", "http://localhost:"+port)
+		if err := app.Listen(":" + port); err != nil {
+			slog.Error("Failed to start server", "error", err)
+			os.Exit(1)
+		}
+	}()
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
+	sig := <-quit
+
+	timeout := env.Duration("SHUTDOWN_TIMEOUT", 15*time.Second)
+	slog.Info("Shutting down", "signal", sig.String(), "timeout", timeout.String())
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if err := lifecycle.Shutdown(ctx, app.ShutdownWithContext); err != nil {
+		slog.Error("Shutdown finished with errors", "error", err)
+		os.Exit(1)
 	}
+	slog.Info("Shutdown complete")
 }
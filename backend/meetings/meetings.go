@@ -0,0 +1,80 @@
+// Package meetings keeps track of who was in which meeting room and when, for personal
+// insights. The meetings themselves run in the browser.
+package meetings
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"backend/config"
+	"backend/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Collection holds meeting attendance
+const Collection = "meeting_attendance"
+
+// Joined records that the user entered the meeting room at
+func Joined(ctx context.Context, userID, meetingID string, at time.Time) error {
+	_, err := config.DB.Collection(Collection).InsertOne(ctx, models.MeetingAttendance{
+		UserID:    userID,
+		MeetingID: meetingID,
+		JoinedAt:  at,
+	})
+	return err
+}
+
+// Left records that the user left the meeting room at, closing their latest open
+// attendance. Leaving a room that wasn't joined is ignored.
+func Left(ctx context.Context, userID, meetingID string, at time.Time) error {
+	err := config.DB.Collection(Collection).FindOneAndUpdate(ctx,
+		bson.M{"userId": userID, "meetingId": meetingID, "leftAt": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"leftAt": at}},
+		options.FindOneAndUpdate().SetSort(bson.D{{Key: "joinedAt", Value: -1}}),
+	).Err()
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil
+	}
+	return err
+}
+
+// Attended returns the user's attendance since since, oldest first
+func Attended(ctx context.Context, userID string, since time.Time) ([]models.MeetingAttendance, error) {
+	cursor, err := config.DB.Collection(Collection).Find(ctx,
+		bson.M{"userId": userID, "joinedAt": bson.M{"$gte": since}},
+		options.Find().SetSort(bson.D{{Key: "joinedAt", Value: 1}}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	attended := []models.MeetingAttendance{}
+	err = cursor.All(ctx, &attended)
+	return attended, err
+}
+
+// Attendees returns the IDs of the users who joined the meeting room since since
+func Attendees(ctx context.Context, meetingID string, since time.Time) ([]string, error) {
+	ids, err := config.DB.Collection(Collection).Distinct(ctx, "userId",
+		bson.M{"meetingId": meetingID, "joinedAt": bson.M{"$gte": since}},
+	)
+	if err != nil {
+		return nil, err
+	}
+	attendees := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if s, ok := id.(string); ok {
+			attendees = append(attendees, s)
+		}
+	}
+	return attendees, nil
+}
+
+// DeleteForUser removes the user's attendance, e.g. when the account is purged
+func DeleteForUser(ctx context.Context, userID string) error {
+	_, err := config.DB.Collection(Collection).DeleteMany(ctx, bson.M{"userId": userID})
+	return err
+}
@@ -0,0 +1,89 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "newrpl"
+
+// HTTP
+var (
+	HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by method, route template and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	HTTPRequestsInFlight = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "http_requests_in_flight",
+		Help:      "HTTP requests currently being served.",
+	})
+)
+
+// LegacyRouteRequests counts calls to deprecated unversioned routes so they can be retired safely
+var LegacyRouteRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Name:      "legacy_route_requests_total",
+	Help:      "Requests served by deprecated pre-/api/v1 routes.",
+}, []string{"method", "route"})
+
+// MongoDB
+var MongoCommandDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: namespace,
+	Name:      "mongo_command_duration_seconds",
+	Help:      "MongoDB command latency by command, collection and outcome.",
+	Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+}, []string{"command", "collection", "outcome"})
+
+// Long-lived connections (WebSocket, meeting rooms), labelled by kind
+var ActiveConnections = promauto.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: namespace,
+	Name:      "active_connections",
+	Help:      "Open long-lived client connections by kind.",
+}, []string{"kind"})
+
+// Domain counters
+var (
+	CheckinsSaved = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "checkins_saved_total",
+		Help:      "Emotion check-ins stored, by mood. Moods are validated against the mood definitions, which keeps the label bounded.",
+	}, []string{"mood"})
+
+	Logins = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "logins_total",
+		Help:      "Login attempts by result (success, failure) and reason.",
+	}, []string{"result", "reason"})
+
+	UploadsStored = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "uploads_stored_total",
+		Help:      "Uploaded files stored successfully.",
+	})
+
+	RemindersSent = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "reminders_sent_total",
+		Help:      "Check-in reminder deliveries by channel and result (sent, skipped, failed).",
+	}, []string{"channel", "result"})
+
+	AlertsRaised = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "wellbeing_alerts_raised_total",
+		Help:      "Wellbeing alerts raised by the risk rules, by scope (user, team) and rule.",
+	}, []string{"scope", "rule"})
+)
+
+// LoginSucceeded records a successful login
+func LoginSucceeded() {
+	Logins.WithLabelValues("success", "").Inc()
+}
+
+// LoginFailed records a failed login with a short machine-readable reason
+func LoginFailed(reason string) {
+	Logins.WithLabelValues("failure", reason).Inc()
+}
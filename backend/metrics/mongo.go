@@ -0,0 +1,47 @@
+package metrics
+
+import (
+	"context"
+	"strconv"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/event"
+)
+
+// MongoMonitor returns a command monitor that records the duration of every
+// MongoDB command in MongoCommandDuration
+func MongoMonitor() *event.CommandMonitor {
+	// Collection names are only present on the started event, keyed by connection and request ID
+	var collections sync.Map
+
+	key := func(connectionID string, requestID int64) string {
+		return connectionID + "/" + strconv.FormatInt(requestID, 10)
+	}
+
+	finish := func(e event.CommandFinishedEvent, outcome string) {
+		collection := "unknown"
+		if v, ok := collections.LoadAndDelete(key(e.ConnectionID, e.RequestID)); ok {
+			collection = v.(string)
+		}
+		MongoCommandDuration.WithLabelValues(e.CommandName, collection, outcome).Observe(e.Duration.Seconds())
+	}
+
+	return &event.CommandMonitor{
+		Started: func(_ context.Context, e *event.CommandStartedEvent) {
+			// The command document starts with {<commandName>: <collection>} for CRUD commands
+			collection := "none"
+			if v, err := e.Command.LookupErr(e.CommandName); err == nil {
+				if name, ok := v.StringValueOK(); ok {
+					collection = name
+				}
+			}
+			collections.Store(key(e.ConnectionID, e.RequestID), collection)
+		},
+		Succeeded: func(_ context.Context, e *event.CommandSucceededEvent) {
+			finish(e.CommandFinishedEvent, "success")
+		},
+		Failed: func(_ context.Context, e *event.CommandFailedEvent) {
+			finish(e.CommandFinishedEvent, "failure")
+		},
+	}
+}
@@ -1,10 +1,17 @@
 package middleware
 
 import (
+	"backend/account"
+	"backend/apperror"
+	"backend/presence"
 	"backend/utils"
+	"context"
 	"fmt"
+	"log/slog"
 	"strings"
+	"time"
 
+	"github.com/gofiber/contrib/websocket"
 	"github.com/gofiber/fiber/v2"
 	"github.com/golang-jwt/jwt/v5"
 )
@@ -15,14 +22,16 @@ func Protected() fiber.Handler {
 		// Get authorization header
 		authHeader := c.Get("Authorization")
 
-		// Debug authorization header
-		fmt.Println("Auth header received:", authHeader)
+		// Browsers can't set headers on WebSocket handshakes or EventSource requests,
+		// so those may pass ?access_token=
+		streaming := websocket.IsWebSocketUpgrade(c) || strings.Contains(c.Get("Accept"), "text/event-stream")
+		if authHeader == "" && streaming && c.Query("access_token") != "" {
+			authHeader = "Bearer " + c.Query("access_token")
+		}
 
 		// Cek apakah header authorization ada dan formatnya benar
 		if authHeader == "" || !strings.HasPrefix(authHeader, "Bearer ") {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"error": "Unauthorized - Missing or invalid token format",
-			})
+			return apperror.AuthTokenMissing
 		}
 
 		// Extract token
@@ -38,22 +47,37 @@ func Protected() fiber.Handler {
 		})
 
 		if err != nil {
-			fmt.Println("Token validation error:", err)
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"error": "Unauthorized - Invalid or expired token",
-			})
+			utils.Log(c).Warn("token validation failed", "error", err)
+			return apperror.AuthTokenInvalid.Wrap(err)
 		}
 
 		// Validasi token dan extract claims
 		if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
 			// Set user info in context untuk route handlers
 			c.Locals("user", claims)
-			fmt.Println("User authenticated with ID:", claims["id"])
+
+			// Tag every log line of this request with the authenticated user
+			logger := utils.Log(c).With(slog.Any("user_id", claims["id"]))
+			c.Locals(utils.LoggerKey, logger)
+			logger.Debug("user authenticated")
+
+			// Tokens outlive deleted accounts, so check the account is still there
+			userID, _ := claims["id"].(string)
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			active, err := account.Active(ctx, userID)
+			cancel()
+			if err != nil {
+				return apperror.Internal.Wrap(err)
+			}
+			if !active {
+				return apperror.AuthTokenInvalid
+			}
+
+			// Every authenticated request counts as activity for presence
+			presence.Touch(userID)
 			return c.Next()
 		}
 
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error": "Unauthorized - Invalid token claims",
-		})
+		return apperror.AuthTokenInvalid
 	}
 }
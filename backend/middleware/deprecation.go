@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"time"
+
+	"backend/metrics"
+	"backend/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// LegacyDeprecatedAt is when the unversioned routes were superseded by /api/v1
+var LegacyDeprecatedAt = time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC)
+
+// defaultLegacySunset gives clients a three month transition window
+var defaultLegacySunset = LegacyDeprecatedAt.AddDate(0, 3, 0)
+
+var routeParam = regexp.MustCompile(`:(\w+)`)
+
+// LegacySunset reads LEGACY_SUNSET (YYYY-MM-DD), the date after which legacy routes may be removed
+func LegacySunset() time.Time {
+	if v := os.Getenv("LEGACY_SUNSET"); v != "" {
+		t, err := time.Parse(time.DateOnly, v)
+		if err == nil {
+			return t
+		}
+	}
+	return defaultLegacySunset
+}
+
+// Deprecated marks a legacy route with Deprecation, Sunset and successor Link headers
+// and records who still calls it. successor is the /api/v1 route template, e.g. "/api/v1/users/:id".
+func Deprecated(successor string) fiber.Handler {
+	deprecation := fmt.Sprintf("@%d", LegacyDeprecatedAt.Unix())
+	sunset := LegacySunset().UTC().Format(http.TimeFormat)
+
+	return func(c *fiber.Ctx) error {
+		target := routeParam.ReplaceAllStringFunc(successor, func(param string) string {
+			return c.Params(param[1:])
+		})
+
+		c.Set("Deprecation", deprecation)
+		c.Set("Sunset", sunset)
+		c.Set(fiber.HeaderLink, fmt.Sprintf(`<%s>; rel="successor-version"`, target))
+
+		metrics.LegacyRouteRequests.WithLabelValues(c.Method(), c.Route().Path).Inc()
+		utils.Log(c).Warn("legacy route used",
+			"route", c.Route().Path,
+			"successor", successor,
+			"user_agent", c.Get(fiber.HeaderUserAgent),
+		)
+
+		return c.Next()
+	}
+}
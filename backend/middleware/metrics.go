@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"strconv"
+	"time"
+
+	"backend/apperror"
+	"backend/metrics"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Metrics records request latency per route template in Prometheus
+func Metrics() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+		self := c.Route()
+		metrics.HTTPRequestsInFlight.Inc()
+		defer metrics.HTTPRequestsInFlight.Dec()
+
+		err := c.Next()
+
+		status := c.Response().StatusCode()
+		if err != nil {
+			status = apperror.StatusOf(err)
+		}
+
+		// Use the route template, not the raw path, to keep label cardinality bounded
+		route := c.Route().Path
+		if c.Route() == self {
+			// Nothing after this middleware matched the request
+			route = "unmatched"
+		}
+
+		metrics.HTTPRequestDuration.
+			WithLabelValues(c.Method(), route, strconv.Itoa(status)).
+			Observe(time.Since(start).Seconds())
+
+		return err
+	}
+}
@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"fmt"
+	"time"
+
+	"backend/apperror"
+	"backend/env"
+	"backend/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/limiter"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// MeetingNotifyLimit caps how many meeting invites and mentions one user may send per
+// MEETING_NOTIFY_WINDOW (default 10 per minute, from MEETING_NOTIFY_LIMIT), so nobody can
+// flood other people's notifications. Counts are kept per replica. Use it after Protected
+// and share one instance between the routes it covers.
+func MeetingNotifyLimit() fiber.Handler {
+	return limiter.New(limiter.Config{
+		Max:        env.PositiveInt("MEETING_NOTIFY_LIMIT", 10),
+		Expiration: env.Duration("MEETING_NOTIFY_WINDOW", time.Minute),
+		KeyGenerator: func(c *fiber.Ctx) string {
+			if claims, ok := c.Locals("user").(jwt.MapClaims); ok {
+				return fmt.Sprint(claims["id"])
+			}
+			return c.IP()
+		},
+		LimitReached: func(c *fiber.Ctx) error {
+			utils.Log(c).Warn("meeting notification rate limit reached")
+			return apperror.TooManyRequests
+		},
+	})
+}
@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"log/slog"
+	"regexp"
+
+	"backend/utils"
+
+	"github.com/gofiber/fiber/v2"
+	fiberutils "github.com/gofiber/fiber/v2/utils"
+)
+
+// RequestIDHeader is read from incoming requests and echoed on every response
+const RequestIDHeader = "X-Request-ID"
+
+// Only accept client-supplied IDs that are safe to put in logs and headers
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9\-_.]{1,64}$`)
+
+// RequestID assigns every request an ID, exposes it in the response header
+// and attaches a logger carrying it to the context
+func RequestID() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id := c.Get(RequestIDHeader)
+		if !validRequestID.MatchString(id) {
+			id = fiberutils.UUIDv4()
+		}
+
+		c.Set(RequestIDHeader, id)
+		c.Locals(utils.RequestIDKey, id)
+		c.Locals(utils.LoggerKey, slog.Default().With(slog.String("request_id", id)))
+
+		return c.Next()
+	}
+}
@@ -1,20 +1,116 @@
 package models
 
 import (
-    "time"
-    "go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type Emotion struct {
-    ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-    UserID    primitive.ObjectID `bson:"user_id" json:"user_id"`
-    UserName  string             `bson:"user_name" json:"user_name"`
-    Mood      string             `bson:"mood" json:"mood"`
-    Note      string             `bson:"note,omitempty" json:"note"`
-    CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID    primitive.ObjectID `bson:"user_id,omitempty" json:"user_id"`
+	UserName  string             `bson:"user_name" json:"user_name"`
+	Team      string             `bson:"team,omitempty" json:"team,omitempty"`
+	Mood      string             `bson:"mood" json:"mood"`
+	Note      string             `bson:"note,omitempty" json:"note"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt *time.Time         `bson:"updated_at,omitempty" json:"updated_at,omitempty"`
+
+	// Optional 1–5 ratings, see Dimensions
+	Energy   *int     `bson:"energy,omitempty" json:"energy,omitempty"`
+	Stress   *int     `bson:"stress,omitempty" json:"stress,omitempty"`
+	Workload *int     `bson:"workload,omitempty" json:"workload,omitempty"`
+	Sleep    *int     `bson:"sleep,omitempty" json:"sleep,omitempty"`
+	Tags     []string `bson:"tags,omitempty" json:"tags,omitempty"`
+
+	// Anonymized check-ins belonged to a deleted account: user_id is zero and the name
+	// and note are removed, but they still count in aggregates
+	Anonymized bool `bson:"anonymized,omitempty" json:"anonymized,omitempty"`
+
+	// Anonymous check-ins have no user_id, name or note; they carry the team and a
+	// pseudonym that only identifies the user within Period, to limit repeat submissions
+	Anonymous bool   `bson:"anonymous,omitempty" json:"anonymous,omitempty"`
+	Pseudonym string `bson:"pseudonym,omitempty" json:"-"`
+	Period    string `bson:"period,omitempty" json:"-"`
+
+	// Day is set when one check-in per user per day is enforced, see checkin.Settings.
+	// IdempotencyKey is a hash of the client's Idempotency-Key header and who sent it.
+	Day            string `bson:"day,omitempty" json:"-"`
+	IdempotencyKey string `bson:"idempotency_key,omitempty" json:"-"`
 }
 
 type EmotionStats struct {
-    Mood  string `bson:"_id" json:"mood"`
-    Count int    `bson:"count" json:"count"`
-}
\ No newline at end of file
+	Mood  string `bson:"_id" json:"mood"`
+	Count int    `bson:"count" json:"count"`
+
+	// Presentation and scores from the mood's definition; absent for "other"
+	Label   string   `bson:"-" json:"label,omitempty"`
+	Emoji   string   `bson:"-" json:"emoji,omitempty"`
+	Color   string   `bson:"-" json:"color,omitempty"`
+	Valence *float64 `bson:"-" json:"valence,omitempty"`
+	Arousal *float64 `bson:"-" json:"arousal,omitempty"`
+}
+
+// Dimensions are the numeric ratings a check-in may include, each on a 1–5 scale.
+// The names are both the JSON/BSON field names and the values of ?dimensions=.
+var Dimensions = []string{"energy", "stress", "workload", "sleep"}
+
+// EmotionRequest is the body of POST /emotions. Anonymous check-ins are attributed to
+// the caller's token; user_name, note and tags are not stored for them.
+type EmotionRequest struct {
+	// UserID is optional; check-ins always belong to the caller, so it must be their own ID
+	UserID    string   `json:"user_id" validate:"omitempty,mongodb"`
+	UserName  string   `json:"user_name" validate:"max=100"`
+	Mood      string   `json:"mood" validate:"required,mood"`
+	Note      string   `json:"note" validate:"max=1000"`
+	Anonymous bool     `json:"anonymous"`
+	Energy    *int     `json:"energy" validate:"omitempty,gte=1,lte=5"`
+	Stress    *int     `json:"stress" validate:"omitempty,gte=1,lte=5"`
+	Workload  *int     `json:"workload" validate:"omitempty,gte=1,lte=5"`
+	Sleep     *int     `json:"sleep" validate:"omitempty,gte=1,lte=5"`
+	Tags      []string `json:"tags" validate:"max=10,dive,required,max=32"`
+}
+
+// EmotionUpdateRequest is the body of PUT /emotions/:id; omitted fields are left
+// unchanged, a rating of 0 removes it and an empty tag list removes the tags
+type EmotionUpdateRequest struct {
+	Mood     *string   `json:"mood" validate:"omitempty,mood"`
+	Note     *string   `json:"note" validate:"omitempty,max=1000"`
+	Energy   *int      `json:"energy" validate:"omitempty,gte=0,lte=5"`
+	Stress   *int      `json:"stress" validate:"omitempty,gte=0,lte=5"`
+	Workload *int      `json:"workload" validate:"omitempty,gte=0,lte=5"`
+	Sleep    *int      `json:"sleep" validate:"omitempty,gte=0,lte=5"`
+	Tags     *[]string `json:"tags" validate:"omitempty,max=10,dive,required,max=32"`
+}
+
+// EmotionStatsQuery holds the query parameters shared by the stats endpoints.
+// Tag may list several comma-separated tags, all of which must be present.
+type EmotionStatsQuery struct {
+	Period string `query:"period" json:"period"`
+	Team   string `query:"team" json:"team" validate:"max=100"`
+	Tag    string `query:"tag" json:"tag" validate:"max=200"`
+}
+
+// DimensionStatsQuery holds the query parameters of GET /emotions/stats/dimensions;
+// Dimensions is a comma-separated subset of Dimensions, all of them if empty
+type DimensionStatsQuery struct {
+	Period     string `query:"period" json:"period"`
+	Team       string `query:"team" json:"team" validate:"max=100"`
+	Tag        string `query:"tag" json:"tag" validate:"max=200"`
+	Dimensions string `query:"dimensions" json:"dimensions" validate:"max=100"`
+}
+
+// DimensionStats summarises one dimension over the check-ins that rated it
+type DimensionStats struct {
+	Dimension string   `json:"dimension"`
+	Count     int      `json:"count"`
+	Mean      *float64 `json:"mean,omitempty"`
+	Min       *int     `json:"min,omitempty"`
+	Max       *int     `json:"max,omitempty"`
+	P25       *int     `json:"p25,omitempty"`
+	P50       *int     `json:"p50,omitempty"`
+	P75       *int     `json:"p75,omitempty"`
+	P90       *int     `json:"p90,omitempty"`
+	// Suppressed is set when fewer than k users rated the dimension; no numbers are shown
+	Suppressed bool `json:"suppressed,omitempty"`
+}
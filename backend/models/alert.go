@@ -0,0 +1,57 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Alert statuses: an open alert is acknowledged by a team leader, then resolved by
+// them or automatically once the rule stops matching
+const (
+	AlertOpen         = "open"
+	AlertAcknowledged = "acknowledged"
+	AlertResolved     = "resolved"
+)
+
+// AlertStatuses lists the statuses alerts can be filtered by
+var AlertStatuses = []string{AlertOpen, AlertAcknowledged, AlertResolved}
+
+// Alert is a wellbeing risk the rules engine found in a user's or a team's check-ins.
+// Team leaders of Team see it.
+type Alert struct {
+	ID primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	// Scope is "user" for one person's check-ins, "team" for the team's aggregate
+	Scope    string `json:"scope" bson:"scope"`
+	Rule     string `json:"rule" bson:"rule"`
+	Team     string `json:"team" bson:"team"`
+	UserID   string `json:"userId,omitempty" bson:"userId,omitempty"`
+	UserName string `json:"userName,omitempty" bson:"userName,omitempty"`
+	// Details holds the numbers behind the alert, e.g. the baseline and recent averages
+	Details map[string]interface{} `json:"details,omitempty" bson:"details,omitempty"`
+	Status  string                 `json:"status" bson:"status"`
+	// Key identifies the subject and rule until the rule stops matching, so an episode
+	// raises one alert even when a leader resolves it early
+	Key string `json:"-" bson:"key,omitempty"`
+
+	CreatedAt      time.Time  `json:"createdAt" bson:"createdAt"`
+	LastSeenAt     time.Time  `json:"lastSeenAt" bson:"lastSeenAt"`
+	AcknowledgedAt *time.Time `json:"acknowledgedAt,omitempty" bson:"acknowledgedAt,omitempty"`
+	AcknowledgedBy string     `json:"acknowledgedBy,omitempty" bson:"acknowledgedBy,omitempty"`
+	ResolvedAt     *time.Time `json:"resolvedAt,omitempty" bson:"resolvedAt,omitempty"`
+	// ResolvedBy is the leader's user ID, or "system" when the rule stopped matching
+	ResolvedBy string `json:"resolvedBy,omitempty" bson:"resolvedBy,omitempty"`
+	Note       string `json:"note,omitempty" bson:"note,omitempty"`
+}
+
+// AlertListQuery holds the query parameters of GET /alerts
+type AlertListQuery struct {
+	Limit  int    `query:"limit" json:"limit" validate:"omitempty,min=1,max=100"`
+	Cursor string `query:"cursor" json:"cursor"`
+	Status string `query:"status" json:"status" validate:"omitempty,oneof=open acknowledged resolved"`
+}
+
+// AlertActionRequest is the optional body of PUT /alerts/:id/acknowledge and /resolve
+type AlertActionRequest struct {
+	Note string `json:"note" validate:"max=1000"`
+}
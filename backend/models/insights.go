@@ -0,0 +1,82 @@
+package models
+
+import "time"
+
+// InsightsQuery holds the query parameters of GET /me/insights
+type InsightsQuery struct {
+	// Days is how far back to look, today included; 90 by default
+	Days int `query:"days" json:"days" validate:"omitempty,min=7,max=365"`
+	// Timezone decides which day and hour a check-in falls on; defaults to the
+	// timezone of the reminder settings
+	Timezone string `query:"timezone" json:"timezone" validate:"omitempty,timezone"`
+}
+
+// Insights summarises the caller's own named check-ins. Averages are mood valences, from
+// -1 to 1, and are null where there was nothing to average.
+type Insights struct {
+	From     time.Time `json:"from"`
+	To       time.Time `json:"to"`
+	Timezone string    `json:"timezone"`
+	Checkins int       `json:"checkins"`
+	Average  *float64  `json:"average"`
+
+	Streaks StreakInsights `json:"streaks"`
+	// Weekdays has one slot per day of the week, 0 being Sunday
+	Weekdays []SlotAverage `json:"weekdays"`
+	// Hours has one slot per hour of the day
+	Hours     []SlotAverage      `json:"hours"`
+	Tags      []TagCount         `json:"tags"`
+	BestDays  []DayAverage       `json:"bestDays"`
+	WorstDays []DayAverage       `json:"worstDays"`
+	Month     MonthChange        `json:"monthChange"`
+	Meetings  MeetingCorrelation `json:"meetings"`
+}
+
+// StreakInsights counts consecutive days with a check-in
+type StreakInsights struct {
+	// Current ends today, or yesterday while today has no check-in yet
+	Current int `json:"current"`
+	Longest int `json:"longest"`
+	// LongestEnd is the last day of the longest streak, as "2006-01-02"
+	LongestEnd string `json:"longestEnd,omitempty"`
+}
+
+// SlotAverage is the average mood of the check-ins in one weekday or hour
+type SlotAverage struct {
+	Slot     int      `json:"slot"`
+	Checkins int      `json:"checkins"`
+	Average  *float64 `json:"average"`
+}
+
+// TagCount is how often a tag was used
+type TagCount struct {
+	Tag   string `json:"tag"`
+	Count int    `json:"count"`
+}
+
+// DayAverage is the average mood of one day, as "2006-01-02"
+type DayAverage struct {
+	Day      string  `json:"day"`
+	Checkins int     `json:"checkins"`
+	Average  float64 `json:"average"`
+}
+
+// MonthChange compares the last 30 days with the 30 days before them
+type MonthChange struct {
+	Current          *float64 `json:"current"`
+	Previous         *float64 `json:"previous"`
+	Change           *float64 `json:"change"`
+	CurrentCheckins  int      `json:"currentCheckins"`
+	PreviousCheckins int      `json:"previousCheckins"`
+}
+
+// MeetingCorrelation relates the daily average mood to the number of meetings attended
+// that day
+type MeetingCorrelation struct {
+	// Days with a check-in that were compared
+	Days int `json:"days"`
+	// Correlation is Pearson's r, from -1 to 1; null with too few days or no variation
+	Correlation     *float64 `json:"correlation"`
+	WithMeetings    *float64 `json:"withMeetings"`
+	WithoutMeetings *float64 `json:"withoutMeetings"`
+}
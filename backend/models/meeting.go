@@ -0,0 +1,17 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MeetingAttendance records that a user was in a meeting room. Meetings themselves run
+// in the browser; the meeting room reports joining and leaving.
+type MeetingAttendance struct {
+	ID        primitive.ObjectID `json:"-" bson:"_id,omitempty"`
+	UserID    string             `json:"-" bson:"userId"`
+	MeetingID string             `json:"meetingId" bson:"meetingId"`
+	JoinedAt  time.Time          `json:"joinedAt" bson:"joinedAt"`
+	LeftAt    *time.Time         `json:"leftAt,omitempty" bson:"leftAt,omitempty"`
+}
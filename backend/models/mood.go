@@ -0,0 +1,65 @@
+package models
+
+// MoodDefinition is one entry of the organization's mood vocabulary, stored in the
+// moods collection. Check-ins must use the key of an active definition.
+type MoodDefinition struct {
+	Key string `json:"key" bson:"key"`
+	// Labels holds the display name per locale ("id", "en")
+	Labels map[string]string `json:"labels" bson:"labels"`
+	Emoji  string            `json:"emoji" bson:"emoji"`
+	Color  string            `json:"color" bson:"color"`
+	// Valence scores how pleasant the mood is, from -1 (very unpleasant) to 1
+	Valence float64 `json:"valence" bson:"valence"`
+	// Arousal scores how energetic the mood is, from -1 (calm, drained) to 1 (activated)
+	Arousal float64 `json:"arousal" bson:"arousal"`
+	// Order positions the mood in pickers and statistics, ascending
+	Order int `json:"order" bson:"order"`
+	// Expressions lists the face-api expressions that map to this mood
+	Expressions []string `json:"expressions,omitempty" bson:"expressions,omitempty"`
+	// Inactive moods can't be chosen anymore but still label existing check-ins
+	Inactive bool `json:"inactive,omitempty" bson:"inactive,omitempty"`
+}
+
+// Label returns the display name in locale, falling back to the key
+func (d MoodDefinition) Label(locale string) string {
+	if label := d.Labels[locale]; label != "" {
+		return label
+	}
+	for _, label := range d.Labels {
+		return label
+	}
+	return d.Key
+}
+
+// DefaultMoods seeds the moods collection: the Dashboard's options plus the five-point
+// scale of the Insights page. face-api's angry, fearful and disgusted map to stressed,
+// surprised to excited.
+var DefaultMoods = []MoodDefinition{
+	{Key: "very_happy", Labels: map[string]string{"id": "Sangat senang", "en": "Very happy"}, Emoji: "😄", Color: "#2ecc71", Valence: 1, Arousal: 0.6, Order: 10},
+	{Key: "happy", Labels: map[string]string{"id": "Senang", "en": "Happy"}, Emoji: "😊", Color: "#4bc0c0", Valence: 0.7, Arousal: 0.3, Order: 20, Expressions: []string{"happy"}},
+	{Key: "excited", Labels: map[string]string{"id": "Bersemangat", "en": "Excited"}, Emoji: "😃", Color: "#36a2eb", Valence: 0.8, Arousal: 0.9, Order: 30, Expressions: []string{"surprised"}},
+	{Key: "neutral", Labels: map[string]string{"id": "Biasa saja", "en": "Neutral"}, Emoji: "😐", Color: "#9966ff", Valence: 0, Arousal: 0, Order: 40, Expressions: []string{"neutral"}},
+	{Key: "tired", Labels: map[string]string{"id": "Lelah", "en": "Tired"}, Emoji: "😔", Color: "#ff9f40", Valence: -0.3, Arousal: -0.7, Order: 50},
+	{Key: "stressed", Labels: map[string]string{"id": "Stres", "en": "Stressed"}, Emoji: "😠", Color: "#ff6384", Valence: -0.7, Arousal: 0.8, Order: 60, Expressions: []string{"angry", "fearful", "disgusted"}},
+	{Key: "sad", Labels: map[string]string{"id": "Sedih", "en": "Sad"}, Emoji: "😢", Color: "#5d6d7e", Valence: -0.7, Arousal: -0.4, Order: 70, Expressions: []string{"sad"}},
+	{Key: "very_sad", Labels: map[string]string{"id": "Sangat sedih", "en": "Very sad"}, Emoji: "😭", Color: "#34495e", Valence: -1, Arousal: -0.5, Order: 80},
+}
+
+// ExpressionMatchRequest is the body of POST /moods/match: face-api expression
+// probabilities such as {"happy": 0.92, "neutral": 0.05}
+type ExpressionMatchRequest struct {
+	Expressions map[string]float64 `json:"expressions" validate:"required,min=1,max=20,dive,keys,max=32,endkeys,gte=0,lte=1"`
+}
+
+// MoodDefinitionRequest is the body of PUT /moods/:key. Moods are never deleted, since
+// check-ins keep their keys; set Inactive to retire one.
+type MoodDefinitionRequest struct {
+	Labels      map[string]string `json:"labels" validate:"required,min=1,dive,keys,oneof=id en,endkeys,required,max=50"`
+	Emoji       string            `json:"emoji" validate:"max=16"`
+	Color       string            `json:"color" validate:"omitempty,hexcolor"`
+	Valence     float64           `json:"valence" validate:"gte=-1,lte=1"`
+	Arousal     float64           `json:"arousal" validate:"gte=-1,lte=1"`
+	Order       int               `json:"order"`
+	Expressions []string          `json:"expressions" validate:"max=7,dive,oneof=neutral happy sad angry fearful disgusted surprised"`
+	Inactive    bool              `json:"inactive"`
+}
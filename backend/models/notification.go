@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Notification is an in-app message for one user
+type Notification struct {
+	ID        primitive.ObjectID     `json:"id" bson:"_id,omitempty"`
+	UserID    string                 `json:"-" bson:"userId"`
+	Type      string                 `json:"type" bson:"type"`
+	Title     string                 `json:"title" bson:"title"`
+	Body      string                 `json:"body,omitempty" bson:"body,omitempty"`
+	Link      string                 `json:"link,omitempty" bson:"link,omitempty"`
+	Payload   map[string]interface{} `json:"payload,omitempty" bson:"payload,omitempty"`
+	Read      bool                   `json:"read" bson:"read"`
+	CreatedAt time.Time              `json:"createdAt" bson:"createdAt"`
+	ReadAt    *time.Time             `json:"readAt,omitempty" bson:"readAt,omitempty"`
+}
+
+// NotificationListQuery holds the query parameters of GET /notifications
+type NotificationListQuery struct {
+	Limit  int    `query:"limit" json:"limit" validate:"omitempty,min=1,max=100"`
+	Cursor string `query:"cursor" json:"cursor"`
+	Unread bool   `query:"unread" json:"unread"`
+}
+
+// MeetingInviteRequest is the body of POST /meetings/:id/invites
+type MeetingInviteRequest struct {
+	UserIDs []string `json:"userIds" validate:"required,max=50,dive,required,max=64"`
+}
+
+// MentionRequest is the body of POST /meetings/:id/mentions; Message is the chat line
+// the users were mentioned in, shown shortened in the notification
+type MentionRequest struct {
+	UserIDs []string `json:"userIds" validate:"required,max=20,dive,required,max=64"`
+	Message string   `json:"message" validate:"required,max=1000"`
+}
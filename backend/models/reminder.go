@@ -0,0 +1,60 @@
+package models
+
+import "time"
+
+// ReminderChannels are the ways a reminder can reach a user
+var ReminderChannels = []string{"in_app", "email", "webhook"}
+
+// ReminderSettings configure a user's daily check-in reminder; stored on the user as "reminder"
+type ReminderSettings struct {
+	Enabled bool `json:"enabled" bson:"enabled"`
+	// Time is the local time of day to remind at, "15:04"
+	Time string `json:"time" bson:"time"`
+	// Timezone is an IANA name such as "Asia/Jakarta"
+	Timezone string `json:"timezone" bson:"timezone"`
+	// Workdays are the weekdays to remind on, 0 = Sunday
+	Workdays   []int       `json:"workdays" bson:"workdays"`
+	Channels   []string    `json:"channels" bson:"channels"`
+	WebhookURL string      `json:"webhookUrl,omitempty" bson:"webhookUrl,omitempty"`
+	QuietHours *QuietHours `json:"quietHours,omitempty" bson:"quietHours,omitempty"`
+	// Locale of the reminder text, "id" or "en"
+	Locale string `json:"locale,omitempty" bson:"locale,omitempty"`
+
+	// SnoozedUntil holds reminders back until then
+	SnoozedUntil *time.Time `json:"snoozedUntil,omitempty" bson:"snoozedUntil,omitempty"`
+	// LastSentDay is the local day, "2006-01-02", of the last reminder, so one is sent per day
+	LastSentDay string `json:"-" bson:"lastSentDay,omitempty"`
+}
+
+// QuietHours is a local time range without reminders; it may wrap midnight, e.g. 20:00–07:00
+type QuietHours struct {
+	Start string `json:"start" bson:"start" validate:"required,datetime=15:04"`
+	End   string `json:"end" bson:"end" validate:"required,datetime=15:04"`
+}
+
+// DefaultReminder is used until the user saves their own settings
+func DefaultReminder(timezone string) ReminderSettings {
+	return ReminderSettings{
+		Time:     "16:00",
+		Timezone: timezone,
+		Workdays: []int{1, 2, 3, 4, 5},
+		Channels: []string{"in_app"},
+	}
+}
+
+// ReminderRequest is the body of PUT /me/reminders
+type ReminderRequest struct {
+	Enabled    bool        `json:"enabled"`
+	Time       string      `json:"time" validate:"required,datetime=15:04"`
+	Timezone   string      `json:"timezone" validate:"required,timezone"`
+	Workdays   []int       `json:"workdays" validate:"max=7,dive,gte=0,lte=6"`
+	Channels   []string    `json:"channels" validate:"required_if=Enabled true,max=3,dive,oneof=in_app email webhook"`
+	WebhookURL string      `json:"webhookUrl" validate:"omitempty,http_url,max=512"`
+	QuietHours *QuietHours `json:"quietHours"`
+	Locale     string      `json:"locale" validate:"omitempty,oneof=id en"`
+}
+
+// SnoozeRequest is the body of POST /me/reminders/snooze
+type SnoozeRequest struct {
+	Minutes int `json:"minutes" validate:"required,gte=5,lte=1440"`
+}
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Team holds per-team settings. Users and check-ins reference teams by name.
+type Team struct {
+	ID   primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Name string             `json:"name" bson:"name"`
+
+	// AnonymityK overrides the minimum group size of the team's aggregates
+	AnonymityK int `json:"anonymityK,omitempty" bson:"anonymityK,omitempty"`
+	// Noise overrides whether the team's aggregate counts are perturbed
+	Noise *bool `json:"noise,omitempty" bson:"noise,omitempty"`
+
+	UpdatedAt time.Time `json:"updatedAt,omitempty" bson:"updatedAt,omitempty"`
+	UpdatedBy string    `json:"updatedBy,omitempty" bson:"updatedBy,omitempty"`
+}
+
+// TeamPrivacyRequest is the body of PUT /teams/:name/privacy
+type TeamPrivacyRequest struct {
+	K     int  `json:"k" validate:"required,gte=2,lte=100"`
+	Noise bool `json:"noise"`
+}
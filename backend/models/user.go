@@ -10,10 +10,35 @@ type User struct {
 	Email        string    `json:"email" bson:"email"`
 	Password     string    `json:"password" bson:"password"`
 	Role         string    `json:"role,omitempty" bson:"role,omitempty"`
+	Team         string    `json:"team,omitempty" bson:"team,omitempty"`
 	Status       string    `json:"status,omitempty" bson:"status,omitempty"`
 	LastActive   time.Time `json:"lastActive,omitempty" bson:"lastActive,omitempty"`
 	Bio          string    `json:"bio,omitempty" bson:"bio,omitempty"`
 	ProfileImage string    `json:"profileImage,omitempty" bson:"profileImage,omitempty"`
+
+	// ProfileImages maps thumbnail sizes in pixels ("64", "128", ...) to their URLs
+	ProfileImages map[string]string `json:"profileImages,omitempty" bson:"profileImages,omitempty"`
+
+	StatusMessage *StatusMessage `json:"statusMessage,omitempty" bson:"statusMessage,omitempty"`
+
+	Reminder *ReminderSettings `json:"-" bson:"reminder,omitempty"`
+
+	// AnonymousMode is the user's choice to check in anonymously. Their anonymous check-ins
+	// can't be traced back to them, so they are never alerted about as missing, and their
+	// reminders don't claim they haven't checked in.
+	AnonymousMode bool `json:"-" bson:"anonymousMode,omitempty"`
+
+	// Admin users assign roles and teams; granted with cmd/grant-admin, never through the API
+	Admin bool `json:"-" bson:"admin,omitempty"`
+
+	// PasswordHistory holds the most recent password hashes, newest last, to prevent reuse
+	PasswordHistory []string `json:"-" bson:"passwordHistory,omitempty"`
+
+	// DeletedAt is set when the user deletes their account; logging in before PurgeAt restores it
+	DeletedAt *time.Time `json:"-" bson:"deletedAt,omitempty"`
+	PurgeAt   *time.Time `json:"-" bson:"purgeAt,omitempty"`
+	// Purging marks an account the purge job has claimed; it can no longer be restored
+	Purging bool `json:"-" bson:"purging,omitempty"`
 }
 
 // UserResponse is a model without password for returning to clients
@@ -22,8 +47,90 @@ type UserResponse struct {
 	Nama         string    `json:"nama"`
 	Email        string    `json:"email"`
 	Role         string    `json:"role,omitempty"`
+	Team         string    `json:"team,omitempty"`
 	Status       string    `json:"status,omitempty"`
 	LastActive   time.Time `json:"lastActive,omitempty"`
 	Bio          string    `json:"bio,omitempty"`
 	ProfileImage string    `json:"profileImage,omitempty"`
+
+	ProfileImages map[string]string `json:"profileImages,omitempty"`
+	StatusMessage *StatusMessage    `json:"statusMessage,omitempty"`
+}
+
+// UserListQuery holds the query parameters of GET /users and GET /team-members
+type UserListQuery struct {
+	Limit  int    `query:"limit" json:"limit" validate:"omitempty,min=1,max=100"`
+	Cursor string `query:"cursor" json:"cursor"`
+	Q      string `query:"q" json:"q" validate:"max=100"`
+	Role   string `query:"role" json:"role" validate:"omitempty,role"`
+	Team   string `query:"team" json:"team" validate:"max=100"`
+	Status string `query:"status" json:"status" validate:"omitempty,oneof=online away offline in-meeting"`
+	Sort   string `query:"sort" json:"sort" validate:"omitempty,oneof=nama -nama email -email role -role team -team lastActive -lastActive"`
+}
+
+// StatusMessage is a short note a user sets next to their presence, e.g. "Focus time"
+type StatusMessage struct {
+	Text      string     `json:"text,omitempty" bson:"text,omitempty"`
+	Emoji     string     `json:"emoji,omitempty" bson:"emoji,omitempty"`
+	InMeeting bool       `json:"inMeeting,omitempty" bson:"inMeeting,omitempty"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty" bson:"expiresAt,omitempty"`
+}
+
+// Active reports whether the message is set and not yet expired at now
+func (m *StatusMessage) Active(now time.Time) bool {
+	return m != nil && (m.ExpiresAt == nil || now.Before(*m.ExpiresAt))
+}
+
+// StatusMessageRequest is the body of PUT /me/status; without expiresAt the message stays until cleared
+type StatusMessageRequest struct {
+	Text      string     `json:"text" validate:"required_without=InMeeting,max=100"`
+	Emoji     string     `json:"emoji" validate:"max=16"`
+	InMeeting bool       `json:"inMeeting"`
+	ExpiresAt *time.Time `json:"expiresAt" validate:"omitempty,future"`
+}
+
+// PrivacyPreferences is the body and response of /me/privacy
+type PrivacyPreferences struct {
+	AnonymousMode bool `json:"anonymousMode"`
+}
+
+// DeleteAccountRequest is the body of DELETE /me; the password confirms it's really the user
+type DeleteAccountRequest struct {
+	Password string `json:"password" validate:"required,max=256"`
+}
+
+// Roles lists the job roles an admin can assign
+var Roles = []string{
+	"Team Member",
+	"Team Leader",
+	"Developer",
+	"Designer",
+	"Product Manager",
+	"QA Engineer",
+}
+
+// RegisterRequest is the body of POST /auth/register
+type RegisterRequest struct {
+	Nama     string `json:"nama" validate:"required,min=2,max=100"`
+	Email    string `json:"email" validate:"required,email,max=254"`
+	Password string `json:"password" validate:"required,max=256"`
+	Bio      string `json:"bio" validate:"max=500"`
+}
+
+// LoginRequest is the body of POST /auth/login
+type LoginRequest struct {
+	Email    string `json:"email" validate:"required,email"`
+	Password string `json:"password" validate:"required,max=256"`
+}
+
+// UpdateUserRequest is the body of PUT /users/:id; empty fields are left unchanged.
+// Role and Team decide who leads which team, so only admins may change them.
+type UpdateUserRequest struct {
+	Nama            string `json:"nama" validate:"omitempty,min=2,max=100"`
+	Email           string `json:"email" validate:"omitempty,email,max=254"`
+	Role            string `json:"role" validate:"omitempty,role"`
+	Team            string `json:"team" validate:"max=100"`
+	Bio             string `json:"bio" validate:"max=500"`
+	CurrentPassword string `json:"currentPassword" validate:"required_with=NewPassword,max=256"`
+	NewPassword     string `json:"newPassword" validate:"required_with=CurrentPassword,max=256"`
 }
@@ -0,0 +1,172 @@
+package moods
+
+import (
+	"cmp"
+	"context"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"backend/config"
+	"backend/env"
+	"backend/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Collection holds the organization's mood definitions, seeded by a migration
+const Collection = "moods"
+
+// cacheTTL bounds how long edits to the collection take to show up
+const cacheTTL = time.Minute
+
+var (
+	mu       sync.RWMutex
+	cached   []models.MoodDefinition
+	loadedAt time.Time
+)
+
+// All returns every definition, active or not, in display order. Definitions are cached;
+// until the collection can be read the built-in defaults are used.
+func All() []models.MoodDefinition {
+	mu.RLock()
+	defs, fresh := cached, time.Since(loadedAt) < cacheTTL
+	mu.RUnlock()
+	if fresh {
+		return defs
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	loaded, err := load(ctx)
+	if err != nil || len(loaded) == 0 {
+		if err != nil {
+			slog.Warn("Could not load mood definitions, using previous ones", "error", err)
+		}
+		if defs == nil {
+			defs = sorted(models.DefaultMoods)
+		}
+		// Retry after the TTL rather than on every request
+		loaded = defs
+	}
+
+	mu.Lock()
+	cached, loadedAt = loaded, time.Now()
+	mu.Unlock()
+	return loaded
+}
+
+// Active returns the definitions users can choose, in display order
+func Active() []models.MoodDefinition {
+	var active []models.MoodDefinition
+	for _, d := range All() {
+		if !d.Inactive {
+			active = append(active, d)
+		}
+	}
+	return active
+}
+
+// Get returns the definition of key, including inactive ones
+func Get(key string) (models.MoodDefinition, bool) {
+	for _, d := range All() {
+		if d.Key == key {
+			return d, true
+		}
+	}
+	return models.MoodDefinition{}, false
+}
+
+// IsSelectable reports whether key names an active mood, i.e. one a check-in may use
+func IsSelectable(key string) bool {
+	d, ok := Get(key)
+	return ok && !d.Inactive
+}
+
+// Save creates or replaces the definition of def.Key and drops the cache, reporting
+// whether it was created. Other replicas pick the change up within cacheTTL.
+func Save(ctx context.Context, def models.MoodDefinition) (bool, error) {
+	res, err := config.DB.Collection(Collection).ReplaceOne(ctx, bson.M{"key": def.Key}, def,
+		options.Replace().SetUpsert(true),
+	)
+	if err != nil {
+		return false, err
+	}
+	Invalidate()
+	return res.UpsertedCount > 0, nil
+}
+
+// Invalidate drops the cache so the next call reads the collection
+func Invalidate() {
+	mu.Lock()
+	loadedAt = time.Time{}
+	mu.Unlock()
+}
+
+// FromExpressions maps face-api expression probabilities to an active mood: the most
+// likely expression at or above minConfidence that some mood lists wins
+func FromExpressions(scores map[string]float64, minConfidence float64) (mood models.MoodDefinition, expression string, confidence float64, ok bool) {
+	byExpression := map[string]models.MoodDefinition{}
+	for _, d := range Active() {
+		for _, e := range d.Expressions {
+			if _, taken := byExpression[e]; !taken {
+				byExpression[e] = d
+			}
+		}
+	}
+
+	for e, p := range scores {
+		d, mapped := byExpression[e]
+		if !mapped || p < minConfidence {
+			continue
+		}
+		// Ties go to the alphabetically first expression so results are stable
+		if !ok || p > confidence || (p == confidence && e < expression) {
+			mood, expression, confidence, ok = d, e, p, true
+		}
+	}
+	return mood, expression, confidence, ok
+}
+
+func load(ctx context.Context) ([]models.MoodDefinition, error) {
+	if config.DB == nil {
+		return nil, nil
+	}
+	cursor, err := config.DB.Collection(Collection).Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	var defs []models.MoodDefinition
+	if err := cursor.All(ctx, &defs); err != nil {
+		return nil, err
+	}
+	return sorted(defs), nil
+}
+
+func sorted(defs []models.MoodDefinition) []models.MoodDefinition {
+	out := slices.Clone(defs)
+	slices.SortStableFunc(out, func(a, b models.MoodDefinition) int {
+		return cmp.Or(cmp.Compare(a.Order, b.Order), cmp.Compare(a.Key, b.Key))
+	})
+	return out
+}
+
+var (
+	confidenceOnce sync.Once
+	minConfidence  = 0.5
+)
+
+// MinExpressionConfidence is the probability a face-api expression needs before it is
+// mapped to a mood, from MOOD_EXPRESSION_MIN_CONFIDENCE
+func MinExpressionConfidence() float64 {
+	confidenceOnce.Do(func() {
+		if f := env.Float("MOOD_EXPRESSION_MIN_CONFIDENCE", minConfidence); f >= 0 && f <= 1 {
+			minConfidence = f
+		} else {
+			slog.Warn("MOOD_EXPRESSION_MIN_CONFIDENCE is not a probability, using default", "value", f)
+		}
+	})
+	return minConfidence
+}
@@ -0,0 +1,152 @@
+// Package notifications stores in-app notifications and pushes new ones to the
+// recipient's open notification feeds.
+package notifications
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"backend/config"
+	"backend/lifecycle"
+	"backend/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Collection holds in-app notifications
+const Collection = "notifications"
+
+// Notification types
+const (
+	TypeMeetingInvite  = "meeting_invite"
+	TypeMention        = "mention"
+	TypeReminder       = "checkin_reminder"
+	TypeWellbeingAlert = "wellbeing_alert"
+)
+
+// Event is sent to a user's feed subscribers: "notification" carries a new notification,
+// "read" the ids marked read (none when all were). Unread is the count afterwards.
+type Event struct {
+	Type         string               `json:"type"`
+	Notification *models.Notification `json:"notification,omitempty"`
+	IDs          []primitive.ObjectID `json:"ids,omitempty"`
+	Unread       int64                `json:"unread"`
+}
+
+var (
+	mu      sync.Mutex
+	subs    = map[string]map[chan Event]struct{}{}
+	started sync.Once
+)
+
+// Start registers the shutdown hook that closes open feeds. Call once at startup.
+func Start() {
+	started.Do(func() {
+		lifecycle.OnDrain("notification-feed", func(ctx context.Context) error {
+			closeSubscribers()
+			return nil
+		})
+	})
+}
+
+// Subscribe returns a channel of the user's notification events and a function to stop
+// receiving them. Events are delivered only by the replica that produced them; clients
+// reload the list when they reconnect. Slow subscribers miss events rather than block.
+func Subscribe(userID string) (<-chan Event, func()) {
+	ch := make(chan Event, 16)
+
+	mu.Lock()
+	if subs[userID] == nil {
+		subs[userID] = map[chan Event]struct{}{}
+	}
+	subs[userID][ch] = struct{}{}
+	mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			mu.Lock()
+			defer mu.Unlock()
+			if _, ok := subs[userID][ch]; ok {
+				delete(subs[userID], ch)
+				if len(subs[userID]) == 0 {
+					delete(subs, userID)
+				}
+				close(ch)
+			}
+		})
+	}
+}
+
+func publish(userID string, ev Event) {
+	mu.Lock()
+	defer mu.Unlock()
+	for ch := range subs[userID] {
+		select {
+		case ch <- ev:
+		default:
+		}
+	}
+}
+
+func closeSubscribers() {
+	mu.Lock()
+	defer mu.Unlock()
+	for userID, chans := range subs {
+		for ch := range chans {
+			close(ch)
+		}
+		delete(subs, userID)
+	}
+}
+
+// Create stores n for n.UserID and pushes it to the user's open feeds
+func Create(ctx context.Context, n models.Notification) (models.Notification, error) {
+	n.ID = primitive.NewObjectID()
+	n.Read = false
+	if n.CreatedAt.IsZero() {
+		n.CreatedAt = time.Now()
+	}
+	if _, err := config.DB.Collection(Collection).InsertOne(ctx, n); err != nil {
+		return n, err
+	}
+
+	// The count is for the badge; a failure here must not fail the notification
+	unread, _ := UnreadCount(ctx, n.UserID)
+	publish(n.UserID, Event{Type: "notification", Notification: &n, Unread: unread})
+	return n, nil
+}
+
+// UnreadCount returns how many unread notifications the user has
+func UnreadCount(ctx context.Context, userID string) (int64, error) {
+	return config.DB.Collection(Collection).CountDocuments(ctx, bson.M{"userId": userID, "read": false})
+}
+
+// MarkRead marks the given notifications of the user read; ids of other users are ignored.
+// Without ids every notification of the user is marked read. Returns how many changed.
+func MarkRead(ctx context.Context, userID string, ids ...primitive.ObjectID) (int64, error) {
+	filter := bson.M{"userId": userID, "read": false}
+	if len(ids) > 0 {
+		filter["_id"] = bson.M{"$in": ids}
+	}
+	res, err := config.DB.Collection(Collection).UpdateMany(ctx, filter,
+		bson.M{"$set": bson.M{"read": true, "readAt": time.Now()}},
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	if res.ModifiedCount > 0 {
+		unread, _ := UnreadCount(ctx, userID)
+		publish(userID, Event{Type: "read", IDs: ids, Unread: unread})
+	}
+	return res.ModifiedCount, nil
+}
+
+// DeleteAll removes every notification of the user, e.g. when the account is purged
+func DeleteAll(ctx context.Context, userID string) error {
+	_, err := config.DB.Collection(Collection).DeleteMany(ctx, bson.M{"userId": userID})
+	return err
+}
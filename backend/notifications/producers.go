@@ -0,0 +1,58 @@
+package notifications
+
+import (
+	"context"
+
+	"backend/models"
+)
+
+// MeetingInvite notifies each invitee that from invited them to a meeting
+func MeetingInvite(ctx context.Context, from models.User, to []string, meetingID, link string) error {
+	return each(ctx, to, models.Notification{
+		Type:  TypeMeetingInvite,
+		Title: from.Nama + " invited you to a meeting",
+		Link:  link,
+		Payload: map[string]interface{}{
+			"meetingId": meetingID,
+			"fromId":    from.ID,
+			"fromName":  from.Nama,
+		},
+	})
+}
+
+// Mention notifies each mentioned user that from mentioned them in a meeting's chat
+func Mention(ctx context.Context, from models.User, to []string, meetingID, link, excerpt string) error {
+	return each(ctx, to, models.Notification{
+		Type:  TypeMention,
+		Title: from.Nama + " mentioned you",
+		Body:  excerpt,
+		Link:  link,
+		Payload: map[string]interface{}{
+			"meetingId": meetingID,
+			"fromId":    from.ID,
+			"fromName":  from.Nama,
+		},
+	})
+}
+
+// WellbeingAlert notifies each recipient, e.g. a team's leaders, about a wellbeing risk
+func WellbeingAlert(ctx context.Context, to []string, title, body, link string, payload map[string]interface{}) error {
+	return each(ctx, to, models.Notification{
+		Type:    TypeWellbeingAlert,
+		Title:   title,
+		Body:    body,
+		Link:    link,
+		Payload: payload,
+	})
+}
+
+// each creates a copy of n for every user in to
+func each(ctx context.Context, to []string, n models.Notification) error {
+	for _, userID := range to {
+		n.UserID = userID
+		if _, err := Create(ctx, n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"os"
+	"sync"
+	"time"
+)
+
+// SMTPSettings are read from SMTP_* environment variables on first use
+type SMTPSettings struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+var (
+	smtpOnce     sync.Once
+	smtpSettings SMTPSettings
+)
+
+// SMTP returns the mail server settings; without SMTP_HOST email is not sent
+func SMTP() SMTPSettings {
+	smtpOnce.Do(func() {
+		smtpSettings = SMTPSettings{
+			Host:     os.Getenv("SMTP_HOST"),
+			Port:     os.Getenv("SMTP_PORT"),
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+			From:     os.Getenv("SMTP_FROM"),
+		}
+		if smtpSettings.Port == "" {
+			smtpSettings.Port = "587"
+		}
+		if smtpSettings.From == "" {
+			smtpSettings.From = smtpSettings.Username
+		}
+	})
+	return smtpSettings
+}
+
+// email sends a plain-text mail; smtp.SendMail upgrades to TLS when the server offers STARTTLS
+type email struct{}
+
+func (email) Name() string { return "email" }
+
+func (email) Send(ctx context.Context, to Recipient, msg Message) error {
+	settings := SMTP()
+	if settings.Host == "" || settings.From == "" || to.Email == "" {
+		return ErrNotConfigured
+	}
+
+	var auth smtp.Auth
+	if settings.Username != "" {
+		auth = smtp.PlainAuth("", settings.Username, settings.Password, settings.Host)
+	}
+
+	body := msg.Body
+	if msg.Link != "" {
+		body += "\n\n" + msg.Link
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", settings.From)
+	fmt.Fprintf(&buf, "To: %s\r\n", to.Email)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Title))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	buf.WriteString(body)
+	buf.WriteString("\r\n")
+
+	// net/smtp has no context support; run it aside so a hung server can't outlive ctx
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(net.JoinHostPort(settings.Host, settings.Port), auth, settings.From, []string{to.Email}, buf.Bytes())
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
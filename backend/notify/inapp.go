@@ -0,0 +1,25 @@
+package notify
+
+import (
+	"context"
+
+	"backend/models"
+	"backend/notifications"
+)
+
+// inApp stores the message as a notification the user sees in the app
+type inApp struct{}
+
+func (inApp) Name() string { return "in_app" }
+
+func (inApp) Send(ctx context.Context, to Recipient, msg Message) error {
+	_, err := notifications.Create(ctx, models.Notification{
+		UserID:  to.UserID,
+		Type:    msg.Type,
+		Title:   msg.Title,
+		Body:    msg.Body,
+		Link:    msg.Link,
+		Payload: msg.Payload,
+	})
+	return err
+}
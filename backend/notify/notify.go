@@ -0,0 +1,63 @@
+// Package notify delivers messages to users through pluggable channels: in-app
+// notifications, email over SMTP and outgoing webhooks.
+package notify
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrNotConfigured is returned by channels that lack the settings or recipient data to send
+var ErrNotConfigured = errors.New("notify: channel not configured")
+
+// Message is what a channel delivers
+type Message struct {
+	// Type identifies the kind of message, e.g. "checkin_reminder"
+	Type  string
+	Title string
+	Body  string
+	// Link is a path in the app the message refers to, e.g. "/dashboard"
+	Link    string
+	Payload map[string]interface{}
+}
+
+// Recipient is who a message is for, with the addresses the channels need
+type Recipient struct {
+	UserID     string
+	Name       string
+	Email      string
+	WebhookURL string
+}
+
+// Channel delivers messages one way, e.g. by email
+type Channel interface {
+	Name() string
+	Send(ctx context.Context, to Recipient, msg Message) error
+}
+
+var (
+	mu       sync.RWMutex
+	channels = map[string]Channel{}
+)
+
+func init() {
+	Register(inApp{})
+	Register(email{})
+	Register(webhook{})
+}
+
+// Register adds a channel, replacing any with the same name
+func Register(ch Channel) {
+	mu.Lock()
+	defer mu.Unlock()
+	channels[ch.Name()] = ch
+}
+
+// Get returns the channel named name
+func Get(name string) (Channel, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	ch, ok := channels[name]
+	return ch, ok
+}
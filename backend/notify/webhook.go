@@ -0,0 +1,173 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// ErrWebhookForbidden is returned for webhook URLs the server refuses to call: hosts off
+// NOTIFY_WEBHOOK_ALLOWED_HOSTS, or addresses inside the server's own network
+var ErrWebhookForbidden = errors.New("notify: webhook address not allowed")
+
+// webhookClient checks every address it connects to, after DNS resolution and on every
+// redirect, so a host can't resolve to an internal address once the URL was accepted.
+// Proxies are not used, since they would connect on the client's behalf.
+var webhookClient = &http.Client{
+	Timeout: 10 * time.Second,
+	Transport: &http.Transport{
+		Proxy: nil,
+		DialContext: (&net.Dialer{
+			Timeout: 5 * time.Second,
+			Control: func(network, address string, _ syscall.RawConn) error {
+				addrPort, err := netip.ParseAddrPort(address)
+				if err != nil {
+					return err
+				}
+				if !publicAddr(addrPort.Addr()) {
+					return ErrWebhookForbidden
+				}
+				return nil
+			},
+		}).DialContext,
+		TLSHandshakeTimeout: 5 * time.Second,
+	},
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		if len(via) >= 3 {
+			return http.ErrUseLastResponse
+		}
+		if !allowedHost(req.URL.Hostname()) {
+			return ErrWebhookForbidden
+		}
+		return nil
+	},
+}
+
+var (
+	allowedHostsOnce sync.Once
+	allowedHosts     []string
+)
+
+// allowedHost reports whether host may receive webhooks. With NOTIFY_WEBHOOK_ALLOWED_HOSTS
+// set (comma-separated, e.g. "hooks.slack.com,chat.example.com") only those hosts and
+// their subdomains may; otherwise any public host may.
+func allowedHost(host string) bool {
+	allowedHostsOnce.Do(func() {
+		for _, h := range strings.Split(os.Getenv("NOTIFY_WEBHOOK_ALLOWED_HOSTS"), ",") {
+			if h = strings.ToLower(strings.TrimSpace(h)); h != "" {
+				allowedHosts = append(allowedHosts, h)
+			}
+		}
+	})
+	if len(allowedHosts) == 0 {
+		return true
+	}
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	for _, h := range allowedHosts {
+		if host == h || strings.HasSuffix(host, "."+h) {
+			return true
+		}
+	}
+	return false
+}
+
+// sharedAddressSpace is the carrier-grade NAT range (RFC 6598), private in all but name
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// publicAddr reports whether addr is a public unicast address: not loopback, private,
+// link-local (which includes cloud metadata endpoints), unspecified or multicast
+func publicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsGlobalUnicast() &&
+		!addr.IsPrivate() &&
+		!addr.IsLoopback() &&
+		!addr.IsLinkLocalUnicast() &&
+		!sharedAddressSpace.Contains(addr)
+}
+
+// CheckWebhookURL rejects webhook URLs that aren't http(s), whose host isn't allowed, or
+// whose host resolves to an address that isn't public. Sending checks the addresses again.
+func CheckWebhookURL(ctx context.Context, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return ErrWebhookForbidden
+	}
+	if !allowedHost(u.Hostname()) {
+		return ErrWebhookForbidden
+	}
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", u.Hostname())
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrWebhookForbidden, err)
+	}
+	for _, addr := range addrs {
+		if !publicAddr(addr) {
+			return ErrWebhookForbidden
+		}
+	}
+	return nil
+}
+
+// webhook posts the message as JSON to the recipient's URL, e.g. a chat incoming webhook.
+// With NOTIFY_WEBHOOK_SECRET set the body is signed in X-Signature-256 as
+// "sha256=<hex HMAC>", so receivers can check it came from us.
+type webhook struct{}
+
+func (webhook) Name() string { return "webhook" }
+
+func (webhook) Send(ctx context.Context, to Recipient, msg Message) error {
+	if to.WebhookURL == "" {
+		return ErrNotConfigured
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"type":    msg.Type,
+		"title":   msg.Title,
+		"text":    msg.Body,
+		"link":    msg.Link,
+		"payload": msg.Payload,
+		"userId":  to.UserID,
+		"sentAt":  time.Now().UTC(),
+	})
+	if err != nil {
+		return err
+	}
+
+	// Settings saved before addresses were checked may still point inside the network
+	if u, err := url.Parse(to.WebhookURL); err != nil || !allowedHost(u.Hostname()) {
+		return ErrWebhookForbidden
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, to.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if secret := os.Getenv("NOTIFY_WEBHOOK_SECRET"); secret != "" {
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write(body)
+		req.Header.Set("X-Signature-256", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := webhookClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook answered %s", resp.Status)
+	}
+	return nil
+}
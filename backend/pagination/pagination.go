@@ -0,0 +1,146 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"strings"
+
+	"backend/apperror"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Info describes where a page sits in the full result set
+type Info struct {
+	Limit      int    `json:"limit"`
+	Total      int64  `json:"total"`
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+// Page is the response envelope of every cursor-paginated listing
+type Page[T any] struct {
+	Data []T  `json:"data"`
+	Page Info `json:"page"`
+}
+
+// Sort is a keyset ordering on one field, with _id breaking ties
+type Sort struct {
+	Field string
+	Desc  bool
+}
+
+// ParseSort turns "field" or "-field" into a Sort
+func ParseSort(s string) Sort {
+	if strings.HasPrefix(s, "-") {
+		return Sort{Field: s[1:], Desc: true}
+	}
+	return Sort{Field: s}
+}
+
+// Order returns the MongoDB sort document
+func (s Sort) Order() bson.D {
+	dir := 1
+	if s.Desc {
+		dir = -1
+	}
+	return bson.D{{Key: s.Field, Value: dir}, {Key: "_id", Value: dir}}
+}
+
+// cursor is the position after the last returned document. It is BSON-encoded so the
+// values keep their types (ObjectID vs string IDs, dates) across requests.
+type cursor struct {
+	Value interface{} `bson:"v"`
+	ID    interface{} `bson:"id"`
+}
+
+// Encode returns the opaque cursor pointing after a document with the given sort value and _id
+func Encode(value, id interface{}) string {
+	raw, err := bson.Marshal(cursor{Value: value, ID: id})
+	if err != nil {
+		return ""
+	}
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// CursorAfter returns the cursor pointing after doc in a listing sorted by field
+func CursorAfter(doc bson.Raw, field string) string {
+	return Encode(lookup(doc, field), lookup(doc, "_id"))
+}
+
+func lookup(doc bson.Raw, key string) interface{} {
+	rv, err := doc.LookupErr(key)
+	if err != nil || rv.Type == bson.TypeNull {
+		return nil
+	}
+	var v interface{}
+	if err := rv.Unmarshal(&v); err != nil {
+		return nil
+	}
+	return v
+}
+
+// After returns the filter selecting documents that come after the encoded cursor in sort
+// order, or nil for an empty cursor. Invalid cursors yield INVALID_CURSOR.
+func (s Sort) After(encoded string) (bson.M, error) {
+	if encoded == "" {
+		return nil, nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, apperror.InvalidCursor.Wrap(err)
+	}
+	var c cursor
+	if err := bson.Unmarshal(raw, &c); err != nil || c.ID == nil {
+		return nil, apperror.InvalidCursor.Wrap(err)
+	}
+
+	// MongoDB only compares values of the same type, and missing fields sort as null:
+	// first when ascending, last when descending
+	if c.Value == nil {
+		tie := and(bson.M{s.Field: nil}, s.idAfter(c.ID))
+		if s.Desc {
+			return tie, nil
+		}
+		return bson.M{"$or": bson.A{tie, bson.M{s.Field: bson.M{"$ne": nil}}}}, nil
+	}
+
+	op := "$gt"
+	if s.Desc {
+		op = "$lt"
+	}
+	or := bson.A{
+		bson.M{s.Field: bson.M{op: c.Value}},
+		and(bson.M{s.Field: c.Value}, s.idAfter(c.ID)),
+	}
+	if s.Desc {
+		or = append(or, bson.M{s.Field: nil})
+	}
+	return bson.M{"$or": or}, nil
+}
+
+// idAfter selects _id values after id. Users may have string or ObjectID _ids; BSON
+// orders strings before ObjectIDs, so the other type is included or excluded as a whole.
+func (s Sort) idAfter(id interface{}) bson.M {
+	op := "$gt"
+	if s.Desc {
+		op = "$lt"
+	}
+	after := bson.M{"_id": bson.M{op: id}}
+
+	switch id.(type) {
+	case string:
+		if !s.Desc {
+			return bson.M{"$or": bson.A{after, bson.M{"_id": bson.M{"$type": "objectId"}}}}
+		}
+	case primitive.ObjectID:
+		if s.Desc {
+			return bson.M{"$or": bson.A{after, bson.M{"_id": bson.M{"$type": "string"}}}}
+		}
+	}
+	return after
+}
+
+func and(a, b bson.M) bson.M {
+	return bson.M{"$and": bson.A{a, b}}
+}
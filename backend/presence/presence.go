@@ -0,0 +1,325 @@
+package presence
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"backend/config"
+	"backend/env"
+	"backend/lifecycle"
+	"backend/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Presence statuses
+const (
+	Online    = "online"
+	Away      = "away"
+	Offline   = "offline"
+	InMeeting = "in-meeting"
+)
+
+// State is a user's computed presence
+type State struct {
+	UserID        string                `json:"userId"`
+	Status        string                `json:"status"`
+	LastActive    time.Time             `json:"lastActive,omitempty"`
+	StatusMessage *models.StatusMessage `json:"statusMessage,omitempty"`
+}
+
+// Event is sent to feed subscribers whenever a user's presence changes
+type Event struct {
+	Type string `json:"type"`
+	State
+}
+
+// entry is what we know about a user who was active since the process started
+type entry struct {
+	lastSeen    time.Time
+	flushed     time.Time // lastSeen value last written to the database
+	message     *models.StatusMessage
+	knowMessage bool   // message reflects the stored value (set through SetMessage)
+	status      string // status last announced to subscribers
+}
+
+var (
+	mu      sync.Mutex
+	users   = map[string]*entry{}
+	subs    = map[chan Event]struct{}{}
+	started sync.Once
+	stop    = make(chan struct{})
+
+	// Overridden from PRESENCE_* environment variables in Start
+	onlineWindow  = 2 * time.Minute
+	awayWindow    = 15 * time.Minute
+	flushInterval = time.Minute
+)
+
+// sweepInterval is how often time-based transitions (online → away → offline) are detected
+const sweepInterval = 15 * time.Second
+
+// Compute derives a status from the last activity and the status message at now.
+// An active in-meeting message wins, since people in meetings are often away from the app.
+func Compute(lastActive time.Time, msg *models.StatusMessage, now time.Time) string {
+	switch {
+	case msg.Active(now) && msg.InMeeting:
+		return InMeeting
+	case lastActive.IsZero() || now.Sub(lastActive) > awayWindow:
+		return Offline
+	case now.Sub(lastActive) <= onlineWindow:
+		return Online
+	default:
+		return Away
+	}
+}
+
+// Filter returns the query matching users whose stored presence is status at now.
+// Activity not yet flushed is not visible to it, so results can lag by PRESENCE_FLUSH_INTERVAL.
+func Filter(status string, now time.Time) bson.M {
+	meeting := bson.M{
+		"statusMessage.inMeeting": true,
+		"$or": bson.A{
+			bson.M{"statusMessage.expiresAt": nil},
+			bson.M{"statusMessage.expiresAt": bson.M{"$gt": now}},
+		},
+	}
+	notMeeting := bson.M{"$nor": bson.A{meeting}}
+	onlineSince, awaySince := now.Add(-onlineWindow), now.Add(-awayWindow)
+
+	switch status {
+	case InMeeting:
+		return meeting
+	case Online:
+		return bson.M{"$and": bson.A{notMeeting, bson.M{"lastActive": bson.M{"$gte": onlineSince}}}}
+	case Away:
+		return bson.M{"$and": bson.A{notMeeting, bson.M{"lastActive": bson.M{"$lt": onlineSince, "$gte": awaySince}}}}
+	case Offline:
+		return bson.M{"$and": bson.A{notMeeting, bson.M{"$or": bson.A{
+			bson.M{"lastActive": bson.M{"$lt": awaySince}},
+			bson.M{"lastActive": nil},
+		}}}}
+	}
+	return bson.M{}
+}
+
+// Of returns the presence of a stored user, preferring activity not yet flushed to the database
+func Of(user models.User) State {
+	now := time.Now()
+	lastActive, msg := user.LastActive, user.StatusMessage
+
+	mu.Lock()
+	if e, ok := users[user.ID]; ok {
+		if e.lastSeen.After(lastActive) {
+			lastActive = e.lastSeen
+		}
+		if e.knowMessage {
+			msg = e.message
+		}
+	}
+	mu.Unlock()
+
+	if !msg.Active(now) {
+		msg = nil
+	}
+	return State{
+		UserID:        user.ID,
+		Status:        Compute(lastActive, msg, now),
+		LastActive:    lastActive,
+		StatusMessage: msg,
+	}
+}
+
+// Touch records activity by userID. The database is updated later in batches.
+func Touch(userID string) {
+	if userID == "" {
+		return
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	e := lookup(userID)
+	e.lastSeen = time.Now()
+	announce(userID, e, e.lastSeen)
+}
+
+// SetMessage records a user's new status message (nil when cleared) after it has been stored
+func SetMessage(userID string, msg *models.StatusMessage) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	e := lookup(userID)
+	e.message, e.knowMessage = msg, true
+	e.status = "" // always announce, the message itself changed
+	announce(userID, e, time.Now())
+}
+
+// Subscribe returns a channel of presence events and a function to stop receiving them.
+// The channel is closed on unsubscribe and when the server starts shutting down.
+// Slow subscribers miss events rather than block activity tracking.
+func Subscribe() (<-chan Event, func()) {
+	ch := make(chan Event, 64)
+
+	mu.Lock()
+	subs[ch] = struct{}{}
+	mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			mu.Lock()
+			defer mu.Unlock()
+			if _, ok := subs[ch]; ok {
+				delete(subs, ch)
+				close(ch)
+			}
+		})
+	}
+}
+
+// Start runs the background loop that announces time-based transitions and flushes
+// lastActive to the database, and registers its shutdown hooks. Call once after config.ConnectDB.
+func Start() {
+	started.Do(func() {
+		onlineWindow = env.Duration("PRESENCE_ONLINE_WINDOW", onlineWindow)
+		awayWindow = env.Duration("PRESENCE_AWAY_WINDOW", awayWindow)
+		flushInterval = env.Duration("PRESENCE_FLUSH_INTERVAL", flushInterval)
+
+		lifecycle.OnDrain("presence-feed", func(ctx context.Context) error {
+			closeSubscribers()
+			return nil
+		})
+		lifecycle.OnStop("presence", func(ctx context.Context) error {
+			close(stop)
+			return flush(ctx)
+		})
+		go run()
+	})
+}
+
+func run() {
+	ticker := time.NewTicker(sweepInterval)
+	defer ticker.Stop()
+
+	lastFlush := time.Now()
+	for {
+		select {
+		case <-stop:
+			return
+		case now := <-ticker.C:
+			sweep(now)
+			if now.Sub(lastFlush) >= flushInterval {
+				ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+				if err := flush(ctx); err != nil {
+					slog.Warn("Presence flush failed, will retry", "error", err)
+				}
+				cancel()
+				lastFlush = now
+			}
+		}
+	}
+}
+
+// sweep announces status changes caused by time passing and forgets users who went
+// offline once their activity is stored
+func sweep(now time.Time) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	for id, e := range users {
+		announce(id, e, now)
+		if e.status == Offline && !e.flushed.Before(e.lastSeen) && !e.message.Active(now) {
+			delete(users, id)
+		}
+	}
+}
+
+// flush writes unsaved activity with one bulk write. $max keeps newer values written by
+// other instances.
+func flush(ctx context.Context) error {
+	mu.Lock()
+	pending := make(map[string]time.Time)
+	for id, e := range users {
+		if e.lastSeen.After(e.flushed) {
+			pending[id] = e.lastSeen
+		}
+	}
+	mu.Unlock()
+
+	if len(pending) == 0 || config.UserCollectionRef == nil {
+		return nil
+	}
+
+	writes := make([]mongo.WriteModel, 0, len(pending))
+	for id, seen := range pending {
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(config.UserIDFilter(id)).
+			SetUpdate(bson.M{"$max": bson.M{"lastActive": seen}}))
+	}
+
+	opts := options.BulkWrite().SetOrdered(false)
+	if _, err := config.UserCollectionRef.BulkWrite(ctx, writes, opts); err != nil {
+		return err
+	}
+
+	mu.Lock()
+	for id, seen := range pending {
+		if e, ok := users[id]; ok && seen.After(e.flushed) {
+			e.flushed = seen
+		}
+	}
+	mu.Unlock()
+
+	slog.Debug("Presence flushed", "users", len(pending))
+	return nil
+}
+
+func closeSubscribers() {
+	mu.Lock()
+	defer mu.Unlock()
+	for ch := range subs {
+		delete(subs, ch)
+		close(ch)
+	}
+}
+
+// lookup returns the entry for userID, creating it; callers hold mu
+func lookup(userID string) *entry {
+	e, ok := users[userID]
+	if !ok {
+		e = &entry{}
+		users[userID] = e
+	}
+	return e
+}
+
+// announce sends the user's state to subscribers if the status changed; callers hold mu
+func announce(userID string, e *entry, now time.Time) {
+	msg := e.message
+	if !msg.Active(now) {
+		msg = nil
+	}
+	status := Compute(e.lastSeen, msg, now)
+	if status == e.status {
+		return
+	}
+	e.status = status
+
+	ev := Event{Type: "presence", State: State{
+		UserID:        userID,
+		Status:        status,
+		LastActive:    e.lastSeen,
+		StatusMessage: msg,
+	}}
+	for ch := range subs {
+		select {
+		case ch <- ev:
+		default:
+		}
+	}
+}
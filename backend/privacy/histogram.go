@@ -0,0 +1,72 @@
+package privacy
+
+import (
+	"math"
+	"slices"
+	"strconv"
+
+	"backend/models"
+)
+
+// Histogram counts how often each value of a rating was given, and by which contributors
+type Histogram struct {
+	Counts map[int]int
+	Users  []string
+}
+
+// Summarize returns count, mean, min, max and percentiles of the histogram. With fewer
+// than policy.K distinct users only the suppressed flag is returned. With noise each
+// bin is perturbed before summarising, so every derived number is protected.
+func Summarize(dimension string, h Histogram, policy Policy) models.DimensionStats {
+	stats := models.DimensionStats{Dimension: dimension}
+	if DistinctUsers(h.Users) < policy.K {
+		stats.Suppressed = len(h.Users) > 0
+		return stats
+	}
+
+	values := make([]int, 0, len(h.Counts))
+	counts := make(map[int]int, len(h.Counts))
+	for v, n := range h.Counts {
+		if policy.Noise {
+			n = noisy(n, policy.Epsilon, policy.Query, dimension, strconv.Itoa(v))
+		}
+		if n > 0 {
+			values = append(values, v)
+			counts[v] = n
+		}
+	}
+	slices.Sort(values)
+
+	total, sum := 0, 0
+	for _, v := range values {
+		total += counts[v]
+		sum += v * counts[v]
+	}
+	stats.Count = total
+	if total == 0 {
+		return stats
+	}
+
+	mean := math.Round(float64(sum)/float64(total)*100) / 100
+	stats.Mean = &mean
+	stats.Min = &values[0]
+	stats.Max = &values[len(values)-1]
+
+	percentile := func(p float64) *int {
+		// Nearest rank: the smallest value with at least p% of ratings at or below it
+		rank := int(math.Ceil(p / 100 * float64(total)))
+		seen := 0
+		for _, v := range values {
+			seen += counts[v]
+			if seen >= rank {
+				return &v
+			}
+		}
+		return stats.Max
+	}
+	stats.P25 = percentile(25)
+	stats.P50 = percentile(50)
+	stats.P75 = percentile(75)
+	stats.P90 = percentile(90)
+	return stats
+}
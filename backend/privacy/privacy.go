@@ -0,0 +1,158 @@
+package privacy
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"sync"
+
+	"backend/env"
+)
+
+// OtherBucket is the key of the bucket that small buckets are merged into
+const OtherBucket = "other"
+
+// Policy controls how aggregates are protected before they leave the API
+type Policy struct {
+	// K is the minimum number of distinct users a bucket must contain to be shown
+	K int `json:"k"`
+	// Noise adds Laplace noise to counts, so repeated queries over slightly different
+	// periods can't be subtracted to single out one check-in
+	Noise bool `json:"noise"`
+	// Epsilon is the privacy budget of the noise; smaller means noisier
+	Epsilon float64 `json:"epsilon,omitempty"`
+	// Query identifies the question being answered, see QueryKey. The noise is derived
+	// from it and the true counts, so repeating a query returns the same answer and
+	// averaging repeated answers doesn't wash the noise out.
+	Query string `json:"-"`
+}
+
+// QueryKey builds Policy.Query from what selects the check-ins of an aggregate, e.g.
+// its endpoint, team, period and filters in a canonical form
+func QueryKey(parts ...string) string {
+	return fmt.Sprintf("%q", parts)
+}
+
+// Settings are the deployment-wide defaults and limits, read from PRIVACY_* variables
+type Settings struct {
+	DefaultK int
+	MinK     int // teams can't configure a smaller k
+	Noise    bool
+	Epsilon  float64
+}
+
+var (
+	settingsOnce sync.Once
+	settings     Settings
+)
+
+// Config returns the settings from the environment
+func Config() Settings {
+	settingsOnce.Do(func() {
+		settings = Settings{
+			MinK:    env.PositiveInt("PRIVACY_MIN_K", 3),
+			Noise:   env.Bool("PRIVACY_NOISE", false),
+			Epsilon: env.PositiveFloat("PRIVACY_EPSILON", 1.0),
+		}
+		settings.DefaultK = max(env.PositiveInt("PRIVACY_DEFAULT_K", 5), settings.MinK)
+	})
+	return settings
+}
+
+// DefaultPolicy applies to aggregates not scoped to a team with its own configuration
+func DefaultPolicy() Policy {
+	s := Config()
+	return Policy{K: s.DefaultK, Noise: s.Noise, Epsilon: s.Epsilon}
+}
+
+// Bucket is one group of an aggregate, with the distinct contributors it was computed from;
+// see DistinctUsers
+type Bucket struct {
+	Key   string
+	Count int
+	Users []string
+}
+
+// Result is an aggregate after the policy was applied
+type Result struct {
+	Buckets []Bucket
+	// Merged counts the buckets folded into OtherBucket
+	Merged int
+	// Suppressed reports whether check-ins were withheld entirely because even the
+	// merged bucket had fewer than K users
+	Suppressed bool
+}
+
+// Apply enforces k-anonymity on buckets: every bucket with fewer than policy.K distinct
+// users is merged into OtherBucket, which is itself dropped if still below K. Counts are
+// then perturbed if the policy asks for noise. The input is not modified.
+func Apply(buckets []Bucket, policy Policy) Result {
+	var (
+		result Result
+		other  = Bucket{Key: OtherBucket}
+		seen   = map[string]bool{}
+	)
+	for _, b := range buckets {
+		if DistinctUsers(b.Users) >= policy.K {
+			result.Buckets = append(result.Buckets, Bucket{Key: b.Key, Count: b.Count})
+			continue
+		}
+		result.Merged++
+		other.Count += b.Count
+		for _, u := range b.Users {
+			if !seen[u] {
+				seen[u] = true
+				other.Users = append(other.Users, u)
+			}
+		}
+	}
+
+	if result.Merged > 0 {
+		if DistinctUsers(other.Users) >= policy.K {
+			result.Buckets = append(result.Buckets, Bucket{Key: other.Key, Count: other.Count})
+		} else {
+			result.Suppressed = true
+		}
+	}
+
+	if policy.Noise {
+		for i := range result.Buckets {
+			result.Buckets[i].Count = noisy(result.Buckets[i].Count, policy.Epsilon, policy.Query, result.Buckets[i].Key)
+		}
+	}
+
+	// Largest first; the order must not reveal which buckets were merged
+	slices.SortStableFunc(result.Buckets, func(a, b Bucket) int {
+		return b.Count - a.Count
+	})
+	return result
+}
+
+// noisy adds Laplace(1/epsilon) noise to a count (sensitivity 1) and rounds it,
+// never returning a negative count. The noise is drawn from a keyed hash of the query,
+// the bucket within it and the count, so it is the same whenever they are.
+func noisy(count int, epsilon float64, query string, bucket ...string) int {
+	if epsilon <= 0 {
+		epsilon = 1
+	}
+	u := uniform(append([]string{query, strconv.Itoa(count)}, bucket...)...) - 0.5
+	noise := -(1 / epsilon) * math.Copysign(1, u) * math.Log(1-2*math.Abs(u))
+	return max(0, int(math.Round(float64(count)+noise)))
+}
+
+// uniform derives a number in (0, 1) from parts with the server's secret, so it can't be
+// predicted and subtracted by clients
+func uniform(parts ...string) float64 {
+	mac := hmac.New(sha256.New, Anonymous().secret)
+	mac.Write([]byte("noise"))
+	for _, p := range parts {
+		mac.Write([]byte{0})
+		mac.Write([]byte(p))
+	}
+	bits := binary.BigEndian.Uint64(mac.Sum(nil)) >> 11
+	return (float64(bits) + 0.5) / (1 << 53)
+}
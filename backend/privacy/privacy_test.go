@@ -0,0 +1,191 @@
+package privacy
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func users(ids ...string) []string { return ids }
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name       string
+		buckets    []Bucket
+		k          int
+		want       []Bucket
+		merged     int
+		suppressed bool
+	}{
+		{
+			name: "buckets with k users are kept, largest first",
+			buckets: []Bucket{
+				{Key: "sad", Count: 3, Users: users("a", "b", "c")},
+				{Key: "happy", Count: 5, Users: users("a", "b", "d")},
+			},
+			k:    3,
+			want: []Bucket{{Key: "happy", Count: 5}, {Key: "sad", Count: 3}},
+		},
+		{
+			name: "small buckets are merged into other",
+			buckets: []Bucket{
+				{Key: "happy", Count: 4, Users: users("a", "b", "c")},
+				{Key: "sad", Count: 1, Users: users("d")},
+				{Key: "angry", Count: 2, Users: users("e", "f")},
+			},
+			k:      3,
+			want:   []Bucket{{Key: "happy", Count: 4}, {Key: OtherBucket, Count: 3}},
+			merged: 2,
+		},
+		{
+			name: "other is dropped if still below k",
+			buckets: []Bucket{
+				{Key: "happy", Count: 4, Users: users("a", "b", "c")},
+				{Key: "sad", Count: 1, Users: users("d")},
+			},
+			k:          3,
+			want:       []Bucket{{Key: "happy", Count: 4}},
+			merged:     1,
+			suppressed: true,
+		},
+		{
+			name: "users in several small buckets count once in other",
+			buckets: []Bucket{
+				{Key: "sad", Count: 2, Users: users("a", "b")},
+				{Key: "angry", Count: 1, Users: users("b")},
+			},
+			k:          3,
+			merged:     2,
+			suppressed: true,
+		},
+		{
+			name: "rotating pseudonyms don't add up to k",
+			buckets: []Bucket{
+				{Key: "sad", Count: 3, Users: users(
+					AnonymousContributor("2026-10-17", "p1"),
+					AnonymousContributor("2026-10-18", "p2"),
+					AnonymousContributor("2026-10-19", "p3"),
+				)},
+			},
+			k:          3,
+			merged:     1,
+			suppressed: true,
+		},
+		{
+			name: "pseudonyms of one period are distinct users",
+			buckets: []Bucket{
+				{Key: "sad", Count: 3, Users: users(
+					AnonymousContributor("2026-10-19", "p1"),
+					AnonymousContributor("2026-10-19", "p2"),
+					AnonymousContributor("2026-10-19", "p3"),
+				)},
+			},
+			k:    3,
+			want: []Bucket{{Key: "sad", Count: 3}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Apply(tt.buckets, Policy{K: tt.k})
+			if !reflect.DeepEqual(got.Buckets, tt.want) {
+				t.Errorf("buckets = %v, want %v", got.Buckets, tt.want)
+			}
+			if got.Merged != tt.merged {
+				t.Errorf("merged = %d, want %d", got.Merged, tt.merged)
+			}
+			if got.Suppressed != tt.suppressed {
+				t.Errorf("suppressed = %v, want %v", got.Suppressed, tt.suppressed)
+			}
+		})
+	}
+}
+
+func TestDistinctUsers(t *testing.T) {
+	tests := []struct {
+		name         string
+		contributors []string
+		want         int
+	}{
+		{"none", nil, 0},
+		{"named", users("a", "b"), 2},
+		{"anonymous across periods", users(
+			AnonymousContributor("2026-10-18", "p1"),
+			AnonymousContributor("2026-10-19", "p2"),
+			AnonymousContributor("2026-10-19", "p3"),
+		), 2},
+		{"named and anonymous may be the same people", users(
+			"a",
+			AnonymousContributor("2026-10-19", "p1"),
+			AnonymousContributor("2026-10-19", "p2"),
+		), 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DistinctUsers(tt.contributors); got != tt.want {
+				t.Errorf("DistinctUsers() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNoisyNeverNegative(t *testing.T) {
+	tests := []struct {
+		count   int
+		epsilon float64
+	}{
+		{0, 0.1},
+		{1, 0.1},
+		{0, 0},
+		{3, -1},
+	}
+	for _, tt := range tests {
+		for i := range 1000 {
+			query := QueryKey("test", fmt.Sprint(i))
+			if got := noisy(tt.count, tt.epsilon, query); got < 0 {
+				t.Fatalf("noisy(%d, %v) = %d for %s", tt.count, tt.epsilon, got, query)
+			}
+		}
+	}
+}
+
+func TestNoiseIsDeterministicPerQuery(t *testing.T) {
+	buckets := []Bucket{
+		{Key: "happy", Count: 20, Users: users("a", "b", "c")},
+		{Key: "sad", Count: 10, Users: users("a", "b", "c")},
+	}
+	policy := Policy{K: 3, Noise: true, Epsilon: 0.5, Query: QueryKey("moods", "week", "core", "")}
+
+	first := Apply(buckets, policy)
+	sum := map[string]int{}
+	const repeats = 50
+	for range repeats {
+		got := Apply(buckets, policy)
+		if !reflect.DeepEqual(got, first) {
+			t.Fatalf("repeated query = %v, first answer %v", got.Buckets, first.Buckets)
+		}
+		for _, b := range got.Buckets {
+			sum[b.Key] += b.Count
+		}
+	}
+	// Averaging repeated answers gives back the first answer, not the true count
+	for _, b := range first.Buckets {
+		if avg := sum[b.Key] / repeats; avg != b.Count {
+			t.Errorf("average of %s = %d, want %d", b.Key, avg, b.Count)
+		}
+	}
+
+	h := Histogram{Counts: map[int]int{1: 4, 3: 9, 5: 2}, Users: users("a", "b", "c")}
+	if a, b := Summarize("energy", h, policy), Summarize("energy", h, policy); !reflect.DeepEqual(a, b) {
+		t.Errorf("repeated summary = %+v, first %+v", b, a)
+	}
+}
+
+func TestNoiseDiffersBetweenQueries(t *testing.T) {
+	seen := map[int]bool{}
+	for _, team := range []string{"core", "design", "qa", "ops", "mobile", "web", "data", "infra"} {
+		seen[noisy(50, 0.5, QueryKey("moods", "week", team, ""), "happy")] = true
+	}
+	if len(seen) < 2 {
+		t.Errorf("eight different queries got the same noisy count %v", seen)
+	}
+}
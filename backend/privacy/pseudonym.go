@@ -0,0 +1,118 @@
+package privacy
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"backend/env"
+	"backend/utils"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// AnonymousSettings control anonymous check-ins, read from ANONYMOUS_* variables
+type AnonymousSettings struct {
+	// Period is how long a pseudonym lives: "day" or "week"
+	Period string
+	// Limit is how many anonymous check-ins one user may make per period
+	Limit  int
+	secret []byte
+}
+
+var (
+	anonymousOnce sync.Once
+	anonymous     AnonymousSettings
+)
+
+// Anonymous returns the anonymous check-in settings from the environment
+func Anonymous() AnonymousSettings {
+	anonymousOnce.Do(func() {
+		anonymous = AnonymousSettings{
+			Period: os.Getenv("ANONYMOUS_CHECKIN_PERIOD"),
+			Limit:  env.PositiveInt("ANONYMOUS_CHECKIN_LIMIT", 1),
+		}
+		if anonymous.Period != "week" {
+			anonymous.Period = "day"
+		}
+
+		anonymous.secret = []byte(os.Getenv("ANONYMOUS_PSEUDONYM_SECRET"))
+		if len(anonymous.secret) == 0 {
+			slog.Warn("ANONYMOUS_PSEUDONYM_SECRET not set, deriving pseudonyms from the JWT secret")
+			anonymous.secret = utils.GetJWTSecret()
+		}
+	})
+	return anonymous
+}
+
+// PeriodKey names the pseudonym period containing t, e.g. "2026-10-19" or "2026-W42"
+func (s AnonymousSettings) PeriodKey(t time.Time) string {
+	t = t.UTC()
+	if s.Period == "week" {
+		year, week := t.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week)
+	}
+	return t.Format("2006-01-02")
+}
+
+// Pseudonym identifies a user within one period without revealing who they are.
+// It changes every period, so check-ins from different periods can't be linked, and
+// can't be recomputed without the server's secret.
+func (s AnonymousSettings) Pseudonym(userID, periodKey string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte("anonymous-checkin\x00" + periodKey + "\x00" + userID))
+	return hex.EncodeToString(mac.Sum(nil)[:16])
+}
+
+// anonymousPrefix starts the contributor IDs of anonymous check-ins
+const anonymousPrefix = "anon:"
+
+// AnonymousContributor identifies an anonymous check-in in distinct-user counts by the
+// period of its pseudonym and the pseudonym itself
+func AnonymousContributor(periodKey, pseudonym string) string {
+	return anonymousPrefix + periodKey + ":" + pseudonym
+}
+
+// ContributorExpr is an aggregation expression identifying who made a check-in, for
+// DistinctUsers: the user ID, or AnonymousContributor for anonymous check-ins
+var ContributorExpr = bson.M{"$cond": bson.A{
+	bson.M{"$eq": bson.A{"$anonymous", true}},
+	bson.M{"$concat": bson.A{
+		anonymousPrefix,
+		bson.M{"$ifNull": bson.A{"$period", ""}},
+		":",
+		bson.M{"$ifNull": bson.A{"$pseudonym", ""}},
+	}},
+	bson.M{"$toString": bson.M{"$ifNull": bson.A{"$user_id", "unknown"}}},
+}}
+
+// DistinctUsers counts the people behind contributors (user IDs and AnonymousContributor
+// IDs) as conservatively as they allow. A pseudonym only tells people apart within its
+// period and one person gets a new one every period, so anonymous contributors count as
+// the most that checked in within any single period. Anyone may also have checked in
+// under their name, so the count is the larger of the named and anonymous counts, never
+// their sum.
+func DistinctUsers(contributors []string) int {
+	named := 0
+	perPeriod := map[string]int{}
+	for _, c := range contributors {
+		rest, ok := strings.CutPrefix(c, anonymousPrefix)
+		if !ok {
+			named++
+			continue
+		}
+		period, _, _ := strings.Cut(rest, ":")
+		perPeriod[period]++
+	}
+	anonymous := 0
+	for _, n := range perPeriod {
+		anonymous = max(anonymous, n)
+	}
+	return max(named, anonymous)
+}
@@ -0,0 +1,64 @@
+package privacy
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"backend/config"
+	"backend/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// TeamsCollection stores per-team settings; teams without a document use the defaults
+const TeamsCollection = "teams"
+
+// TeamPolicy returns the policy for aggregates scoped to team
+func TeamPolicy(ctx context.Context, team string) (Policy, error) {
+	policy := DefaultPolicy()
+	if team == "" {
+		return policy, nil
+	}
+
+	var doc models.Team
+	err := config.DB.Collection(TeamsCollection).FindOne(ctx, bson.M{"name": team}).Decode(&doc)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return policy, nil
+	}
+	if err != nil {
+		return policy, err
+	}
+	return teamPolicy(doc, policy), nil
+}
+
+// SetTeamPolicy stores the team's k and noise setting, creating the team document if needed
+func SetTeamPolicy(ctx context.Context, team string, k int, noise bool, updatedBy string) (Policy, error) {
+	var doc models.Team
+	err := config.DB.Collection(TeamsCollection).FindOneAndUpdate(ctx,
+		bson.M{"name": team},
+		bson.M{
+			"$set":         bson.M{"anonymityK": k, "noise": noise, "updatedAt": time.Now(), "updatedBy": updatedBy},
+			"$setOnInsert": bson.M{"name": team},
+		},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&doc)
+	if err != nil {
+		return Policy{}, err
+	}
+	return teamPolicy(doc, DefaultPolicy()), nil
+}
+
+// teamPolicy applies the team's overrides to def. A team can raise k freely but never
+// go below the deployment's minimum.
+func teamPolicy(doc models.Team, def Policy) Policy {
+	if doc.AnonymityK > 0 {
+		def.K = max(doc.AnonymityK, Config().MinK)
+	}
+	if doc.Noise != nil {
+		def.Noise = *doc.Noise
+	}
+	return def
+}
@@ -0,0 +1,261 @@
+// Package reminders sends users a daily check-in reminder at their local time on their
+// working days, unless they already checked in, through the channels they picked.
+package reminders
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	_ "time/tzdata" // users pick any IANA zone; don't depend on the image having tzdata
+
+	"backend/apperror"
+	"backend/checkin"
+	"backend/config"
+	"backend/env"
+	"backend/lifecycle"
+	"backend/metrics"
+	"backend/models"
+	"backend/notifications"
+	"backend/notify"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	started sync.Once
+	stop    = make(chan struct{})
+	done    = make(chan struct{})
+
+	// Overridden from REMINDER_INTERVAL in Start
+	interval = time.Minute
+)
+
+// Start runs the scheduler in the background. Every replica runs it; each reminder is
+// claimed on the user before it is sent, so concurrent runs don't send it twice.
+func Start() {
+	started.Do(func() {
+		interval = env.Duration("REMINDER_INTERVAL", interval)
+
+		lifecycle.OnStop("reminders", func(ctx context.Context) error {
+			close(stop)
+			select {
+			case <-done:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+		go run()
+	})
+}
+
+func run() {
+	defer close(done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case now := <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), interval)
+			sent, err := SendDue(ctx, now)
+			cancel()
+			if err != nil {
+				slog.Warn("Sending reminders failed, will retry", "error", err, "sent", sent)
+			} else if sent > 0 {
+				slog.Info("Check-in reminders sent", "count", sent)
+			}
+		}
+	}
+}
+
+// DefaultTimezone is offered to users who haven't picked one: CHECKIN_TIMEZONE, else UTC
+func DefaultTimezone() string {
+	if name := checkin.Config().Location.String(); name != "Local" {
+		return name
+	}
+	return "UTC"
+}
+
+// Due reports whether r wants a reminder at now and, if so, the local day it is for.
+// A reminder is due from its time until the end of a working day, outside quiet hours
+// and snoozes, once per day.
+func Due(r models.ReminderSettings, now time.Time) (day string, due bool) {
+	if !r.Enabled {
+		return "", false
+	}
+	if r.SnoozedUntil != nil && now.Before(*r.SnoozedUntil) {
+		return "", false
+	}
+	loc, err := time.LoadLocation(r.Timezone)
+	if err != nil {
+		return "", false
+	}
+
+	local := now.In(loc)
+	day = local.Format("2006-01-02")
+	if day == r.LastSentDay || !slices.Contains(r.Workdays, int(local.Weekday())) {
+		return "", false
+	}
+
+	clock := local.Format("15:04")
+	if clock < r.Time {
+		return "", false
+	}
+	if q := r.QuietHours; q != nil && inRange(clock, q.Start, q.End) {
+		return "", false
+	}
+	return day, true
+}
+
+// inRange reports whether clock is in [start, end), where the range may wrap midnight
+func inRange(clock, start, end string) bool {
+	if start <= end {
+		return start <= clock && clock < end
+	}
+	return clock >= start || clock < end
+}
+
+// SendDue sends every reminder due at now and returns how many users were reminded
+func SendDue(ctx context.Context, now time.Time) (int, error) {
+	if config.UserCollectionRef == nil {
+		return 0, nil
+	}
+
+	filter := bson.M{
+		"reminder.enabled": true,
+		"deletedAt":        bson.M{"$exists": false},
+	}
+	opts := options.Find().SetProjection(bson.M{"_id": 1, "nama": 1, "email": 1, "reminder": 1, "anonymousMode": 1})
+	cursor, err := config.UserCollectionRef.Find(ctx, filter, opts)
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	sent := 0
+	for cursor.Next(ctx) {
+		var user models.User
+		if err := cursor.Decode(&user); err != nil {
+			return sent, err
+		}
+		if user.Reminder == nil {
+			continue
+		}
+		day, due := Due(*user.Reminder, now)
+		if !due {
+			continue
+		}
+
+		ok, err := remind(ctx, user, day, now)
+		if err != nil {
+			return sent, err
+		}
+		if ok {
+			sent++
+		}
+	}
+	return sent, cursor.Err()
+}
+
+// remind sends user their reminder for day unless they already made a named check-in
+// that day. Anonymous check-ins can't be told apart, so users in anonymous mode get a
+// reminder that doesn't claim they haven't checked in.
+func remind(ctx context.Context, user models.User, day string, now time.Time) (bool, error) {
+	loc, _ := time.LoadLocation(user.Reminder.Timezone) // validated by Due
+	local := now.In(loc)
+	midnight := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+
+	checkedIn, err := checkedInSince(ctx, user.ID, midnight)
+	if err != nil || checkedIn {
+		return false, err
+	}
+
+	// Claim the day's reminder; another replica or a check-in race may have beaten us
+	claim := bson.M{"$and": bson.A{config.UserIDFilter(user.ID), bson.M{"reminder.lastSentDay": bson.M{"$ne": day}}}}
+	res, err := config.UserCollectionRef.UpdateOne(ctx, claim, bson.M{"$set": bson.M{"reminder.lastSentDay": day}})
+	if err != nil || res.MatchedCount == 0 {
+		return false, err
+	}
+
+	to := notify.Recipient{
+		UserID:     user.ID,
+		Name:       user.Nama,
+		Email:      user.Email,
+		WebhookURL: user.Reminder.WebhookURL,
+	}
+	msg := message(user, day)
+
+	delivered := false
+	for _, name := range user.Reminder.Channels {
+		ch, ok := notify.Get(name)
+		if !ok {
+			continue
+		}
+		err := ch.Send(ctx, to, msg)
+		switch {
+		case err == nil:
+			delivered = true
+			metrics.RemindersSent.WithLabelValues(name, "sent").Inc()
+		case errors.Is(err, notify.ErrNotConfigured):
+			metrics.RemindersSent.WithLabelValues(name, "skipped").Inc()
+		default:
+			// Not retried: a late reminder is worse than a missed one
+			metrics.RemindersSent.WithLabelValues(name, "failed").Inc()
+			slog.Warn("Reminder delivery failed", "channel", name, "user", user.ID, "error", err)
+		}
+	}
+	return delivered, nil
+}
+
+// checkedInSince reports whether the user made a named check-in after since
+func checkedInSince(ctx context.Context, userID string, since time.Time) (bool, error) {
+	objectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return false, nil
+	}
+	n, err := config.DB.Collection("emotions").CountDocuments(ctx,
+		bson.M{"user_id": objectID, "created_at": bson.M{"$gte": since}},
+		options.Count().SetLimit(1),
+	)
+	return n > 0, err
+}
+
+// message renders the reminder text in the user's locale
+func message(user models.User, day string) notify.Message {
+	locale := user.Reminder.Locale
+	if !slices.Contains(apperror.Locales, locale) {
+		locale = apperror.DefaultLocale()
+	}
+
+	name := strings.TrimSpace(user.Nama)
+	msg := notify.Message{
+		Type:    notifications.TypeReminder,
+		Link:    "/dashboard",
+		Payload: map[string]interface{}{"day": day},
+	}
+	switch {
+	case locale == "en" && user.AnonymousMode:
+		msg.Title = "How are you feeling today?"
+		msg.Body = "Hi " + name + ", if you haven't checked in today, it only takes a moment."
+	case locale == "en":
+		msg.Title = "How are you feeling today?"
+		msg.Body = "Hi " + name + ", you haven't checked in today yet. It only takes a moment."
+	case user.AnonymousMode:
+		msg.Title = "Bagaimana perasaan Anda hari ini?"
+		msg.Body = "Hai " + name + ", jika belum check-in hari ini, hanya butuh sebentar."
+	default:
+		msg.Title = "Bagaimana perasaan Anda hari ini?"
+		msg.Body = "Hai " + name + ", Anda belum check-in hari ini. Hanya butuh sebentar."
+	}
+	return msg
+}
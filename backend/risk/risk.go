@@ -0,0 +1,331 @@
+// Package risk looks for wellbeing risks in the check-ins, such as sustained low moods,
+// sharp drops against a person's or team's own baseline and people who stopped checking
+// in, and raises alerts for the team leaders.
+//
+// Anonymous check-ins only count towards team alerts and are never linked back to a
+// user; users in anonymous mode aren't alerted about as missing. Team rules only look at
+// days and windows with at least the team's k distinct contributors.
+package risk
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"backend/config"
+	"backend/lifecycle"
+	"backend/metrics"
+	"backend/models"
+	"backend/moods"
+	"backend/notifications"
+	"backend/privacy"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Collection holds the alerts
+const Collection = "alerts"
+
+// Alert scopes
+const (
+	ScopeUser = "user"
+	ScopeTeam = "team"
+)
+
+// teamLeaderRole receives and handles the alerts of their team
+const teamLeaderRole = "Team Leader"
+
+var (
+	started sync.Once
+	stop    = make(chan struct{})
+	done    = make(chan struct{})
+)
+
+// Start runs the rules every RISK_INTERVAL in the background. Every replica runs them;
+// an episode is stored once per subject and rule, so leaders are notified once.
+func Start() {
+	started.Do(func() {
+		lifecycle.OnStop("risk", func(ctx context.Context) error {
+			close(stop)
+			select {
+			case <-done:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+		go run()
+	})
+}
+
+func run() {
+	defer close(done)
+
+	interval := Config().Interval
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case now := <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), interval)
+			raised, err := Scan(ctx, now)
+			cancel()
+			if err != nil {
+				slog.Warn("Wellbeing risk scan failed, will retry", "error", err, "raised", raised)
+			} else if raised > 0 {
+				slog.Info("Wellbeing alerts raised", "count", raised)
+			}
+		}
+	}
+}
+
+// Scan applies the rules to the check-ins of the recent and baseline windows, raises
+// alerts for new findings and resolves those whose rule no longer matches. Returns how
+// many alerts were raised.
+func Scan(ctx context.Context, now time.Time) (int, error) {
+	s := Config()
+	users, teams, err := load(ctx, s, now)
+	if err != nil {
+		return 0, err
+	}
+
+	raised := 0
+	seen := bson.A{}
+	add := func(a models.Alert, findings []Finding) error {
+		for _, f := range findings {
+			a.Rule, a.Details = f.Rule, f.Details
+			isNew, key, err := raise(ctx, a, now)
+			if err != nil {
+				return err
+			}
+			seen = append(seen, key)
+			if isNew {
+				raised++
+			}
+		}
+		return nil
+	}
+
+	if s.UserAlerts {
+		flagged := map[string][]Finding{}
+		for userID, points := range users {
+			findings := s.Evaluate(points, now, 1)
+			if f, ok := s.Missing(points, now); ok {
+				findings = append(findings, f)
+			}
+			if len(findings) > 0 {
+				flagged[userID] = findings
+			}
+		}
+
+		subjects, err := lookupUsers(ctx, flagged)
+		if err != nil {
+			return raised, err
+		}
+		for _, user := range subjects {
+			findings := flagged[user.ID]
+			if user.AnonymousMode {
+				// They check in anonymously by choice, so named check-ins stopping says nothing
+				findings = slices.DeleteFunc(findings, func(f Finding) bool { return f.Rule == RuleMissingCheckins })
+			}
+			a := models.Alert{Scope: ScopeUser, Team: user.Team, UserID: user.ID, UserName: user.Nama}
+			if err := add(a, findings); err != nil {
+				return raised, err
+			}
+		}
+	}
+
+	for team, points := range teams {
+		policy, err := privacy.TeamPolicy(ctx, team)
+		if err != nil {
+			return raised, err
+		}
+		if err := add(models.Alert{Scope: ScopeTeam, Team: team}, s.Evaluate(points, now, policy.K)); err != nil {
+			return raised, err
+		}
+	}
+
+	// Only after a complete scan, or alerts of subjects not reached yet would be resolved
+	return raised, settle(ctx, seen, now)
+}
+
+// settle ends the episodes whose rule didn't match in the scan at now: unresolved alerts
+// are resolved, and alerts a leader resolved while the rule still matched may be raised
+// again from now on
+func settle(ctx context.Context, seen bson.A, now time.Time) error {
+	collection := config.DB.Collection(Collection)
+	over := bson.M{"key": bson.M{"$exists": true, "$nin": seen}, "lastSeenAt": bson.M{"$lt": now}}
+
+	_, err := collection.UpdateMany(ctx,
+		bson.M{"$and": bson.A{over, bson.M{"status": bson.M{"$ne": models.AlertResolved}}}},
+		bson.M{
+			"$set":   bson.M{"status": models.AlertResolved, "resolvedAt": now, "resolvedBy": "system"},
+			"$unset": bson.M{"key": ""},
+		},
+	)
+	if err != nil {
+		return err
+	}
+	_, err = collection.UpdateMany(ctx, over, bson.M{"$unset": bson.M{"key": ""}})
+	return err
+}
+
+// load reads the check-ins of both windows as points per named user and per team,
+// oldest first
+func load(ctx context.Context, s Settings, now time.Time) (map[string][]Point, map[string][]Point, error) {
+	_, since := s.Windows(now)
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: 1}}).
+		SetProjection(bson.M{"user_id": 1, "team": 1, "mood": 1, "created_at": 1, "anonymous": 1, "pseudonym": 1, "period": 1})
+	cursor, err := config.DB.Collection("emotions").Find(ctx, bson.M{"created_at": bson.M{"$gte": since}}, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer cursor.Close(ctx)
+
+	users := map[string][]Point{}
+	teams := map[string][]Point{}
+	for cursor.Next(ctx) {
+		var e models.Emotion
+		if err := cursor.Decode(&e); err != nil {
+			return nil, nil, err
+		}
+		mood, ok := moods.Get(e.Mood)
+		if !ok {
+			continue
+		}
+
+		p := Point{At: e.CreatedAt, Valence: mood.Valence}
+		switch {
+		case e.Anonymous:
+			p.Contributor = privacy.AnonymousContributor(e.Period, e.Pseudonym)
+		case !e.UserID.IsZero():
+			p.Contributor = e.UserID.Hex()
+			users[p.Contributor] = append(users[p.Contributor], p)
+		}
+		if e.Team != "" {
+			teams[e.Team] = append(teams[e.Team], p)
+		}
+	}
+	return users, teams, cursor.Err()
+}
+
+// lookupUsers loads the flagged users that still exist and belong to a team
+func lookupUsers(ctx context.Context, flagged map[string][]Finding) ([]models.User, error) {
+	if len(flagged) == 0 {
+		return nil, nil
+	}
+	ids := bson.A{}
+	for userID := range flagged {
+		ids = append(ids, userID)
+		if objectID, err := primitive.ObjectIDFromHex(userID); err == nil {
+			ids = append(ids, objectID)
+		}
+	}
+
+	cursor, err := config.UserCollectionRef.Find(ctx,
+		bson.M{
+			"_id":       bson.M{"$in": ids},
+			"team":      bson.M{"$nin": bson.A{nil, ""}},
+			"deletedAt": bson.M{"$exists": false},
+		},
+		options.Find().SetProjection(bson.M{"nama": 1, "team": 1, "anonymousMode": 1}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	var users []models.User
+	err = cursor.All(ctx, &users)
+	return users, err
+}
+
+// raise stores a as an open alert unless the episode of its subject and rule already has
+// one, in which case that one's details are refreshed. A leader resolving an alert doesn't
+// reopen it here; leaders hear about new episodes only.
+func raise(ctx context.Context, a models.Alert, now time.Time) (bool, string, error) {
+	a.Key = fmt.Sprintf("%s:%s:%s:%s", a.Scope, a.Rule, a.Team, a.UserID)
+	a.ID = primitive.NewObjectID()
+	a.Status = models.AlertOpen
+	a.CreatedAt, a.LastSeenAt = now, now
+
+	collection := config.DB.Collection(Collection)
+	_, err := collection.InsertOne(ctx, a)
+	if mongo.IsDuplicateKeyError(err) {
+		_, err = collection.UpdateOne(ctx, bson.M{"key": a.Key},
+			bson.M{"$set": bson.M{"details": a.Details, "userName": a.UserName, "lastSeenAt": now}},
+		)
+		return false, a.Key, err
+	}
+	if err != nil {
+		return false, a.Key, err
+	}
+
+	metrics.AlertsRaised.WithLabelValues(a.Scope, a.Rule).Inc()
+	if err := notifyLeaders(ctx, a); err != nil {
+		// The alert is listed for the leaders either way
+		slog.Warn("Notifying team leaders of an alert failed", "alert", a.ID.Hex(), "error", err)
+	}
+	return true, a.Key, nil
+}
+
+// notifyLeaders sends a notification about a to the leaders of its team, except the
+// person the alert is about
+func notifyLeaders(ctx context.Context, a models.Alert) error {
+	cursor, err := config.UserCollectionRef.Find(ctx,
+		bson.M{"team": a.Team, "role": teamLeaderRole, "deletedAt": bson.M{"$exists": false}},
+		options.Find().SetProjection(bson.M{"_id": 1}),
+	)
+	if err != nil {
+		return err
+	}
+	var leaders []models.User
+	if err := cursor.All(ctx, &leaders); err != nil {
+		return err
+	}
+
+	to := make([]string, 0, len(leaders))
+	for _, leader := range leaders {
+		if leader.ID != a.UserID {
+			to = append(to, leader.ID)
+		}
+	}
+	if len(to) == 0 {
+		return nil
+	}
+
+	title := "Wellbeing alert: team " + a.Team
+	if a.Scope == ScopeUser {
+		title = "Wellbeing alert: " + a.UserName
+	}
+	return notifications.WellbeingAlert(ctx, to, title, Describe(a), "/my-team?alert="+a.ID.Hex(),
+		map[string]interface{}{"alertId": a.ID.Hex(), "scope": a.Scope, "rule": a.Rule, "team": a.Team},
+	)
+}
+
+// Describe explains an alert in one sentence
+func Describe(a models.Alert) string {
+	switch a.Rule {
+	case RuleNegativeStreak:
+		return fmt.Sprintf("Low mood on %v check-in days in a row", a.Details["days"])
+	case RuleMoodDrop:
+		return fmt.Sprintf("Average mood dropped by %v compared to the previous weeks", a.Details["drop"])
+	case RuleMissingCheckins:
+		return fmt.Sprintf("No check-in for %v days", a.Details["days"])
+	}
+	return a.Rule
+}
+
+// DeleteForUser removes the alerts about the user, e.g. when the account is purged
+func DeleteForUser(ctx context.Context, userID string) error {
+	_, err := config.DB.Collection(Collection).DeleteMany(ctx, bson.M{"userId": userID})
+	return err
+}
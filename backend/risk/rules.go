@@ -0,0 +1,240 @@
+package risk
+
+import (
+	"maps"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"backend/checkin"
+	"backend/env"
+	"backend/privacy"
+)
+
+// Rules
+const (
+	// RuleNegativeStreak: the mood stayed at or below RISK_NEGATIVE_VALENCE on the last
+	// RISK_STREAK_DAYS days with check-ins
+	RuleNegativeStreak = "negative_streak"
+	// RuleMoodDrop: the average mood of the recent window fell by RISK_DROP or more
+	// below the baseline window before it
+	RuleMoodDrop = "mood_drop"
+	// RuleMissingCheckins: someone who checked in regularly during the baseline window
+	// hasn't for RISK_MISSING_DAYS days; users only, except those in anonymous mode
+	RuleMissingCheckins = "missing_checkins"
+)
+
+// Settings are read from RISK_* environment variables on first use
+type Settings struct {
+	Interval        time.Duration
+	StreakDays      int
+	NegativeValence float64
+	Drop            float64
+	// RecentDays are compared against the BaselineDays before them
+	RecentDays   int
+	BaselineDays int
+	// MinCheckins each window needs before the averages are compared
+	MinCheckins int
+	MissingDays int
+	// UserAlerts switches alerts about individuals on; team alerts are always raised
+	UserAlerts bool
+}
+
+// Point is one check-in as the rules see it
+type Point struct {
+	At time.Time
+	// Valence is the mood's score, from -1 to 1
+	Valence float64
+	// Contributor is the user ID or privacy.AnonymousContributor; "" for anonymized check-ins
+	Contributor string
+}
+
+// Finding is a rule that matched, with the numbers behind it
+type Finding struct {
+	Rule    string
+	Details map[string]interface{}
+}
+
+var (
+	settingsOnce sync.Once
+	settings     Settings
+)
+
+// Config returns the rule settings from the environment
+func Config() Settings {
+	settingsOnce.Do(func() {
+		settings = Settings{
+			Interval:        env.Duration("RISK_INTERVAL", time.Hour),
+			StreakDays:      env.PositiveInt("RISK_STREAK_DAYS", 3),
+			NegativeValence: env.Float("RISK_NEGATIVE_VALENCE", -0.3),
+			Drop:            env.Float("RISK_DROP", 0.5),
+			RecentDays:      env.PositiveInt("RISK_RECENT_DAYS", 7),
+			BaselineDays:    env.PositiveInt("RISK_BASELINE_DAYS", 28),
+			MinCheckins:     env.PositiveInt("RISK_MIN_CHECKINS", 3),
+			MissingDays:     env.PositiveInt("RISK_MISSING_DAYS", 5),
+			UserAlerts:      env.Bool("RISK_USER_ALERTS", true),
+		}
+	})
+	return settings
+}
+
+// Windows returns where the recent window and the baseline window before it start;
+// both end at now
+func (s Settings) Windows(now time.Time) (recentStart, baselineStart time.Time) {
+	recentStart = checkin.Config().DayStart(now).AddDate(0, 0, -(s.RecentDays - 1))
+	return recentStart, recentStart.AddDate(0, 0, -s.BaselineDays)
+}
+
+// Evaluate applies the streak and drop rules to points, oldest first. A day or window
+// only counts when at least k distinct contributors checked in, so team aggregates
+// never rest on fewer people than the team's anonymity threshold; use 1 for one user.
+func (s Settings) Evaluate(points []Point, now time.Time, k int) []Finding {
+	var findings []Finding
+	if f, ok := s.streak(points, now, k); ok {
+		findings = append(findings, f)
+	}
+	if f, ok := s.drop(points, now, k); ok {
+		findings = append(findings, f)
+	}
+	return findings
+}
+
+func (s Settings) streak(points []Point, now time.Time, k int) (Finding, bool) {
+	type day struct {
+		key          string
+		sum          float64
+		n            int
+		contributors map[string]bool
+	}
+	days := []*day{}
+	byKey := map[string]*day{}
+	checkins := checkin.Config()
+	for _, p := range points {
+		key := checkins.DayKey(p.At)
+		d, ok := byKey[key]
+		if !ok {
+			d = &day{key: key, contributors: map[string]bool{}}
+			byKey[key] = d
+			days = append(days, d)
+		}
+		d.sum += p.Valence
+		d.n++
+		if p.Contributor != "" {
+			d.contributors[p.Contributor] = true
+		}
+	}
+	days = slices.DeleteFunc(days, func(d *day) bool { return distinct(d.contributors) < k })
+	slices.SortFunc(days, func(a, b *day) int { return strings.Compare(a.key, b.key) })
+
+	// The streak has to reach into the recent window, or it is over already
+	recentStart, _ := s.Windows(now)
+	if len(days) == 0 || days[len(days)-1].key < checkins.DayKey(recentStart) {
+		return Finding{}, false
+	}
+
+	var sum float64
+	streak := 0
+	for i := len(days) - 1; i >= 0; i-- {
+		avg := days[i].sum / float64(days[i].n)
+		if avg > s.NegativeValence {
+			break
+		}
+		sum += avg
+		streak++
+	}
+	if streak < s.StreakDays {
+		return Finding{}, false
+	}
+	return Finding{Rule: RuleNegativeStreak, Details: map[string]interface{}{
+		"days":    streak,
+		"since":   days[len(days)-streak].key,
+		"average": round(sum / float64(streak)),
+	}}, true
+}
+
+func (s Settings) drop(points []Point, now time.Time, k int) (Finding, bool) {
+	recentStart, baselineStart := s.Windows(now)
+	var recent, baseline window
+	for _, p := range points {
+		switch {
+		case !p.At.Before(recentStart):
+			recent.add(p)
+		case !p.At.Before(baselineStart):
+			baseline.add(p)
+		}
+	}
+	if !recent.enough(s.MinCheckins, k) || !baseline.enough(s.MinCheckins, k) {
+		return Finding{}, false
+	}
+
+	drop := baseline.mean() - recent.mean()
+	if drop < s.Drop {
+		return Finding{}, false
+	}
+	return Finding{Rule: RuleMoodDrop, Details: map[string]interface{}{
+		"baseline": round(baseline.mean()),
+		"recent":   round(recent.mean()),
+		"drop":     round(drop),
+	}}, true
+}
+
+// Missing applies the missing check-ins rule to one user's points, oldest first
+func (s Settings) Missing(points []Point, now time.Time) (Finding, bool) {
+	if len(points) == 0 {
+		return Finding{}, false
+	}
+	recentStart, baselineStart := s.Windows(now)
+	regular := 0
+	for _, p := range points {
+		if !p.At.Before(baselineStart) && p.At.Before(recentStart) {
+			regular++
+		}
+	}
+
+	last := points[len(points)-1].At
+	if regular < s.MinCheckins || now.Sub(last) < time.Duration(s.MissingDays)*24*time.Hour {
+		return Finding{}, false
+	}
+	return Finding{Rule: RuleMissingCheckins, Details: map[string]interface{}{
+		"lastCheckin": last,
+		"days":        int(now.Sub(last).Hours() / 24),
+	}}, true
+}
+
+// window accumulates the check-ins of one period
+type window struct {
+	sum          float64
+	n            int
+	contributors map[string]bool
+}
+
+func (w *window) add(p Point) {
+	if w.contributors == nil {
+		w.contributors = map[string]bool{}
+	}
+	w.sum += p.Valence
+	w.n++
+	if p.Contributor != "" {
+		w.contributors[p.Contributor] = true
+	}
+}
+
+func (w *window) enough(minCheckins, k int) bool {
+	return w.n >= minCheckins && distinct(w.contributors) >= k
+}
+
+// distinct counts the people behind a set of contributors, see privacy.DistinctUsers
+func distinct(contributors map[string]bool) int {
+	return privacy.DistinctUsers(slices.Collect(maps.Keys(contributors)))
+}
+
+func (w *window) mean() float64 {
+	return w.sum / float64(w.n)
+}
+
+// round keeps two decimals, enough to explain an alert
+func round(f float64) float64 {
+	return math.Round(f*100) / 100
+}
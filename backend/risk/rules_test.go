@@ -0,0 +1,190 @@
+package risk
+
+import (
+	"testing"
+	"time"
+
+	"backend/privacy"
+)
+
+// Local noon, so day keys follow checkin.Config whatever the machine's timezone
+var now = time.Date(2026, 10, 19, 12, 0, 0, 0, time.Local)
+
+var testSettings = Settings{
+	StreakDays:      3,
+	NegativeValence: -0.3,
+	Drop:            0.5,
+	RecentDays:      7,
+	BaselineDays:    28,
+	MinCheckins:     3,
+	MissingDays:     5,
+}
+
+// checkins makes one check-in per contributor, daysAgo days before now
+func checkins(daysAgo int, valence float64, contributors ...string) []Point {
+	var points []Point
+	for _, c := range contributors {
+		points = append(points, Point{At: now.AddDate(0, 0, -daysAgo), Valence: valence, Contributor: c})
+	}
+	return points
+}
+
+// series joins check-ins, oldest first as the rules expect
+func series(days ...[]Point) []Point {
+	var points []Point
+	for _, d := range days {
+		points = append(points, d...)
+	}
+	return points
+}
+
+func anon(daysAgo int, pseudonym string) string {
+	return privacy.AnonymousContributor(now.AddDate(0, 0, -daysAgo).Format("2006-01-02"), pseudonym)
+}
+
+func TestStreak(t *testing.T) {
+	tests := []struct {
+		name   string
+		points []Point
+		k      int
+		want   int // streak days, 0 for no finding
+	}{
+		{"no check-ins", nil, 1, 0},
+		{
+			"three negative days",
+			series(checkins(2, -0.5, "a"), checkins(1, -0.4, "a"), checkins(0, -0.6, "a")),
+			1, 3,
+		},
+		{
+			"a better day breaks the streak",
+			series(checkins(3, -0.5, "a"), checkins(2, 0.5, "a"), checkins(1, -0.5, "a"), checkins(0, -0.5, "a")),
+			1, 0,
+		},
+		{
+			"ended before the recent window",
+			series(checkins(12, -0.5, "a"), checkins(11, -0.5, "a"), checkins(10, -0.5, "a")),
+			1, 0,
+		},
+		{
+			"days below k are suppressed",
+			series(checkins(2, -0.5, "a", "b"), checkins(1, -0.5, "a"), checkins(0, -0.5, "a", "b")),
+			2, 0,
+		},
+		{
+			"a suppressed day doesn't break the streak",
+			series(checkins(3, -0.5, "a", "b"), checkins(2, -0.5, "a", "b"), checkins(1, -0.5, "a", "b"), checkins(0, 0.8, "a")),
+			2, 3,
+		},
+		{
+			"rotating pseudonyms don't add up to k",
+			series(
+				checkins(2, -0.5, anon(2, "p1"), anon(3, "p2")),
+				checkins(1, -0.5, anon(1, "p1"), anon(2, "p2")),
+				checkins(0, -0.5, anon(0, "p1"), anon(1, "p2")),
+			),
+			2, 0,
+		},
+		{
+			"pseudonyms of one period are distinct people",
+			series(
+				checkins(2, -0.5, anon(2, "p1"), anon(2, "p2")),
+				checkins(1, -0.5, anon(1, "p1"), anon(1, "p2")),
+				checkins(0, -0.5, anon(0, "p1"), anon(0, "p2")),
+			),
+			2, 3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, ok := testSettings.streak(tt.points, now, tt.k)
+			got := 0
+			if ok {
+				got = f.Details["days"].(int)
+			}
+			if got != tt.want {
+				t.Errorf("streak days = %d, want %d (%v)", got, tt.want, f.Details)
+			}
+		})
+	}
+}
+
+func TestDrop(t *testing.T) {
+	baseline := series(checkins(20, 0.5, "a"), checkins(15, 0.5, "b"), checkins(10, 0.5, "c"))
+	tests := []struct {
+		name   string
+		points []Point
+		k      int
+		want   float64 // drop, 0 for no finding
+	}{
+		{
+			"dropped",
+			series(baseline, checkins(3, -0.2, "a"), checkins(2, -0.2, "b"), checkins(1, -0.2, "c")),
+			1, 0.7,
+		},
+		{
+			"smaller than RISK_DROP",
+			series(baseline, checkins(3, 0.2, "a"), checkins(2, 0.2, "b"), checkins(1, 0.2, "c")),
+			1, 0,
+		},
+		{
+			"recent window below MinCheckins",
+			series(baseline, checkins(2, -0.2, "a"), checkins(1, -0.2, "b")),
+			1, 0,
+		},
+		{
+			"baseline older than its window",
+			series(checkins(40, 0.5, "a", "b", "c"), checkins(3, -0.2, "a"), checkins(2, -0.2, "b"), checkins(1, -0.2, "c")),
+			1, 0,
+		},
+		{
+			"recent window below k",
+			series(baseline, checkins(3, -0.2, "a"), checkins(2, -0.2, "a"), checkins(1, -0.2, "a")),
+			2, 0,
+		},
+		{
+			"anonymous contributors across periods count once",
+			series(baseline, checkins(3, -0.2, anon(3, "p1")), checkins(2, -0.2, anon(2, "p2")), checkins(1, -0.2, anon(1, "p3"))),
+			2, 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, ok := testSettings.drop(tt.points, now, tt.k)
+			got := 0.0
+			if ok {
+				got = f.Details["drop"].(float64)
+			}
+			if got != tt.want {
+				t.Errorf("drop = %v, want %v (%v)", got, tt.want, f.Details)
+			}
+		})
+	}
+}
+
+func TestMissing(t *testing.T) {
+	regular := series(checkins(12, 0.1, "a"), checkins(10, 0.1, "a"), checkins(8, 0.1, "a"))
+	tests := []struct {
+		name   string
+		points []Point
+		want   int // days since the last check-in, 0 for no finding
+	}{
+		{"never checked in", nil, 0},
+		{"regular, then gone", regular, 8},
+		{"checked in recently", series(regular, checkins(2, 0.1, "a")), 0},
+		{"not long enough", series(regular, checkins(4, 0.1, "a")), 0},
+		{"below MinCheckins", series(checkins(10, 0.1, "a"), checkins(8, 0.1, "a")), 0},
+		{"only before the baseline", series(checkins(40, 0.1, "a"), checkins(39, 0.1, "a"), checkins(38, 0.1, "a")), 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, ok := testSettings.Missing(tt.points, now)
+			got := 0
+			if ok {
+				got = f.Details["days"].(int)
+			}
+			if got != tt.want {
+				t.Errorf("missing days = %d, want %d (%v)", got, tt.want, f.Details)
+			}
+		})
+	}
+}
@@ -1,57 +1,133 @@
 package routes
 
 import (
-    "backend/controllers"
-    "backend/middleware"
+	"backend/controllers"
+	"backend/docs"
+	"backend/env"
+	"backend/middleware"
 
-    "github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 func SetupRoutes(app *fiber.App) {
-    // TAMBAHKAN: Route untuk debugging di root
-    app.Get("/", func(c *fiber.Ctx) error {
-        return c.SendString("API Server is running")
-    })
-
-    // Auth routes - di kedua lokasi untuk kompatibilitas
-    // 1. Tanpa prefix /auth untuk frontend lama
-    app.Post("/login", controllers.Login)
-    app.Post("/register", controllers.Register)
-    
-    // 2. Dengan prefix /auth untuk frontend baru
-    auth := app.Group("/auth")
-    auth.Post("/login", controllers.Login)
-    auth.Post("/register", controllers.Register)
-
-    // TAMBAHKAN: Non-protected User endpoint
-    app.Get("/users", controllers.GetUsers)
-    app.Get("/users/:id", controllers.GetUserById)
-
-    // Protected Api routes
-    api := app.Group("/api")
-    api.Use(middleware.Protected())
-
-    // Protected User endpoints dalam group /api
-    api.Get("/users", controllers.GetUsers)
-    api.Get("/team-members", controllers.GetTeamMembers)
-    api.Get("/users/:id", controllers.GetUserById)
-    api.Put("/users/:id", controllers.UpdateUser)
-
-    // Add these lines to your routes setup
-
-    // Emotion routes
-    app.Post("/api/emotions", controllers.SaveEmotion)
-    app.Get("/api/emotions/stats", controllers.GetEmotionStats)
-    app.Get("/api/emotions/user/:id", controllers.GetUserEmotions)
-
-    // Upload profile image
-    api.Post("/upload-profile-image", controllers.UploadProfileImage)
-
-    // Health check
-    app.Get("/health", func(c *fiber.Ctx) error {
-        return c.Status(200).JSON(fiber.Map{
-            "status": "ok",
-            "message": "Server is running",
-        })
-    })
-}
\ No newline at end of file
+	// Route untuk debugging di root
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendString("API Server is running")
+	})
+
+	setupV1Routes(app.Group("/api/v1"))
+
+	// Legacy unversioned routes stay available until LEGACY_SUNSET unless switched off
+	if env.Bool("LEGACY_ROUTES_ENABLED", true) {
+		setupLegacyRoutes(app)
+	}
+
+	// API documentation
+	app.Get("/openapi.json", docs.OpenAPI)
+	app.Get("/docs", docs.UI)
+
+	// Uploaded files, served from the configured storage backend
+	app.Get("/uploads/*", controllers.ServeUpload)
+
+	// Prometheus metrics
+	app.Get("/metrics", adaptor.HTTPHandler(promhttp.Handler()))
+
+	// Health checks: liveness never touches dependencies, readiness checks MongoDB and migrations
+	app.Get("/livez", controllers.Livez)
+	app.Get("/readyz", controllers.Readyz)
+	app.Get("/health", controllers.Readyz)
+}
+
+// setupV1Routes registers the versioned API. Authentication is attached per route
+// rather than with a group-level Use, which would also match the public auth routes.
+func setupV1Routes(v1 fiber.Router) {
+	protected := middleware.Protected()
+
+	// Auth
+	v1.Post("/auth/login", controllers.Login)
+	v1.Post("/auth/register", controllers.Register)
+
+	// Users
+	v1.Get("/users", protected, controllers.GetUsers)
+	v1.Get("/users/:id", protected, controllers.GetUserById)
+	v1.Put("/users/:id", protected, controllers.UpdateUser)
+	v1.Get("/team-members", protected, controllers.GetTeamMembers)
+
+	// Current user
+	v1.Post("/me/profile-image", protected, controllers.UploadProfileImage)
+	v1.Put("/me/status", protected, controllers.SetStatusMessage)
+	v1.Delete("/me/status", protected, controllers.ClearStatusMessage)
+	v1.Get("/me/export", protected, controllers.ExportMyData)
+	v1.Delete("/me", protected, controllers.DeleteMyAccount)
+	v1.Get("/me/reminders", protected, controllers.GetReminderSettings)
+	v1.Put("/me/reminders", protected, controllers.UpdateReminderSettings)
+	v1.Post("/me/reminders/snooze", protected, controllers.SnoozeReminder)
+	v1.Get("/me/insights", protected, controllers.GetMyInsights)
+	v1.Get("/me/privacy", protected, controllers.GetMyPrivacy)
+	v1.Put("/me/privacy", protected, controllers.SetMyPrivacy)
+
+	// Presence
+	v1.Get("/presence", protected, controllers.GetPresence)
+	v1.Get("/presence/ws", controllers.PresenceFeedUpgrade, protected, controllers.PresenceFeed)
+
+	// Emotions
+	v1.Post("/emotions", protected, controllers.SaveEmotion)
+	v1.Get("/emotions/stats", protected, controllers.GetEmotionStats)
+	v1.Get("/emotions/stats/dimensions", protected, controllers.GetDimensionStats)
+	v1.Get("/emotions/user/:id", protected, controllers.GetUserEmotions)
+	v1.Put("/emotions/:id", protected, controllers.UpdateEmotion)
+	v1.Delete("/emotions/:id", protected, controllers.DeleteEmotion)
+
+	// Notifications
+	v1.Get("/notifications", protected, controllers.GetNotifications)
+	v1.Get("/notifications/ws", controllers.NotificationFeedUpgrade, protected, controllers.NotificationFeed)
+	v1.Put("/notifications/read-all", protected, controllers.MarkAllNotificationsRead)
+	v1.Put("/notifications/:id/read", protected, controllers.MarkNotificationRead)
+
+	// Meetings run in the browser; these only notify the people involved and the team
+	meetingNotify := middleware.MeetingNotifyLimit()
+	v1.Post("/meetings/:id/invites", protected, meetingNotify, controllers.InviteToMeeting)
+	v1.Post("/meetings/:id/mentions", protected, meetingNotify, controllers.MentionInMeeting)
+	v1.Post("/meetings/:id/start", protected, controllers.StartMeeting)
+	v1.Post("/meetings/:id/end", protected, controllers.EndMeeting)
+
+	v1.Get("/moods", protected, controllers.GetMoods)
+	v1.Post("/moods/match", protected, controllers.MatchExpression)
+	v1.Put("/moods/:key", protected, controllers.PutMood)
+
+	v1.Get("/teams/:name/privacy", protected, controllers.GetTeamPrivacy)
+	v1.Put("/teams/:name/privacy", protected, controllers.SetTeamPrivacy)
+	v1.Get("/teams/:name/events", protected, controllers.TeamEvents)
+
+	// Wellbeing alerts, for team leaders
+	v1.Get("/alerts", protected, controllers.GetAlerts)
+	v1.Put("/alerts/:id/acknowledge", protected, controllers.AcknowledgeAlert)
+	v1.Put("/alerts/:id/resolve", protected, controllers.ResolveAlert)
+}
+
+// setupLegacyRoutes serves the pre-/api/v1 paths with Deprecation/Sunset headers.
+// The root /users routes used to be public; they now require a token like their successors.
+func setupLegacyRoutes(app *fiber.App) {
+	protected := middleware.Protected()
+	deprecated := middleware.Deprecated
+
+	app.Post("/login", deprecated("/api/v1/auth/login"), controllers.Login)
+	app.Post("/register", deprecated("/api/v1/auth/register"), controllers.Register)
+	app.Post("/auth/login", deprecated("/api/v1/auth/login"), controllers.Login)
+	app.Post("/auth/register", deprecated("/api/v1/auth/register"), controllers.Register)
+
+	app.Get("/users", deprecated("/api/v1/users"), protected, controllers.GetUsersLegacy)
+	app.Get("/users/:id", deprecated("/api/v1/users/:id"), protected, controllers.GetUserById)
+
+	app.Get("/api/users", deprecated("/api/v1/users"), protected, controllers.GetUsersLegacy)
+	app.Get("/api/users/:id", deprecated("/api/v1/users/:id"), protected, controllers.GetUserById)
+	app.Put("/api/users/:id", deprecated("/api/v1/users/:id"), protected, controllers.UpdateUser)
+	app.Get("/api/team-members", deprecated("/api/v1/team-members"), protected, controllers.GetTeamMembersLegacy)
+	app.Post("/api/upload-profile-image", deprecated("/api/v1/me/profile-image"), protected, controllers.UploadProfileImage)
+
+	app.Post("/api/emotions", deprecated("/api/v1/emotions"), protected, controllers.SaveEmotion)
+	app.Get("/api/emotions/stats", deprecated("/api/v1/emotions/stats"), protected, controllers.GetEmotionStats)
+	app.Get("/api/emotions/user/:id", deprecated("/api/v1/emotions/user/:id"), protected, controllers.GetUserEmotions)
+}
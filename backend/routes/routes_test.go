@@ -0,0 +1,94 @@
+package routes
+
+import (
+	"encoding/json"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+
+	"backend/docs"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+var pathParam = regexp.MustCompile(`:(\w+)`)
+
+// registeredOperations returns "METHOD /path" for every route SetupRoutes registers,
+// with fiber's :param syntax converted to OpenAPI's {param} and a trailing * to {path}
+func registeredOperations(t *testing.T) map[string]bool {
+	t.Helper()
+
+	app := fiber.New()
+	SetupRoutes(app)
+
+	ops := map[string]bool{}
+	for _, r := range app.GetRoutes(true) {
+		// fiber adds HEAD for every GET automatically
+		if r.Method == fiber.MethodHead {
+			continue
+		}
+		path := pathParam.ReplaceAllString(r.Path, "{$1}")
+		if p, ok := strings.CutSuffix(path, "*"); ok {
+			path = p + "{path}"
+		}
+		ops[r.Method+" "+path] = true
+	}
+	return ops
+}
+
+// specOperations returns "METHOD /path" for every operation in docs/openapi.json
+func specOperations(t *testing.T) map[string]bool {
+	t.Helper()
+
+	var spec struct {
+		Paths map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	if err := json.Unmarshal(docs.Spec, &spec); err != nil {
+		t.Fatalf("openapi.json is not valid JSON: %v", err)
+	}
+
+	ops := map[string]bool{}
+	for path, item := range spec.Paths {
+		for method := range item {
+			switch method {
+			case "parameters", "summary", "description", "servers":
+				continue
+			}
+			ops[strings.ToUpper(method)+" "+path] = true
+		}
+	}
+	return ops
+}
+
+func TestEveryRouteIsDocumented(t *testing.T) {
+	spec := specOperations(t)
+
+	var missing []string
+	for op := range registeredOperations(t) {
+		if !spec[op] {
+			missing = append(missing, op)
+		}
+	}
+	sort.Strings(missing)
+
+	for _, op := range missing {
+		t.Errorf("route %s is registered but missing from docs/openapi.json", op)
+	}
+}
+
+func TestEveryDocumentedRouteExists(t *testing.T) {
+	registered := registeredOperations(t)
+
+	var stale []string
+	for op := range specOperations(t) {
+		if !registered[op] {
+			stale = append(stale, op)
+		}
+	}
+	sort.Strings(stale)
+
+	for _, op := range stale {
+		t.Errorf("docs/openapi.json documents %s but no such route is registered", op)
+	}
+}
@@ -0,0 +1,172 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"backend/env"
+)
+
+// Errors returned by every Store implementation
+var (
+	ErrNotFound     = errors.New("object not found")
+	ErrNotSupported = errors.New("operation not supported by this store")
+)
+
+// Object describes a stored file
+type Object struct {
+	Size        int64
+	ContentType string
+	ModTime     time.Time
+}
+
+// Store keeps uploaded files under slash-separated keys such as "avatars/<name>_256.jpg"
+type Store interface {
+	// Put stores size bytes from r under key, replacing any existing object
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Open returns the object's content, or ErrNotFound; the caller closes it
+	Open(ctx context.Context, key string) (io.ReadCloser, Object, error)
+	// Delete removes the object; deleting a missing object is not an error
+	Delete(ctx context.Context, key string) error
+	// SignedURL returns a time-limited direct download URL, or ErrNotSupported
+	SignedURL(ctx context.Context, key string, ttl time.Duration) (string, error)
+	// Name identifies the backend in logs
+	Name() string
+}
+
+// URLPrefix is the path uploads are served under; stored profile images are URLPrefix+key,
+// so they stay valid whichever backend holds the file
+const URLPrefix = "/uploads/"
+
+var keyPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*(/[A-Za-z0-9][A-Za-z0-9._-]*)*$`)
+
+// ValidKey reports whether key is safe to use as both a file path and an object name.
+// New objects must use valid keys.
+func ValidKey(key string) bool {
+	return keyPattern.MatchString(key) && !strings.Contains(key, "..")
+}
+
+// readableKey reports whether key can be looked up. It is looser than ValidKey so files
+// uploaded before names were generated (with spaces or parentheses) stay reachable,
+// but still rejects anything that could escape the store's root.
+func readableKey(key string) bool {
+	if key == "" || strings.ContainsAny(key, "\\\x00") || strings.HasPrefix(key, "/") {
+		return false
+	}
+	return path.Clean(key) == key && !slices.Contains(strings.Split(key, "/"), "..")
+}
+
+// URL returns the path an object is served from
+func URL(key string) string {
+	return URLPrefix + key
+}
+
+// KeyFromURL extracts the key from an upload URL, which may include the API origin
+// (e.g. "http://localhost:8080/uploads/avatars/x.jpg")
+func KeyFromURL(url string) (string, bool) {
+	i := strings.Index(url, URLPrefix)
+	if i < 0 || (i > 0 && !strings.Contains(url[:i], "://")) {
+		return "", false
+	}
+	key := url[i+len(URLPrefix):]
+	return key, readableKey(key)
+}
+
+// URLMode decides how uploads are downloaded
+type URLMode string
+
+const (
+	// Proxy streams objects through the API
+	Proxy URLMode = "proxy"
+	// Signed redirects to a time-limited URL of the object store
+	Signed URLMode = "signed"
+)
+
+// Config selects and configures the backend
+type Config struct {
+	Driver   string // "local" or "s3"
+	LocalDir string
+
+	S3Endpoint  string
+	S3Region    string
+	S3Bucket    string
+	S3AccessKey string
+	S3SecretKey string
+	S3UseSSL    bool
+
+	URLMode      URLMode
+	SignedURLTTL time.Duration
+}
+
+// ConfigFromEnv reads STORAGE_* and S3_* environment variables
+func ConfigFromEnv() Config {
+	cfg := Config{
+		Driver:       strings.ToLower(os.Getenv("STORAGE_DRIVER")),
+		LocalDir:     os.Getenv("STORAGE_LOCAL_DIR"),
+		S3Endpoint:   os.Getenv("S3_ENDPOINT"),
+		S3Region:     os.Getenv("S3_REGION"),
+		S3Bucket:     os.Getenv("S3_BUCKET"),
+		S3AccessKey:  os.Getenv("S3_ACCESS_KEY"),
+		S3SecretKey:  os.Getenv("S3_SECRET_KEY"),
+		S3UseSSL:     env.Bool("S3_USE_SSL", true),
+		URLMode:      URLMode(strings.ToLower(os.Getenv("STORAGE_URL_MODE"))),
+		SignedURLTTL: env.Duration("STORAGE_SIGNED_URL_TTL", 15*time.Minute),
+	}
+	if cfg.Driver == "" {
+		cfg.Driver = "local"
+	}
+	if cfg.LocalDir == "" {
+		cfg.LocalDir = "./uploads"
+	}
+	if cfg.URLMode != Signed {
+		cfg.URLMode = Proxy
+	}
+	return cfg
+}
+
+// New creates the store described by cfg
+func New(cfg Config) (Store, error) {
+	switch cfg.Driver {
+	case "local":
+		return NewLocal(cfg.LocalDir)
+	case "s3":
+		return NewS3(cfg)
+	}
+	return nil, fmt.Errorf("unknown STORAGE_DRIVER %q", cfg.Driver)
+}
+
+var (
+	defaultStore Store
+	defaultCfg   Config
+)
+
+// Init creates the process-wide store from the environment; call once at startup
+func Init() error {
+	cfg := ConfigFromEnv()
+	store, err := New(cfg)
+	if err != nil {
+		return err
+	}
+	defaultStore, defaultCfg = store, cfg
+	slog.Info("Upload storage ready", "driver", store.Name(), "urlMode", string(cfg.URLMode))
+	return nil
+}
+
+// Default returns the store created by Init
+func Default() Store {
+	return defaultStore
+}
+
+// DefaultConfig returns the configuration Init used
+func DefaultConfig() Config {
+	return defaultCfg
+}
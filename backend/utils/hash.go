@@ -1,20 +1,226 @@
 package utils
 
 import (
-    "golang.org/x/crypto/bcrypt"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"backend/env"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
 )
 
+// ErrEmptyPassword is returned when asked to hash an empty password
+var ErrEmptyPassword = errors.New("password must not be empty")
+
+// ErrUnknownHash is returned for stored hashes no configured algorithm recognises
+var ErrUnknownHash = errors.New("unrecognised password hash format")
+
+// Hasher hashes and verifies passwords with one algorithm
+type Hasher interface {
+	// Hash returns a self-describing encoded hash of password
+	Hash(password string) (string, error)
+	// Verify reports whether password matches encoded
+	Verify(password, encoded string) (bool, error)
+	// Identifies reports whether encoded was produced by this algorithm
+	Identifies(encoded string) bool
+	// Outdated reports whether encoded uses weaker parameters than the hasher's current ones
+	Outdated(encoded string) bool
+}
+
+// BcryptHasher hashes with bcrypt at the given cost
+type BcryptHasher struct {
+	Cost int
+}
+
+func (h BcryptHasher) Hash(password string) (string, error) {
+	bytes, err := bcrypt.GenerateFromPassword([]byte(password), h.Cost)
+	return string(bytes), err
+}
+
+func (h BcryptHasher) Verify(password, encoded string) (bool, error) {
+	err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (h BcryptHasher) Identifies(encoded string) bool {
+	return strings.HasPrefix(encoded, "$2a$") || strings.HasPrefix(encoded, "$2b$") || strings.HasPrefix(encoded, "$2y$")
+}
+
+func (h BcryptHasher) Outdated(encoded string) bool {
+	cost, err := bcrypt.Cost([]byte(encoded))
+	return err != nil || cost != h.Cost
+}
+
+// Argon2idHasher hashes with argon2id and encodes results in the PHC string format:
+// $argon2id$v=19$m=<KiB>,t=<iterations>,p=<parallelism>$<salt>$<key>
+type Argon2idHasher struct {
+	MemoryKiB   uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+type argon2Params struct {
+	memory      uint32
+	iterations  uint32
+	parallelism uint8
+	salt        []byte
+	key         []byte
+}
+
+func (h Argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, h.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, h.Iterations, h.MemoryKiB, h.Parallelism, h.KeyLength)
+
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, h.MemoryKiB, h.Iterations, h.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+func (h Argon2idHasher) Verify(password, encoded string) (bool, error) {
+	p, err := decodeArgon2id(encoded)
+	if err != nil {
+		return false, err
+	}
+
+	key := argon2.IDKey([]byte(password), p.salt, p.iterations, p.memory, p.parallelism, uint32(len(p.key)))
+	return subtle.ConstantTimeCompare(key, p.key) == 1, nil
+}
+
+func (h Argon2idHasher) Identifies(encoded string) bool {
+	return strings.HasPrefix(encoded, "$argon2id$")
+}
+
+func (h Argon2idHasher) Outdated(encoded string) bool {
+	p, err := decodeArgon2id(encoded)
+	if err != nil {
+		return true
+	}
+	return p.memory != h.MemoryKiB || p.iterations != h.Iterations ||
+		p.parallelism != h.Parallelism || uint32(len(p.key)) != h.KeyLength
+}
+
+func decodeArgon2id(encoded string) (argon2Params, error) {
+	var p argon2Params
+
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return p, ErrUnknownHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return p, fmt.Errorf("unsupported argon2 version %q", parts[2])
+	}
+
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.memory, &p.iterations, &p.parallelism); err != nil {
+		return p, fmt.Errorf("invalid argon2 parameters: %w", err)
+	}
+
+	var err error
+	if p.salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return p, fmt.Errorf("invalid argon2 salt: %w", err)
+	}
+	if p.key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil {
+		return p, fmt.Errorf("invalid argon2 key: %w", err)
+	}
+	return p, nil
+}
+
+var (
+	hashersOnce  sync.Once
+	activeHasher Hasher
+	knownHashers []Hasher
+)
+
+// hashers returns the hasher new passwords use (PASSWORD_HASH_ALGORITHM, default argon2id)
+// and every hasher stored hashes may have been produced with
+func hashers() (Hasher, []Hasher) {
+	hashersOnce.Do(func() {
+		argon := Argon2idHasher{
+			MemoryKiB:   uint32(env.Int("ARGON2_MEMORY_KIB", 19*1024)),
+			Iterations:  uint32(env.Int("ARGON2_ITERATIONS", 2)),
+			Parallelism: uint8(env.Int("ARGON2_PARALLELISM", 1)),
+			SaltLength:  16,
+			KeyLength:   32,
+		}
+		bc := BcryptHasher{Cost: env.Int("BCRYPT_COST", 12)}
+
+		knownHashers = []Hasher{argon, bc}
+		activeHasher = argon
+		if strings.ToLower(os.Getenv("PASSWORD_HASH_ALGORITHM")) == "bcrypt" {
+			activeHasher = bc
+		}
+	})
+	return activeHasher, knownHashers
+}
+
+// HashPassword hashes password with the configured algorithm
 func HashPassword(password string) (string, error) {
-    bytes, err := bcrypt.GenerateFromPassword([]byte(password), 14)
-    return string(bytes), err
+	if password == "" {
+		return "", ErrEmptyPassword
+	}
+	active, _ := hashers()
+	return active.Hash(password)
 }
 
-func CheckPasswordHash(password, hash string) bool {
-    err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
-    return err == nil
+// VerifyPassword checks password against a stored hash of any supported algorithm.
+// needsRehash is true when the password matches but the hash uses a different algorithm
+// or weaker parameters than currently configured.
+func VerifyPassword(password, encoded string) (ok bool, needsRehash bool, err error) {
+	active, known := hashers()
+
+	for _, h := range known {
+		if !h.Identifies(encoded) {
+			continue
+		}
+
+		ok, err = h.Verify(password, encoded)
+		if err != nil || !ok {
+			return false, false, err
+		}
+
+		needsRehash = h != active || active.Outdated(encoded)
+		return true, needsRehash, nil
+	}
+
+	return false, false, ErrUnknownHash
 }
 
-func ComparePasswords(hashedPassword, plainPassword string) bool {
-    err := bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(plainPassword))
-    return err == nil
-}
\ No newline at end of file
+var (
+	dummyOnce sync.Once
+	dummyHash string
+)
+
+// VerifyDummy takes as long as VerifyPassword on an account with a current hash. Logins
+// with an unknown email call it, so response times don't tell which emails are registered.
+func VerifyDummy(password string) {
+	dummyOnce.Do(func() {
+		active, _ := hashers()
+		dummyHash, _ = active.Hash("not a real password")
+	})
+	VerifyPassword(password, dummyHash)
+}
+
+// CheckPasswordHash reports whether password matches the stored hash
+func CheckPasswordHash(password, hash string) bool {
+	ok, _, _ := VerifyPassword(password, hash)
+	return ok
+}
@@ -0,0 +1,125 @@
+package utils
+
+import (
+	"log/slog"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// LoggerKey is the fiber.Ctx Locals key holding the request-scoped logger
+const LoggerKey = "logger"
+
+// RequestIDKey is the fiber.Ctx Locals key holding the request ID
+const RequestIDKey = "requestId"
+
+const redacted = "[REDACTED]"
+
+// Keys whose values must never reach the logs, compared case-insensitively
+var sensitiveKeys = map[string]bool{
+	"authorization":   true,
+	"password":        true,
+	"currentpassword": true,
+	"newpassword":     true,
+	"token":           true,
+	"access_token":    true,
+	"refresh_token":   true,
+	"jwt":             true,
+	"secret":          true,
+	"jwt_secret":      true,
+}
+
+var (
+	bearerPattern = regexp.MustCompile(`(?i)bearer\s+[A-Za-z0-9\-_=.]+`)
+	jwtPattern    = regexp.MustCompile(`eyJ[A-Za-z0-9\-_=]+\.[A-Za-z0-9\-_=]+\.[A-Za-z0-9\-_.+/=]*`)
+)
+
+// InitLogger configures the default slog logger from LOG_LEVEL and LOG_FORMAT
+func InitLogger() *slog.Logger {
+	level := slog.LevelInfo
+	switch strings.ToLower(os.Getenv("LOG_LEVEL")) {
+	case "debug":
+		level = slog.LevelDebug
+	case "warn", "warning":
+		level = slog.LevelWarn
+	case "error":
+		level = slog.LevelError
+	}
+
+	opts := &slog.HandlerOptions{
+		Level:       level,
+		ReplaceAttr: redactAttr,
+	}
+
+	var handler slog.Handler
+	if strings.ToLower(os.Getenv("LOG_FORMAT")) == "text" {
+		handler = slog.NewTextHandler(os.Stdout, opts)
+	} else {
+		handler = slog.NewJSONHandler(os.Stdout, opts)
+	}
+
+	logger := slog.New(handler)
+	slog.SetDefault(logger)
+	return logger
+}
+
+// Log returns the request-scoped logger set by the RequestID middleware,
+// falling back to the default logger
+func Log(c *fiber.Ctx) *slog.Logger {
+	if logger, ok := c.Locals(LoggerKey).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
+
+// RedactString masks bearer tokens and JWTs embedded in free text
+func RedactString(s string) string {
+	s = bearerPattern.ReplaceAllString(s, "Bearer "+redacted)
+	return jwtPattern.ReplaceAllString(s, redacted)
+}
+
+// RedactMap returns a copy of m with sensitive keys masked, recursing into nested maps
+func RedactMap(m map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		if sensitiveKeys[strings.ToLower(k)] {
+			out[k] = redacted
+			continue
+		}
+
+		switch val := v.(type) {
+		case map[string]interface{}:
+			out[k] = RedactMap(val)
+		case fiber.Map:
+			out[k] = RedactMap(val)
+		case string:
+			out[k] = RedactString(val)
+		default:
+			out[k] = v
+		}
+	}
+	return out
+}
+
+func redactAttr(groups []string, a slog.Attr) slog.Attr {
+	if sensitiveKeys[strings.ToLower(a.Key)] {
+		return slog.String(a.Key, redacted)
+	}
+
+	switch a.Value.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, RedactString(a.Value.String()))
+	case slog.KindAny:
+		switch val := a.Value.Any().(type) {
+		case map[string]interface{}:
+			return slog.Any(a.Key, RedactMap(val))
+		case fiber.Map:
+			return slog.Any(a.Key, RedactMap(val))
+		case error:
+			return slog.String(a.Key, RedactString(val.Error()))
+		}
+	}
+	return a
+}
@@ -0,0 +1,164 @@
+package utils
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+
+	"backend/env"
+)
+
+// PasswordPolicy describes what a new password must satisfy
+type PasswordPolicy struct {
+	MinLength     int
+	RequireLetter bool
+	RequireUpper  bool
+	RequireLower  bool
+	RequireDigit  bool
+	RequireSymbol bool
+
+	// HistorySize is how many previous passwords (including the current one) can't be reused
+	HistorySize int
+
+	// BreachedDir holds k-anonymity range files named by the first five hex characters of the
+	// SHA-1 hash (e.g. 5BAA6.txt), each line "<remaining 35 hex chars>:<count>". Empty disables the check.
+	BreachedDir string
+}
+
+// PasswordViolation is one rule a password failed, with the rule's argument if any
+type PasswordViolation struct {
+	Rule  string
+	Param string
+}
+
+var (
+	policyOnce sync.Once
+	policy     PasswordPolicy
+)
+
+// GetPasswordPolicy returns the policy configured through PASSWORD_* environment variables
+func GetPasswordPolicy() PasswordPolicy {
+	policyOnce.Do(func() {
+		policy = PasswordPolicy{
+			MinLength:     env.Int("PASSWORD_MIN_LENGTH", 8),
+			RequireLetter: env.Bool("PASSWORD_REQUIRE_LETTER", true),
+			RequireUpper:  env.Bool("PASSWORD_REQUIRE_UPPER", false),
+			RequireLower:  env.Bool("PASSWORD_REQUIRE_LOWER", false),
+			RequireDigit:  env.Bool("PASSWORD_REQUIRE_DIGIT", true),
+			RequireSymbol: env.Bool("PASSWORD_REQUIRE_SYMBOL", false),
+			HistorySize:   env.Int("PASSWORD_HISTORY_SIZE", 5),
+			BreachedDir:   os.Getenv("BREACHED_PASSWORDS_DIR"),
+		}
+	})
+	return policy
+}
+
+// Check returns every rule password violates. personal holds values the password must not
+// equal (case-insensitively), such as the user's email and name.
+func (p PasswordPolicy) Check(password string, personal ...string) []PasswordViolation {
+	var violations []PasswordViolation
+
+	if len([]rune(password)) < p.MinLength {
+		violations = append(violations, PasswordViolation{Rule: "password_min", Param: strconv.Itoa(p.MinLength)})
+	}
+
+	var hasLetter, hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasLetter, hasUpper = true, true
+		case unicode.IsLower(r):
+			hasLetter, hasLower = true, true
+		case unicode.IsLetter(r):
+			hasLetter = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			hasSymbol = true
+		}
+	}
+
+	classes := []struct {
+		required, present bool
+		rule              string
+	}{
+		{p.RequireLetter, hasLetter, "password_letter"},
+		{p.RequireUpper, hasUpper, "password_upper"},
+		{p.RequireLower, hasLower, "password_lower"},
+		{p.RequireDigit, hasDigit, "password_digit"},
+		{p.RequireSymbol, hasSymbol, "password_symbol"},
+	}
+	for _, class := range classes {
+		if class.required && !class.present {
+			violations = append(violations, PasswordViolation{Rule: class.rule})
+		}
+	}
+
+	if isPersonal(password, personal) {
+		violations = append(violations, PasswordViolation{Rule: "password_personal"})
+	}
+
+	if p.IsBreached(password) {
+		violations = append(violations, PasswordViolation{Rule: "password_breached"})
+	}
+
+	return violations
+}
+
+// isPersonal reports whether password equals one of the personal values or an email's local part
+func isPersonal(password string, personal []string) bool {
+	pw := strings.ToLower(strings.TrimSpace(password))
+	for _, value := range personal {
+		value = strings.ToLower(strings.TrimSpace(value))
+		if value == "" {
+			continue
+		}
+		if pw == value {
+			return true
+		}
+		if local, _, ok := strings.Cut(value, "@"); ok && pw == local {
+			return true
+		}
+	}
+	return false
+}
+
+// IsBreached looks the password up in the local range files without any network access.
+// Lookup errors are logged and treated as "not breached" so a missing file can't block sign-ups.
+func (p PasswordPolicy) IsBreached(password string) bool {
+	if p.BreachedDir == "" || password == "" {
+		return false
+	}
+
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := hash[:5], hash[5:]
+
+	f, err := os.Open(filepath.Join(p.BreachedDir, prefix+".txt"))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			slog.Warn("Breached password lookup failed", "prefix", prefix, "error", err)
+		}
+		return false
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		candidate, _, _ := strings.Cut(scanner.Text(), ":")
+		if strings.EqualFold(strings.TrimSpace(candidate), suffix) {
+			return true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		slog.Warn("Breached password lookup failed", "prefix", prefix, "error", err)
+	}
+	return false
+}
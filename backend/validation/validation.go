@@ -0,0 +1,136 @@
+package validation
+
+import (
+	"errors"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	"backend/apperror"
+	"backend/models"
+	"backend/moods"
+	"backend/utils"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+var validate = newValidator()
+
+func newValidator() *validator.Validate {
+	v := validator.New(validator.WithRequiredStructEnabled())
+
+	// Report fields by their JSON name, which is what clients send
+	v.RegisterTagNameFunc(func(f reflect.StructField) string {
+		name := strings.SplitN(f.Tag.Get("json"), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		if name == "" {
+			return f.Name
+		}
+		return name
+	})
+
+	mustRegister(v, "mood", func(fl validator.FieldLevel) bool {
+		return moods.IsSelectable(fl.Field().String())
+	})
+	mustRegister(v, "role", func(fl validator.FieldLevel) bool {
+		return slices.Contains(models.Roles, fl.Field().String())
+	})
+	mustRegister(v, "future", func(fl validator.FieldLevel) bool {
+		t, ok := fl.Field().Interface().(time.Time)
+		return ok && t.After(time.Now())
+	})
+
+	v.RegisterStructValidation(registerPasswordPolicy, models.RegisterRequest{})
+
+	return v
+}
+
+func mustRegister(v *validator.Validate, tag string, fn validator.Func) {
+	if err := v.RegisterValidation(tag, fn); err != nil {
+		panic(err)
+	}
+}
+
+// registerPasswordPolicy applies the password policy during struct validation so its
+// violations are listed together with the other field errors
+func registerPasswordPolicy(sl validator.StructLevel) {
+	req := sl.Current().Interface().(models.RegisterRequest)
+	if req.Password == "" {
+		return // already reported by "required"
+	}
+	for _, v := range utils.GetPasswordPolicy().Check(req.Password, req.Email, req.Nama) {
+		sl.ReportError(req.Password, "password", "Password", v.Rule, v.Param)
+	}
+}
+
+// Password checks a new password against the policy; personal holds values it must not
+// equal, such as the account's email and name. Returns VALIDATION_FAILED or nil.
+func Password(field, password string, personal ...string) error {
+	violations := utils.GetPasswordPolicy().Check(password, personal...)
+	if len(violations) == 0 {
+		return nil
+	}
+
+	details := make([]apperror.FieldError, 0, len(violations))
+	for _, v := range violations {
+		details = append(details, apperror.FieldError{Field: field, Code: v.Rule, Param: v.Param})
+	}
+	return apperror.ValidationFailed.WithDetails(details...)
+}
+
+// Struct validates v against its `validate` tags and returns VALIDATION_FAILED
+// listing every offending field, or nil
+func Struct(v interface{}) error {
+	err := validate.Struct(v)
+	if err == nil {
+		return nil
+	}
+
+	var fieldErrs validator.ValidationErrors
+	if !errors.As(err, &fieldErrs) {
+		return apperror.Internal.Wrap(err)
+	}
+
+	details := make([]apperror.FieldError, 0, len(fieldErrs))
+	for _, fe := range fieldErrs {
+		details = append(details, apperror.FieldError{
+			Field: fe.Field(),
+			Code:  fe.Tag(),
+			Param: paramName(fe),
+		})
+	}
+	return apperror.ValidationFailed.WithDetails(details...)
+}
+
+// ParseBody decodes the request body into dst and validates it
+func ParseBody(c *fiber.Ctx, dst interface{}) error {
+	if err := c.BodyParser(dst); err != nil {
+		return apperror.InvalidRequest.Wrap(err)
+	}
+	return Struct(dst)
+}
+
+// ParseQuery decodes the query string into dst and validates it
+func ParseQuery(c *fiber.Ctx, dst interface{}) error {
+	if err := c.QueryParser(dst); err != nil {
+		return apperror.InvalidRequest.Wrap(err)
+	}
+	return Struct(dst)
+}
+
+// paramName turns cross-field params (Go field names) into the JSON names clients see;
+// request DTOs use lowerCamelCase JSON names for multi-word fields
+func paramName(fe validator.FieldError) string {
+	param := fe.Param()
+	if !strings.HasPrefix(fe.Tag(), "required_") || param == "" {
+		return param
+	}
+	r := []rune(param)
+	r[0] = unicode.ToLower(r[0])
+	return string(r)
+}
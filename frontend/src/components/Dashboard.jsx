@@ -1,5 +1,5 @@
 // filepath: d:\KULIAH\SEMESTER 4\Rekayasa Perangkat Lunak\code\NewRPL\frontend\src\components\Dashboard.jsx
-import React, { useState, useEffect, useContext } from 'react';
+import React, { useState, useEffect, useContext, useRef } from 'react';
 import { useNavigate, Link } from 'react-router-dom';
 import { AuthContext } from '../context/AuthContext';
 import Sidebar from './Sidebar';
@@ -15,19 +15,52 @@ import('./EmotionChart').then(module => {
     console.error('Error loading EmotionChart:', error);
 });
 
+// Dimensi opsional check-in, dinilai 1 (rendah) sampai 5 (tinggi)
+const DIMENSIONS = [
+    { key: 'energy', label: 'Energi' },
+    { key: 'stress', label: 'Stres' },
+    { key: 'workload', label: 'Beban kerja' },
+    { key: 'sleep', label: 'Kualitas tidur' }
+];
+
 // Import secara terpisah untuk mencegah konflik
 import * as emotionService from '../services/emotionService';
+import * as moodService from '../services/moodService';
+import { subscribeTeamEvents } from '../services/teamEventService';
+import { getPrivacyPreferences } from '../services/userService';
 
 function Dashboard() {
     const { user } = useContext(AuthContext);
     const [mood, setMood] = useState(null);
     const [moodNote, setMoodNote] = useState('');
+    const [anonymous, setAnonymous] = useState(false);
+    const [ratings, setRatings] = useState({});
+    const [tagInput, setTagInput] = useState('');
+    // Satu kunci per check-in; tetap sama saat dikirim ulang setelah gagal
+    const idempotencyKey = useRef(null);
     const [submitting, setSubmitting] = useState(false);
     const [submitMessage, setSubmitMessage] = useState({ text: '', type: '' });
     const [emotionStats, setEmotionStats] = useState([]);
+    const [moods, setMoods] = useState([]);
     const [loadingStats, setLoadingStats] = useState(true);
+    // Diperbarui langsung dari stream event tim
+    const [checkinsToday, setCheckinsToday] = useState(null);
+    const [liveMeetings, setLiveMeetings] = useState([]);
+    const statsRefreshTimer = useRef(null);
     const navigate = useNavigate();
 
+    // Daftar mood diatur per organisasi di server
+    useEffect(() => {
+        moodService.getMoods().then(setMoods);
+    }, []);
+
+    // Mode anonim di Settings menjadi pilihan awal check-in
+    useEffect(() => {
+        getPrivacyPreferences()
+            .then(preferences => setAnonymous(preferences.anonymousMode))
+            .catch(error => console.error('Error loading privacy preferences:', error));
+    }, []);
+
     // Initial useEffect to fetch emotion stats
     useEffect(() => {
         const fetchEmotionStats = async () => {
@@ -53,6 +86,39 @@ function Dashboard() {
         fetchEmotionStats();
     }, []);
 
+    // Stream event tim: statistik dimuat ulang setiap ada check-in baru, diubah atau dihapus
+    useEffect(() => {
+        // Beberapa check-in berdekatan cukup memicu satu kali muat ulang
+        const refreshStats = () => {
+            clearTimeout(statsRefreshTimer.current);
+            statsRefreshTimer.current = setTimeout(() => {
+                emotionService.getEmotionStats('week').then(setEmotionStats);
+            }, 1000);
+        };
+
+        const unsubscribe = subscribeTeamEvents(user?.team, {
+            onSnapshot: (snapshot) => {
+                setCheckinsToday(snapshot.today);
+                refreshStats();
+            },
+            onCheckins: (data) => {
+                setCheckinsToday(data.today);
+                refreshStats();
+            },
+            onMeetingStarted: (data) => {
+                setLiveMeetings(prev => [...prev.filter(m => m.meetingId !== data.meetingId), data]);
+            },
+            onMeetingEnded: (data) => {
+                setLiveMeetings(prev => prev.filter(m => m.meetingId !== data.meetingId));
+            }
+        });
+
+        return () => {
+            clearTimeout(statsRefreshTimer.current);
+            unsubscribe();
+        };
+    }, [user?.team]);
+
     const handleMoodSubmit = async () => {
         if (!mood) {
             setSubmitMessage({ text: 'Silakan pilih mood terlebih dahulu', type: 'error' });
@@ -68,20 +134,40 @@ function Dashboard() {
                 throw new Error('Data pengguna tidak valid');
             }
 
-            // Simpan data emosi ke database
-            await emotionService.saveEmotion({
+            if (!idempotencyKey.current) {
+                idempotencyKey.current = crypto.randomUUID();
+            }
+
+            // Simpan data emosi ke database; check-in anonim tidak menyimpan nama dan catatan
+            const result = await emotionService.saveEmotion({
                 user_id: user.id,
-                user_name: user.nama || 'Anonymous User',
+                user_name: anonymous ? '' : (user.nama || 'Anonymous User'),
                 mood: mood,
-                note: moodNote
-            });
+                note: anonymous ? '' : moodNote,
+                ...ratings,
+                // Tag tidak disimpan untuk check-in anonim
+                tags: anonymous ? [] : tagInput.split(',').map(tag => tag.trim()).filter(Boolean),
+                anonymous
+            }, idempotencyKey.current);
+            idempotencyKey.current = null;
 
             // Tampilkan pesan sukses
-            setSubmitMessage({ text: 'Mood Anda telah dicatat!', type: 'success' });
+            let successText = 'Mood Anda telah dicatat!';
+            if (anonymous) {
+                successText = 'Mood Anda telah dicatat secara anonim!';
+            } else if (result?.updated) {
+                successText = 'Check-in Anda hari ini telah diperbarui!';
+            }
+            setSubmitMessage({
+                text: successText,
+                type: 'success'
+            });
             
             // Bersihkan form
             setMood(null);
             setMoodNote('');
+            setRatings({});
+            setTagInput('');
             
             // Refresh statistik emosi
             const newStats = await emotionService.getEmotionStats('week');
@@ -89,7 +175,7 @@ function Dashboard() {
         } catch (error) {
             console.error('Error menyimpan mood:', error);
             setSubmitMessage({ 
-                text: 'Gagal mencatat mood Anda. Silakan coba lagi.', 
+                text: error.response?.data?.error?.message || 'Gagal mencatat mood Anda. Silakan coba lagi.', 
                 type: 'error' 
             });
         } finally {
@@ -144,48 +230,64 @@ function Dashboard() {
                                 </div>
                             )}
                             <div className="mood-selection">
-                                <div 
-                                    className={`mood-option ${mood === 'happy' ? 'selected' : ''}`} 
-                                    onClick={() => setMood('happy')}
-                                >
-                                    <span className="mood-emoji">😊</span>
-                                    <span>Senang</span>
-                                </div>
-                                <div 
-                                    className={`mood-option ${mood === 'neutral' ? 'selected' : ''}`} 
-                                    onClick={() => setMood('neutral')}
-                                >
-                                    <span className="mood-emoji">😐</span>
-                                    <span>Netral</span>
-                                </div>
-                                <div 
-                                    className={`mood-option ${mood === 'tired' ? 'selected' : ''}`} 
-                                    onClick={() => setMood('tired')}
-                                >
-                                    <span className="mood-emoji">😔</span>
-                                    <span>Lelah</span>
-                                </div>
-                                <div 
-                                    className={`mood-option ${mood === 'stressed' ? 'selected' : ''}`} 
-                                    onClick={() => setMood('stressed')}
-                                >
-                                    <span className="mood-emoji">😠</span>
-                                    <span>Stres</span>
-                                </div>
-                                <div 
-                                    className={`mood-option ${mood === 'excited' ? 'selected' : ''}`} 
-                                    onClick={() => setMood('excited')}
-                                >
-                                    <span className="mood-emoji">😃</span>
-                                    <span>Bersemangat</span>
-                                </div>
+                                {moods.map((option) => (
+                                    <div
+                                        key={option.key}
+                                        className={`mood-option ${mood === option.key ? 'selected' : ''}`}
+                                        onClick={() => setMood(option.key)}
+                                    >
+                                        <span className="mood-emoji">{option.emoji}</span>
+                                        <span>{option.label}</span>
+                                    </div>
+                                ))}
                             </div>
                             <textarea 
                                 className="mood-note" 
-                                placeholder="Tambahkan catatan tentang perasaan Anda..." 
-                                value={moodNote}
+                                placeholder={anonymous ? 'Catatan tidak disimpan untuk check-in anonim' : 'Tambahkan catatan tentang perasaan Anda...'} 
+                                value={anonymous ? '' : moodNote}
                                 onChange={(e) => setMoodNote(e.target.value)}
+                                disabled={anonymous}
                             ></textarea>
+                            <div className="dimension-ratings">
+                                {DIMENSIONS.map(dimension => (
+                                    <label key={dimension.key} className="dimension-rating">
+                                        {dimension.label}
+                                        <select
+                                            value={ratings[dimension.key] || ''}
+                                            onChange={(e) => setRatings(prev => {
+                                                const next = { ...prev };
+                                                if (e.target.value) {
+                                                    next[dimension.key] = Number(e.target.value);
+                                                } else {
+                                                    delete next[dimension.key];
+                                                }
+                                                return next;
+                                            })}
+                                        >
+                                            <option value="">-</option>
+                                            {[1, 2, 3, 4, 5].map(value => (
+                                                <option key={value} value={value}>{value}</option>
+                                            ))}
+                                        </select>
+                                    </label>
+                                ))}
+                            </div>
+                            <input
+                                type="text"
+                                className="mood-tags"
+                                placeholder={anonymous ? 'Tag tidak disimpan untuk check-in anonim' : 'Tag, pisahkan dengan koma (mis. deadline, meeting)'}
+                                value={anonymous ? '' : tagInput}
+                                onChange={(e) => setTagInput(e.target.value)}
+                                disabled={anonymous}
+                            />
+                            <label className="anonymous-toggle">
+                                <input
+                                    type="checkbox"
+                                    checked={anonymous}
+                                    onChange={(e) => setAnonymous(e.target.checked)}
+                                />
+                                Kirim secara anonim (tidak terhubung ke nama Anda, tetap dihitung di statistik tim)
+                            </label>
                             <button 
                                 className="submit-button" 
                                 onClick={handleMoodSubmit}
@@ -201,7 +303,10 @@ function Dashboard() {
                         <div className="dashboard-card team-emotion">
                             <div className="card-header">
                                 <h3>Peta Emosi Tim</h3>
-                                <span className="sub-title">Gambaran emosional tim hari ini</span>
+                                <span className="sub-title">
+                                    Gambaran emosional tim hari ini
+                                    {checkinsToday !== null && ` · ${checkinsToday} check-in hari ini`}
+                                </span>
                             </div>
                             <div className="emotion-chart">
                                 {loadingStats ? (
@@ -226,6 +331,19 @@ function Dashboard() {
                                 <Link to="/meetings" className="view-all">View All</Link>
                             </div>
                             <div className="meetings-list">
+                                {liveMeetings.map(meeting => (
+                                    <div className="meeting-item live" key={meeting.meetingId}>
+                                        <div className="meeting-time">
+                                            <div className="time">Live</div>
+                                            <div className="day">Now</div>
+                                        </div>
+                                        <div className="meeting-details">
+                                            <h4>{meeting.meetingId}</h4>
+                                            <p>Started by {meeting.byName}</p>
+                                        </div>
+                                        <button className="btn-join" onClick={() => navigate(meeting.link)}>Join</button>
+                                    </div>
+                                ))}
                                 <div className="meeting-item">
                                     <div className="meeting-time">
                                         <div className="time">10:00 AM</div>
@@ -36,12 +36,12 @@ function EmotionChart({ emotionStats }) {
     
     // Siapkan data grafik
     const chartData = {
-        labels: emotionStats.map(stat => `${getEmotionEmoji(stat.mood)} ${stat.mood}`),
+        labels: emotionStats.map(stat => `${stat.emoji || getEmotionEmoji(stat.mood)} ${stat.label || stat.mood}`),
         datasets: [
             {
                 label: 'Emosi Tim',
                 data: emotionStats.map(stat => stat.count),
-                backgroundColor: emotionStats.map(stat => emotionColors[stat.mood] || 'rgba(201, 203, 207, 0.8)'),
+                backgroundColor: emotionStats.map(stat => stat.color || emotionColors[stat.mood] || 'rgba(201, 203, 207, 0.8)'),
                 borderWidth: 1,
             },
         ],
@@ -63,7 +63,7 @@ function EmotionChart({ emotionStats }) {
                     title: (context) => {
                         const index = context[0].dataIndex;
                         if (index >= 0 && index < emotionStats.length) {
-                            const emotion = emotionStats[index].mood;
+                            const emotion = emotionStats[index].label || emotionStats[index].mood;
                             return emotion.charAt(0).toUpperCase() + emotion.slice(1);
                         }
                         return '';
@@ -2,6 +2,7 @@ import { useState, useEffect, useContext } from 'react';
 import { AuthContext } from '../context/AuthContext';
 import Sidebar from './Sidebar';
 import * as emotionService from '../services/emotionService';
+import { insightsService } from '../services/insightsService';
 import '../styles/Insights.css';
 
 function Insights() {
@@ -24,6 +25,10 @@ function Insights() {
     const [weeklyMood, setWeeklyMood] = useState([]);
     const [isLoadingMood, setIsLoadingMood] = useState(true);
 
+    // Personal insights dari check-in milik user sendiri
+    const [myInsights, setMyInsights] = useState(null);
+    const [myInsightsError, setMyInsightsError] = useState('');
+
     // Convert selected period to API parameter
     const getPeriodParam = () => {
         switch(selectedPeriod) {
@@ -34,6 +39,28 @@ function Insights() {
         }
     };
 
+    const getPeriodDays = () => {
+        switch(selectedPeriod) {
+            case 'Last 30 Days': return 30;
+            case 'Last 3 Months': return 90;
+            default: return 7;
+        }
+    };
+
+    useEffect(() => {
+        const loadMyInsights = async () => {
+            try {
+                setMyInsightsError('');
+                setMyInsights(await insightsService.getMyInsights(getPeriodDays()));
+            } catch (err) {
+                console.error('Error loading personal insights:', err);
+                setMyInsightsError('Gagal memuat insight pribadi.');
+            }
+        };
+
+        loadMyInsights();
+    }, [selectedPeriod]);
+
     // Load data based on selected period and team
     useEffect(() => {
         const loadInsightsData = async () => {
@@ -53,8 +80,9 @@ function Insights() {
                 // Calculate mock metrics based on real mood data
                 if (weeklyData.length > 0) {
                     const totalEmotions = weeklyData.reduce((sum, item) => sum + item.count, 0);
+                    // Valence dari definisi mood menentukan mana yang positif
                     const positiveEmotions = weeklyData
-                        .filter(e => ['happy', 'very_happy', 'excited'].includes(e.mood))
+                        .filter(e => e.valence !== undefined ? e.valence > 0 : ['happy', 'very_happy', 'excited'].includes(e.mood))
                         .reduce((sum, item) => sum + item.count, 0);
                     
                     const happinessValue = totalEmotions > 0 
@@ -172,6 +200,34 @@ function Insights() {
         });
     };
     
+    const weekdayNames = ['Minggu', 'Senin', 'Selasa', 'Rabu', 'Kamis', 'Jumat', 'Sabtu'];
+
+    // Valence (-1..1) ditampilkan sebagai skor 0..100
+    const formatScore = (valence) => {
+        if (valence === null || valence === undefined) return '–';
+        return Math.round((valence + 1) * 50);
+    };
+
+    const formatDay = (day) => new Date(`${day}T00:00:00`).toLocaleDateString('id-ID', {
+        weekday: 'short',
+        day: 'numeric',
+        month: 'short'
+    });
+
+    // Slot dengan rata-rata tertinggi, abaikan slot tanpa check-in
+    const bestSlot = (slots) => {
+        const scored = (slots || []).filter(s => s.average !== null);
+        if (scored.length === 0) return null;
+        return scored.reduce((best, s) => (s.average > best.average ? s : best));
+    };
+
+    const describeCorrelation = (r) => {
+        if (r === null || r === undefined) return 'Belum cukup data untuk melihat hubungan mood dengan meeting.';
+        if (r <= -0.3) return 'Mood kamu cenderung lebih rendah di hari dengan banyak meeting.';
+        if (r >= 0.3) return 'Mood kamu cenderung lebih baik di hari dengan banyak meeting.';
+        return 'Jumlah meeting tidak banyak memengaruhi mood kamu.';
+    };
+
     const generateInsight = (moodData) => {
         const groupedMoods = groupMoodsByCategory(moodData);
         const totalMembers = moodData.reduce((sum, item) => sum + item.count, 0);
@@ -336,7 +392,92 @@ function Insights() {
                             )}
                         </div>
 
-                        
+                        {/* My Insights */}
+                        <div className="my-insights">
+                            <div className="overview-header">
+                                <h3>My Insights</h3>
+                                {myInsights && (
+                                    <span className="overview-date">{myInsights.checkins} check-in</span>
+                                )}
+                            </div>
+
+                            {myInsightsError ? (
+                                <div className="chart-no-data">{myInsightsError}</div>
+                            ) : !myInsights ? (
+                                <div className="loading-overlay">
+                                    <div className="loading-spinner"></div>
+                                    <p>Loading insights...</p>
+                                </div>
+                            ) : myInsights.checkins === 0 ? (
+                                <div className="chart-no-data">
+                                    Belum ada check-in pada periode ini
+                                </div>
+                            ) : (
+                                <>
+                                    <div className="my-insights-stats">
+                                        <div className="my-insights-stat">
+                                            <div className="stat-label">Streak saat ini</div>
+                                            <div className="stat-value">{myInsights.streaks.current} hari</div>
+                                            <div className="stat-note">Terpanjang: {myInsights.streaks.longest} hari</div>
+                                        </div>
+                                        <div className="my-insights-stat">
+                                            <div className="stat-label">Rata-rata mood</div>
+                                            <div className="stat-value">{formatScore(myInsights.average)}</div>
+                                            {myInsights.monthChange.change !== null && (
+                                                <div className={`stat-note ${myInsights.monthChange.change >= 0 ? 'trend-up' : 'trend-down'}`}>
+                                                    {myInsights.monthChange.change >= 0 ? '▲' : '▼'} {Math.abs(Math.round(myInsights.monthChange.change * 50))} vs. bulan lalu
+                                                </div>
+                                            )}
+                                        </div>
+                                        <div className="my-insights-stat">
+                                            <div className="stat-label">Hari terbaik</div>
+                                            <div className="stat-value">
+                                                {bestSlot(myInsights.weekdays) ? weekdayNames[bestSlot(myInsights.weekdays).slot] : '–'}
+                                            </div>
+                                            <div className="stat-note">
+                                                {bestSlot(myInsights.hours) ? `Jam terbaik: ${String(bestSlot(myInsights.hours).slot).padStart(2, '0')}:00` : ''}
+                                            </div>
+                                        </div>
+                                    </div>
+
+                                    <div className="my-insights-lists">
+                                        <div>
+                                            <h4>Hari terbaik</h4>
+                                            {myInsights.bestDays.length > 0 ? myInsights.bestDays.map(d => (
+                                                <div className="my-insights-day" key={d.day}>
+                                                    <span>{formatDay(d.day)}</span>
+                                                    <span className="trend-up">{formatScore(d.average)}</span>
+                                                </div>
+                                            )) : <p className="stat-note">Belum cukup data</p>}
+                                        </div>
+                                        <div>
+                                            <h4>Hari terberat</h4>
+                                            {myInsights.worstDays.length > 0 ? myInsights.worstDays.map(d => (
+                                                <div className="my-insights-day" key={d.day}>
+                                                    <span>{formatDay(d.day)}</span>
+                                                    <span className="trend-down">{formatScore(d.average)}</span>
+                                                </div>
+                                            )) : <p className="stat-note">Belum cukup data</p>}
+                                        </div>
+                                        <div>
+                                            <h4>Tag terbanyak</h4>
+                                            {myInsights.tags.length > 0 ? (
+                                                <div className="my-insights-tags">
+                                                    {myInsights.tags.map(t => (
+                                                        <span className="my-insights-tag" key={t.tag}>#{t.tag} <small>{t.count}</small></span>
+                                                    ))}
+                                                </div>
+                                            ) : <p className="stat-note">Belum ada tag</p>}
+                                        </div>
+                                    </div>
+
+                                    <div className="insight-card">
+                                        <h4><i className="fas fa-video"></i> Mood &amp; Meeting</h4>
+                                        <p>{describeCorrelation(myInsights.meetings.correlation)}</p>
+                                    </div>
+                                </>
+                            )}
+                        </div>
                     </div>
                 )}
             </div>
@@ -2,8 +2,13 @@ import React, { useState, useEffect, useRef, useContext } from 'react';
 import { useNavigate, useParams } from 'react-router-dom';
 import { AuthContext } from '../context/AuthContext';
 import * as faceapi from 'face-api.js';
+import { getUsers } from '../services/userService';
+import { mentionInMeeting } from '../services/notificationService';
+import { startMeeting, endMeeting } from '../services/teamEventService';
 import '../styles/MeetingRoom.css';
 
+const escapeRegExp = (text) => text.replace(/[.*+?^${}()|[\]\\]/g, '\\$&');
+
 function MeetingRoom() {
     const { user } = useContext(AuthContext);
     const navigate = useNavigate();
@@ -22,6 +27,7 @@ function MeetingRoom() {
     const [lastEmotion, setLastEmotion] = useState(null);
     const [isLeaving, setIsLeaving] = useState(false); // State baru untuk animasi ketika leaving
     const [waitingForOthers, setWaitingForOthers] = useState(true); // State untuk menunjukkan menunggu peserta lain
+    const [teamMembers, setTeamMembers] = useState([]); // Untuk mengenali @mention di chat
 
     // Tambahkan ref untuk video element yang di-pin
     const videoRef = useRef();
@@ -357,6 +363,34 @@ function MeetingRoom() {
         }
     };
 
+    // Beri tahu tim bahwa meeting dimulai, dan berakhir saat halaman ditinggalkan
+    useEffect(() => {
+        startMeeting(meetingId).catch(error => console.error('Error announcing meeting:', error));
+        return () => {
+            endMeeting(meetingId).catch(error => console.error('Error announcing meeting end:', error));
+        };
+    }, [meetingId]);
+
+    // Muat anggota tim sekali untuk mengenali @NamaDepan di chat
+    useEffect(() => {
+        getUsers({ limit: 100, team: user?.team || undefined })
+            .then(page => setTeamMembers(page.data))
+            .catch(error => console.error('Error loading team members:', error));
+    }, [user]);
+
+    // Kirim notifikasi ke anggota yang disebut dengan @NamaDepan
+    const notifyMentions = (text) => {
+        const mentioned = teamMembers.filter(member => {
+            const firstName = member.nama?.split(' ')[0];
+            return firstName && member.id !== user?.id &&
+                new RegExp(`@${escapeRegExp(firstName)}\\b`, 'i').test(text);
+        });
+        if (mentioned.length > 0) {
+            mentionInMeeting(meetingId, mentioned.map(member => member.id), text)
+                .catch(error => console.error('Error sending mentions:', error));
+        }
+    };
+
     const sendMessage = (e) => {
         e.preventDefault();
         if (!newMessage.trim()) return;
@@ -370,6 +404,7 @@ function MeetingRoom() {
 
         setMessages(prev => [...prev, message]);
         setNewMessage('');
+        notifyMentions(message.text);
     };
 
     const copyMeetingLink = () => {
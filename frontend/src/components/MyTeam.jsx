@@ -2,6 +2,8 @@ import { useState, useEffect, useContext } from 'react';
 import { AuthContext } from '../context/AuthContext';
 import Sidebar from './Sidebar';
 import { getUsers } from '../services/userService';
+import { subscribePresence } from '../services/presenceService';
+import { getAlerts, acknowledgeAlert, resolveAlert } from '../services/alertService';
 import '../styles/MyTeam.css';
 
 function MyTeam() {
@@ -11,6 +13,14 @@ function MyTeam() {
     const [error, setError] = useState(null);
     const [searchTerm, setSearchTerm] = useState('');
     const [selectedRole, setSelectedRole] = useState('All Roles');
+    const [presence, setPresence] = useState({});
+    const [nextCursor, setNextCursor] = useState(null);
+    const [total, setTotal] = useState(0);
+    const [loadingMore, setLoadingMore] = useState(false);
+    const [alerts, setAlerts] = useState([]);
+    const isLeader = user?.role === 'Team Leader';
+    // Notifications link here with ?alert=<id>
+    const highlightedAlert = new URLSearchParams(window.location.search).get('alert');
 
     
     
@@ -20,52 +30,117 @@ function MyTeam() {
         return name.charAt(0).toUpperCase();
     };
     
-    // Fetch users from database
+    const PAGE_SIZE = 24;
+
+    // Search and role filtering happen on the server so large organizations page quickly
+    const queryParams = () => ({
+        limit: PAGE_SIZE,
+        q: searchTerm.trim() || undefined,
+        role: selectedRole === 'All Roles' ? undefined : selectedRole
+    });
+
+    // Fetch the first page whenever the filters change, debounced while typing
     useEffect(() => {
-        const fetchTeamMembers = async () => {
+        let cancelled = false;
+        const timer = setTimeout(async () => {
             try {
                 setLoading(true);
-                console.log("Fetching team members...");
-                const response = await getUsers();
-                console.log("Raw response:", response);
-
-                // Format response untuk menangani URL gambar
-                const formattedMembers = response.map(member => {
-                    console.log("Processing member:", member.nama, "Profile image:", member.profileImage);
-                    
-                    // Pastikan profileImage memiliki URL lengkap jika ada
-                    if (member.profileImage) {
-                        if (!member.profileImage.startsWith('http')) {
-                            // Pastikan tidak ada duplikat slash
-                            const baseUrl = API_URL.endsWith('/') ? API_URL.slice(0, -1) : API_URL;
-                            const imagePath = member.profileImage.startsWith('/') ? member.profileImage : '/' + member.profileImage;
-                            
-                            member.profileImage = `${baseUrl}${imagePath}`;
-                        }
-                        console.log("Formatted image URL:", member.profileImage);
-                    } else {
-                        console.log("No profile image for this member");
-                    }
-                    
-                    return member;
-                });
-
-                console.log("Formatted members:", formattedMembers);
-                setTeamMembers(formattedMembers);
+                const { data, page } = await getUsers(queryParams());
+                if (cancelled) return;
+                setTeamMembers(data);
+                setNextCursor(page.nextCursor || null);
+                setTotal(page.total);
                 setError(null);
             } catch (err) {
                 console.error('Error fetching team members:', err);
-                setError('Failed to load team members. Please try again later.');
+                if (!cancelled) setError('Failed to load team members. Please try again later.');
             } finally {
-                setLoading(false);
+                if (!cancelled) setLoading(false);
             }
+        }, 300);
+
+        return () => {
+            cancelled = true;
+            clearTimeout(timer);
         };
+    }, [searchTerm, selectedRole]);
 
-        fetchTeamMembers();
+    const loadMore = async () => {
+        if (!nextCursor) return;
+        try {
+            setLoadingMore(true);
+            const { data, page } = await getUsers({ ...queryParams(), cursor: nextCursor });
+            setTeamMembers(prev => [...prev, ...data]);
+            setNextCursor(page.nextCursor || null);
+            setTotal(page.total);
+        } catch (err) {
+            console.error('Error fetching more team members:', err);
+            setError('Failed to load team members. Please try again later.');
+        } finally {
+            setLoadingMore(false);
+        }
+    };
+
+    // Live presence updates
+    useEffect(() => {
+        const unsubscribe = subscribePresence({
+            onSnapshot: (members) => {
+                setPresence(Object.fromEntries(members.map(m => [m.userId, m])));
+            },
+            onChange: (update) => {
+                setPresence(prev => ({ ...prev, [update.userId]: update }));
+            }
+        });
+        return unsubscribe;
     }, []);
 
-    // Get unique roles from team members
-    const roles = ['All Roles', ...new Set(teamMembers.map(member => member.role || 'Team Member').filter(Boolean))];
+    // Wellbeing alerts of the team that still need attention; resolved ones are left out
+    useEffect(() => {
+        if (!isLeader) return;
+        Promise.all([getAlerts({ status: 'open' }), getAlerts({ status: 'acknowledged' })])
+            .then(([open, acknowledged]) => setAlerts([...open.data, ...acknowledged.data]))
+            .catch(err => console.error('Error fetching alerts:', err));
+    }, [isLeader]);
+
+    const handleAlert = async (alert, action) => {
+        try {
+            const updated = action === 'resolve'
+                ? await resolveAlert(alert.id)
+                : await acknowledgeAlert(alert.id);
+            setAlerts(prev => updated.status === 'resolved'
+                ? prev.filter(a => a.id !== alert.id)
+                : prev.map(a => (a.id === alert.id ? updated : a)));
+        } catch (err) {
+            console.error(`Error trying to ${action} alert:`, err);
+        }
+    };
+
+    const alertRuleLabels = {
+        'negative_streak': (details) => `Low mood on ${details.days} check-in days in a row`,
+        'mood_drop': (details) => `Average mood dropped by ${details.drop} compared to the previous weeks`,
+        'missing_checkins': (details) => `No check-in for ${details.days} days`
+    };
+
+    const statusLabels = {
+        'online': 'Online',
+        'away': 'Away',
+        'offline': 'Offline',
+        'in-meeting': 'In a meeting'
+    };
+
+    // Presence from the live feed, falling back to what the user list returned
+    const getPresence = (member) => {
+        const live = presence[member.id] || {};
+        const status = live.status || member.status || 'offline';
+        const statusMessage = live.userId ? live.statusMessage : member.statusMessage;
+        const label = statusMessage?.text
+            ? `${statusLabels[status]} · ${statusMessage.emoji ? statusMessage.emoji + ' ' : ''}${statusMessage.text}`
+            : statusLabels[status];
+        const className = status === 'in-meeting' ? 'status-meeting' : `status-${status}`;
+        return { className, label };
+    };
+
+    const roles = ['All Roles', 'Team Member', 'Team Leader', 'Developer', 'Designer', 'Product Manager', 'QA Engineer'];
 
     // Get role icon based on role
     const getRoleIcon = (role) => {
@@ -80,17 +155,6 @@ function MyTeam() {
         return roleIcons[role] || 'fa-user';
     };
 
-    // Filter the team members based on search and filters
-    const filteredMembers = teamMembers.filter(member => {
-        const matchesSearch =
-            member.nama?.toLowerCase().includes(searchTerm.toLowerCase()) ||
-            member.email?.toLowerCase().includes(searchTerm.toLowerCase());
-
-        const matchesRole = selectedRole === 'All Roles' || member.role === selectedRole;
-
-        return matchesSearch && matchesRole;
-    });
-
     if (!user) {
         return (
             <div className="loading-container">
@@ -132,6 +196,37 @@ function MyTeam() {
                         <p>Manage and collaborate with your team members</p>
                     </div>
 
+                    {isLeader && alerts.length > 0 && (
+                        <div className="team-alerts">
+                            <h3><i className="fas fa-heartbeat"></i> Wellbeing Alerts</h3>
+                            {alerts.map(alert => (
+                                <div
+                                    key={alert.id}
+                                    className={`alert-item alert-${alert.status}${alert.id === highlightedAlert ? ' highlighted' : ''}`}
+                                >
+                                    <div className="alert-details">
+                                        <h4>{alert.scope === 'team' ? `Team ${alert.team}` : alert.userName}</h4>
+                                        <p>{alertRuleLabels[alert.rule]?.(alert.details || {}) || alert.rule}</p>
+                                        <span className="alert-meta">
+                                            Since {new Date(alert.createdAt).toLocaleDateString()}
+                                            {alert.status === 'acknowledged' && ' · Acknowledged'}
+                                        </span>
+                                    </div>
+                                    <div className="alert-actions">
+                                        {alert.status === 'open' && (
+                                            <button className="btn-acknowledge" onClick={() => handleAlert(alert, 'acknowledge')}>
+                                                Acknowledge
+                                            </button>
+                                        )}
+                                        <button className="btn-resolve" onClick={() => handleAlert(alert, 'resolve')}>
+                                            Resolve
+                                        </button>
+                                    </div>
+                                </div>
+                            ))}
+                        </div>
+                    )}
+
                     <div className="team-filters">
                         <div className="filter-group">
                             <label>Role:</label>
@@ -159,9 +254,12 @@ function MyTeam() {
                         </div>
                     ) : (
                         <div className="team-members-grid">
-                            {filteredMembers.length > 0 ? (
-                                filteredMembers.map(member => (
+                            {teamMembers.length > 0 ? (
+                                teamMembers.map(member => (
                                     <div key={member.id || member._id} className="member-card">
+                                        <div className={`member-status ${getPresence(member).className}`}>
+                                            <span className="status-tooltip">{getPresence(member).label}</span>
+                                        </div>
                                         <div className="member-avatar">
                                             {/* Kondisi untuk menampilkan foto atau inisial */}
                                             {member.profileImage ? (
@@ -214,6 +312,14 @@ function MyTeam() {
                         </div>
                     )}
 
+                    {!loading && !error && nextCursor && (
+                        <div className="team-load-more">
+                            <button className="btn-load-more" onClick={loadMore} disabled={loadingMore}>
+                                {loadingMore ? 'Loading...' : `Load more (${teamMembers.length} of ${total})`}
+                            </button>
+                        </div>
+                    )}
+
                     <div className="team-stats">
                         <div className="stats-card">
                             <div className="stats-icon">
@@ -221,7 +327,7 @@ function MyTeam() {
                             </div>
                             <div className="stats-info">
                                 <h3>Team Size</h3>
-                                <div className="stats-value">{teamMembers.length}</div>
+                                <div className="stats-value">{total}</div>
                             </div>
                         </div>
                     </div>
@@ -2,11 +2,29 @@ import { useState, useEffect, useContext } from 'react';
 import { useNavigate } from 'react-router-dom';
 import { AuthContext } from '../context/AuthContext';
 import Sidebar from './Sidebar';
-import { updateUser, uploadProfileImage } from '../services/userService';
+import {
+    updateUser,
+    uploadProfileImage,
+    exportMyData,
+    deleteMyAccount,
+    getReminderSettings,
+    updateReminderSettings,
+    snoozeReminder,
+    getPrivacyPreferences,
+    updatePrivacyPreferences
+} from '../services/userService';
 import '../styles/Settings.css';
 
+const WEEKDAYS = ['Sun', 'Mon', 'Tue', 'Wed', 'Thu', 'Fri', 'Sat'];
+
+const REMINDER_CHANNELS = [
+    { key: 'in_app', label: 'In-app notification' },
+    { key: 'email', label: 'Email' },
+    { key: 'webhook', label: 'Webhook' }
+];
+
 function Settings() {
-    const { user, loginUser } = useContext(AuthContext);
+    const { user, loginUser, logoutUser } = useContext(AuthContext);
     const navigate = useNavigate();
 
     const [loading, setLoading] = useState(false);
@@ -28,6 +46,15 @@ function Settings() {
     const [imageFile, setImageFile] = useState(null);
     const [previewUrl, setPreviewUrl] = useState('');
 
+    // Data & account
+    const [deletePassword, setDeletePassword] = useState('');
+
+    // Check-in reminders
+    const [reminder, setReminder] = useState(null);
+
+    // Privacy
+    const [anonymousMode, setAnonymousMode] = useState(false);
+
     // Load user data on component mount
     useEffect(() => {
         if (!user) {
@@ -43,6 +70,33 @@ function Settings() {
         setPreviewUrl(user.profileImage || '');
     }, [user, navigate]);
 
+    useEffect(() => {
+        if (!user) {
+            return;
+        }
+        getReminderSettings()
+            .then(settings => setReminder({
+                ...settings,
+                timezone: settings.timezone || Intl.DateTimeFormat().resolvedOptions().timeZone
+            }))
+            .catch(error => console.error('Error loading reminder settings:', error));
+        getPrivacyPreferences()
+            .then(preferences => setAnonymousMode(preferences.anonymousMode))
+            .catch(error => console.error('Error loading privacy preferences:', error));
+    }, [user]);
+
+    const handleAnonymousModeChange = async (enabled) => {
+        setAnonymousMode(enabled);
+        try {
+            await updatePrivacyPreferences({ anonymousMode: enabled });
+            setMessage({ text: 'Privacy preferences saved', type: 'success' });
+        } catch (error) {
+            console.error('Error saving privacy preferences:', error);
+            setAnonymousMode(!enabled);
+            setMessage({ text: error.response?.data?.error?.message || 'Failed to save privacy preferences', type: 'error' });
+        }
+    };
+
     const handleImageChange = (e) => {
         if (!e.target.files || e.target.files.length === 0) {
             return;
@@ -52,14 +106,14 @@ function Settings() {
         console.log("Selected file:", file);
 
         // Validasi tipe dan ukuran file
-        if (file.size > 5000000) { // 5MB max
-            setMessage({ text: 'File too large (max 5MB)', type: 'error' });
+        if (file.size > 3 * 1024 * 1024) { // 3MB max, matches AVATAR_MAX_BYTES
+            setMessage({ text: 'File too large (max 3MB)', type: 'error' });
             return;
         }
 
-        const allowedTypes = ['image/jpeg', 'image/png', 'image/gif'];
+        const allowedTypes = ['image/jpeg', 'image/png', 'image/webp'];
         if (!allowedTypes.includes(file.type)) {
-            setMessage({ text: 'Invalid file type. Only JPG, PNG and GIF allowed', type: 'error' });
+            setMessage({ text: 'Invalid file type. Only JPG, PNG and WebP allowed', type: 'error' });
             return;
         }
 
@@ -104,9 +158,7 @@ function Settings() {
                     const updatedData = {
                         nama: fullName,
                         email,
-                        role,
-                        bio,
-                        profileImage: imageUrl
+                        bio
                     };
 
                     console.log("Updating user with data:", updatedData);
@@ -116,7 +168,7 @@ function Settings() {
                         console.log("Update result:", result);
 
                         // Update context
-                        loginUser({ ...user, ...updatedData });
+                        loginUser({ ...user, ...updatedData, profileImage: imageUrl, profileImages: uploadResult.images });
 
                         setMessage({ text: 'Profile updated successfully', type: 'success' });
 
@@ -126,11 +178,11 @@ function Settings() {
                         }, 2000);
                     } catch (updateError) {
                         console.error("Update failed:", updateError);
-                        throw new Error("Failed to update profile: " + (updateError.response?.data?.error || updateError.message));
+                        throw new Error("Failed to update profile: " + (updateError.response?.data?.error?.message || updateError.message));
                     }
                 } catch (uploadError) {
                     console.error("Image upload failed:", uploadError);
-                    throw new Error("Failed to upload profile image: " + (uploadError.response?.data?.error || uploadError.message));
+                    throw new Error("Failed to upload profile image: " + (uploadError.response?.data?.error?.message || uploadError.message));
                 }
             }
 
@@ -166,12 +218,89 @@ function Settings() {
             setMessage({ text: 'Password updated successfully', type: 'success' });
         } catch (error) {
             console.error('Error updating password:', error);
-            setMessage({ text: error.response?.data?.error || 'Failed to update password', type: 'error' });
+            setMessage({ text: error.response?.data?.error?.message || 'Failed to update password', type: 'error' });
         } finally {
             setLoading(false);
         }
     };
 
+    const toggleReminderList = (field, value) => {
+        setReminder(prev => {
+            const list = prev[field] || [];
+            return {
+                ...prev,
+                [field]: list.includes(value) ? list.filter(item => item !== value) : [...list, value]
+            };
+        });
+    };
+
+    const handleReminderUpdate = async (e) => {
+        e.preventDefault();
+        setLoading(true);
+        try {
+            const saved = await updateReminderSettings({
+                enabled: reminder.enabled,
+                time: reminder.time,
+                timezone: reminder.timezone,
+                workdays: reminder.workdays,
+                channels: reminder.channels,
+                webhookUrl: reminder.webhookUrl || '',
+                quietHours: reminder.quietHours?.start && reminder.quietHours?.end ? reminder.quietHours : null,
+                locale: reminder.locale || ''
+            });
+            setReminder(saved);
+            setMessage({ text: 'Reminder settings saved', type: 'success' });
+        } catch (error) {
+            console.error('Error saving reminder settings:', error);
+            setMessage({ text: error.response?.data?.error?.message || 'Failed to save reminder settings', type: 'error' });
+        } finally {
+            setLoading(false);
+        }
+    };
+
+    const handleSnooze = async (minutes) => {
+        try {
+            setReminder(await snoozeReminder(minutes));
+            setMessage({ text: 'Reminders snoozed', type: 'success' });
+        } catch (error) {
+            console.error('Error snoozing reminders:', error);
+            setMessage({ text: error.response?.data?.error?.message || 'Failed to snooze reminders', type: 'error' });
+        }
+    };
+
+    const handleExport = async () => {
+        setLoading(true);
+        try {
+            await exportMyData();
+        } catch (error) {
+            console.error('Error exporting data:', error);
+            setMessage({ text: 'Failed to export your data', type: 'error' });
+        } finally {
+            setLoading(false);
+        }
+    };
+
+    const handleDeleteAccount = async (e) => {
+        e.preventDefault();
+
+        if (!window.confirm('Delete your account? You can restore it by logging in again before it is permanently deleted.')) {
+            return;
+        }
+
+        setLoading(true);
+        try {
+            const result = await deleteMyAccount(deletePassword);
+            const purgeDate = new Date(result.purgeAt).toLocaleDateString();
+            alert(`Your account will be permanently deleted on ${purgeDate}. Log in before then to keep it.`);
+            logoutUser();
+            navigate('/login');
+        } catch (error) {
+            console.error('Error deleting account:', error);
+            setMessage({ text: error.response?.data?.error?.message || 'Failed to delete account', type: 'error' });
+            setLoading(false);
+        }
+    };
+
     if (!user) {
         return (
             <div className="loading-container">
@@ -225,7 +354,7 @@ function Settings() {
                                         <input
                                             type="file"
                                             onChange={handleImageChange}
-                                            accept="image/*"
+                                            accept="image/jpeg,image/png,image/webp"
                                             style={{ display: 'none' }}
                                         />
                                     </label>
@@ -282,17 +411,15 @@ function Settings() {
 
                                 <div className="form-group">
                                     <label htmlFor="role">Role</label>
-                                    <select
+                                    {/* Peran dan tim hanya bisa diubah oleh admin */}
+                                    <input
+                                        type="text"
                                         id="role"
-                                        value={role}
-                                        onChange={(e) => setRole(e.target.value)}
-                                    >
-                                        <option value="Team Member">Team Member</option>
-                                        <option value="Team Leader">Team Leader</option>
-                                        <option value="Developer">Developer</option>
-                                        <option value="Designer">Designer</option>
-                                        <option value="Product Manager">Product Manager</option>
-                                    </select>
+                                        value={user?.team ? `${role} · ${user.team}` : role}
+                                        title="Roles and teams are assigned by an admin"
+                                        readOnly
+                                        disabled
+                                    />
                                 </div>
                             </div>
 
@@ -363,6 +490,181 @@ function Settings() {
                             </div>
                         </form>
                     </div>
+
+                    {/* Check-in Reminders */}
+                    {reminder && (
+                        <div className="settings-section">
+                            <h3>Check-in Reminders</h3>
+                            <p className="settings-description">Get a reminder on working days when you haven't checked in yet</p>
+
+                            <form className="settings-form" onSubmit={handleReminderUpdate}>
+                                <div className="form-group">
+                                    <label className="checkbox-label">
+                                        <input
+                                            type="checkbox"
+                                            checked={reminder.enabled}
+                                            onChange={(e) => setReminder({ ...reminder, enabled: e.target.checked })}
+                                        />
+                                        Send me check-in reminders
+                                    </label>
+                                </div>
+
+                                <div className="form-row">
+                                    <div className="form-group">
+                                        <label htmlFor="reminderTime">Remind me at</label>
+                                        <input
+                                            type="time"
+                                            id="reminderTime"
+                                            value={reminder.time}
+                                            onChange={(e) => setReminder({ ...reminder, time: e.target.value })}
+                                            required
+                                        />
+                                    </div>
+
+                                    <div className="form-group">
+                                        <label htmlFor="reminderTimezone">Time zone</label>
+                                        <input
+                                            type="text"
+                                            id="reminderTimezone"
+                                            value={reminder.timezone}
+                                            onChange={(e) => setReminder({ ...reminder, timezone: e.target.value })}
+                                            required
+                                        />
+                                    </div>
+                                </div>
+
+                                <div className="form-group">
+                                    <label>Working days</label>
+                                    <div className="checkbox-group">
+                                        {WEEKDAYS.map((day, index) => (
+                                            <label key={day} className="checkbox-label">
+                                                <input
+                                                    type="checkbox"
+                                                    checked={reminder.workdays.includes(index)}
+                                                    onChange={() => toggleReminderList('workdays', index)}
+                                                />
+                                                {day}
+                                            </label>
+                                        ))}
+                                    </div>
+                                </div>
+
+                                <div className="form-group">
+                                    <label>Send through</label>
+                                    <div className="checkbox-group">
+                                        {REMINDER_CHANNELS.map(channel => (
+                                            <label key={channel.key} className="checkbox-label">
+                                                <input
+                                                    type="checkbox"
+                                                    checked={reminder.channels.includes(channel.key)}
+                                                    onChange={() => toggleReminderList('channels', channel.key)}
+                                                />
+                                                {channel.label}
+                                            </label>
+                                        ))}
+                                    </div>
+                                </div>
+
+                                {reminder.channels.includes('webhook') && (
+                                    <div className="form-group">
+                                        <label htmlFor="reminderWebhook">Webhook URL</label>
+                                        <input
+                                            type="url"
+                                            id="reminderWebhook"
+                                            value={reminder.webhookUrl || ''}
+                                            onChange={(e) => setReminder({ ...reminder, webhookUrl: e.target.value })}
+                                            required
+                                        />
+                                    </div>
+                                )}
+
+                                <div className="form-row">
+                                    <div className="form-group">
+                                        <label htmlFor="quietStart">Quiet hours from</label>
+                                        <input
+                                            type="time"
+                                            id="quietStart"
+                                            value={reminder.quietHours?.start || ''}
+                                            onChange={(e) => setReminder({ ...reminder, quietHours: { ...reminder.quietHours, start: e.target.value } })}
+                                        />
+                                    </div>
+
+                                    <div className="form-group">
+                                        <label htmlFor="quietEnd">Quiet hours until</label>
+                                        <input
+                                            type="time"
+                                            id="quietEnd"
+                                            value={reminder.quietHours?.end || ''}
+                                            onChange={(e) => setReminder({ ...reminder, quietHours: { ...reminder.quietHours, end: e.target.value } })}
+                                        />
+                                    </div>
+                                </div>
+
+                                {reminder.snoozedUntil && new Date(reminder.snoozedUntil) > new Date() && (
+                                    <p className="settings-description">
+                                        Snoozed until {new Date(reminder.snoozedUntil).toLocaleString()}
+                                    </p>
+                                )}
+
+                                <div className="form-actions">
+                                    <button type="button" className="btn-snooze" onClick={() => handleSnooze(60)} disabled={loading || !reminder.enabled}>
+                                        Snooze 1 hour
+                                    </button>
+                                    <button type="submit" className="btn-update" disabled={loading}>
+                                        {loading ? 'Saving...' : 'Save Reminders'}
+                                    </button>
+                                </div>
+                            </form>
+                        </div>
+                    )}
+
+                    {/* Privacy */}
+                    <div className="settings-section">
+                        <h3>Privacy</h3>
+                        <p className="settings-description">Anonymous check-ins are never linked back to you, so the app can't tell whether you checked in</p>
+
+                        <div className="form-group">
+                            <label className="checkbox-label">
+                                <input
+                                    type="checkbox"
+                                    checked={anonymousMode}
+                                    onChange={(e) => handleAnonymousModeChange(e.target.checked)}
+                                />
+                                I check in anonymously: start check-ins as anonymous and don't alert my team leader when my named check-ins stop
+                            </label>
+                        </div>
+                    </div>
+
+                    {/* Your Data */}
+                    <div className="settings-section">
+                        <h3>Your Data</h3>
+                        <p className="settings-description">Download a copy of your profile, check-ins and images, or delete your account</p>
+
+                        <div className="form-actions form-actions-start">
+                            <button type="button" className="btn-update" onClick={handleExport} disabled={loading}>
+                                Download My Data
+                            </button>
+                        </div>
+
+                        <form className="settings-form" onSubmit={handleDeleteAccount}>
+                            <div className="form-group">
+                                <label htmlFor="deletePassword">Confirm with your password</label>
+                                <input
+                                    type="password"
+                                    id="deletePassword"
+                                    value={deletePassword}
+                                    onChange={(e) => setDeletePassword(e.target.value)}
+                                    required
+                                />
+                            </div>
+
+                            <div className="form-actions">
+                                <button type="submit" className="btn-remove btn-delete-account" disabled={loading}>
+                                    Delete Account
+                                </button>
+                            </div>
+                        </form>
+                    </div>
                 </div>
             </div>
         </div>
@@ -1,6 +1,12 @@
-import { useContext, useState } from 'react';
+import { useContext, useEffect, useState } from 'react';
 import { Link, useLocation, useNavigate } from 'react-router-dom';
 import { AuthContext } from '../context/AuthContext';
+import {
+    getNotifications,
+    markNotificationRead,
+    markAllNotificationsRead,
+    subscribeNotifications
+} from '../services/notificationService';
 import '../styles/Sidebar.css';
 
 function Sidebar() {
@@ -8,6 +14,35 @@ function Sidebar() {
     const location = useLocation();
     const navigate = useNavigate();
     const [showLogout, setShowLogout] = useState(false);
+    const [notifications, setNotifications] = useState([]);
+    const [unread, setUnread] = useState(0);
+    const [showNotifications, setShowNotifications] = useState(false);
+
+    // Keep the badge live; reload the list whenever the feed (re)connects
+    useEffect(() => {
+        if (!user) return;
+
+        const load = () => {
+            getNotifications()
+                .then(page => {
+                    setNotifications(page.data);
+                    setUnread(page.unread);
+                })
+                .catch(error => console.error('Error loading notifications:', error));
+        };
+
+        return subscribeNotifications({
+            onSnapshot: load,
+            onNotification: (notification, count) => {
+                setNotifications(prev => [notification, ...prev].slice(0, 10));
+                setUnread(count);
+            },
+            onRead: (ids, count) => {
+                setNotifications(prev => prev.map(n => (ids.length === 0 || ids.includes(n.id) ? { ...n, read: true } : n)));
+                setUnread(count);
+            }
+        });
+    }, [user]);
 
     // Determine active route
     const isActive = (path) => {
@@ -28,6 +63,32 @@ function Sidebar() {
         setShowLogout(!showLogout);
     };
 
+    const openNotification = async (notification) => {
+        setShowNotifications(false);
+        if (!notification.read) {
+            try {
+                const result = await markNotificationRead(notification.id);
+                setUnread(result.unread);
+                setNotifications(prev => prev.map(n => (n.id === notification.id ? { ...n, read: true } : n)));
+            } catch (error) {
+                console.error('Error marking notification read:', error);
+            }
+        }
+        if (notification.link) {
+            navigate(notification.link);
+        }
+    };
+
+    const handleMarkAllRead = async () => {
+        try {
+            await markAllNotificationsRead();
+            setUnread(0);
+            setNotifications(prev => prev.map(n => ({ ...n, read: true })));
+        } catch (error) {
+            console.error('Error marking notifications read:', error);
+        }
+    };
+
     return (
         <div className="dashboard-sidebar">
             <div className="logo">
@@ -58,6 +119,38 @@ function Sidebar() {
                         <span>Insights</span>
                     </Link>
                 </li>
+                <li className="notification-nav">
+                    <a href="#notifications" onClick={(e) => { e.preventDefault(); setShowNotifications(!showNotifications); }}>
+                        <i className="fas fa-bell"></i>
+                        <span>Notifications</span>
+                        {unread > 0 && <span className="notification-badge">{unread > 99 ? '99+' : unread}</span>}
+                    </a>
+                    {showNotifications && (
+                        <div className="notification-panel">
+                            <div className="notification-panel-header">
+                                <span>Notifications</span>
+                                {unread > 0 && (
+                                    <button onClick={handleMarkAllRead}>Mark all read</button>
+                                )}
+                            </div>
+                            {notifications.length === 0 ? (
+                                <p className="notification-empty">No notifications yet</p>
+                            ) : (
+                                notifications.map(notification => (
+                                    <div
+                                        key={notification.id}
+                                        className={`notification-item ${notification.read ? '' : 'unread'}`}
+                                        onClick={() => openNotification(notification)}
+                                    >
+                                        <div className="notification-title">{notification.title}</div>
+                                        {notification.body && <div className="notification-body">{notification.body}</div>}
+                                        <div className="notification-time">{new Date(notification.createdAt).toLocaleString()}</div>
+                                    </div>
+                                ))
+                            )}
+                        </div>
+                    )}
+                </li>
                 <li className={isActive('/settings') ? 'active' : ''}>
                     <Link to="/settings">
                         <i className="fas fa-cog"></i>
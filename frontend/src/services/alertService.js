@@ -0,0 +1,20 @@
+import api from './api';
+
+// Wellbeing alerts of the current user's team (team leaders only).
+// params: { limit, cursor, status }. Resolves to { data, page: { limit, total, nextCursor } }.
+export const getAlerts = async (params = {}) => {
+    const response = await api.get('/api/v1/alerts', { params });
+    return response.data;
+};
+
+// Mark an alert as being looked into; note is optional
+export const acknowledgeAlert = async (id, note = '') => {
+    const response = await api.put(`/api/v1/alerts/${id}/acknowledge`, note ? { note } : {});
+    return response.data;
+};
+
+// Close an alert; note is optional
+export const resolveAlert = async (id, note = '') => {
+    const response = await api.put(`/api/v1/alerts/${id}/resolve`, note ? { note } : {});
+    return response.data;
+};
@@ -6,8 +6,9 @@ api.interceptors.response.use(
     error => {
         console.error('API Error:', error.response || error);
         
+        // Backend errors use the envelope {"error": {"code", "message", "requestId"}}
         const errorMsg = 
-            error.response?.data?.error || 
+            error.response?.data?.error?.message || 
             error.message || 
             'Something went wrong';
             
@@ -19,8 +20,7 @@ export const register = async (userData) => {
     try {
         console.log('Registering user:', userData);
         
-        // Ubah URL menjadi /register (tanpa /auth prefix)
-        const response = await api.post('/register', userData);
+        const response = await api.post('/api/v1/auth/register', userData);
         
         console.log('Registration response:', response.data);
         return response.data;
@@ -32,7 +32,7 @@ export const register = async (userData) => {
             console.error('Response data:', error.response.data);
             console.error('Response status:', error.response.status);
             console.error('Response headers:', error.response.headers);
-            throw new Error(error.response.data.error || 'Server error');
+            throw new Error(error.response.data.error?.message || 'Server error');
         } else if (error.request) {
             // The request was made but no response was received
             console.error('No response received:', error.request);
@@ -49,8 +49,7 @@ export const login = async (credentials) => {
     try {
         console.log('Logging in user:', credentials.email);
         
-        // Ubah URL menjadi /login (tanpa /auth prefix)
-        const response = await api.post('/login', credentials);
+        const response = await api.post('/api/v1/auth/login', credentials);
         
         // Save token and user data to local storage
         if (response.data.token) {
@@ -1,21 +1,34 @@
 import api from './api';
 
 // Simpan emotional check-in pengguna
-export const saveEmotion = async (emotionData) => {
+// idempotencyKey membuat pengiriman ulang (misalnya klik ganda) tidak menyimpan dua kali
+export const saveEmotion = async (emotionData, idempotencyKey) => {
     try {
-        const response = await api.post('/api/emotions', emotionData);
+        const headers = idempotencyKey ? { 'Idempotency-Key': idempotencyKey } : {};
+        const response = await api.post('/api/v1/emotions', emotionData, { headers });
         return response.data;
     } catch (error) {
         console.error('Error menyimpan data emosi:', error);
-        // Untuk development, kembalikan response sukses palsu
-        return { message: "Emotion recorded successfully (dev mode)" };
+        throw error;
     }
 };
 
+// Ubah check-in milik sendiri (hanya dalam batas waktu edit)
+export const updateEmotion = async (id, changes) => {
+    const response = await api.put(`/api/v1/emotions/${id}`, changes);
+    return response.data;
+};
+
+// Hapus check-in milik sendiri (hanya dalam batas waktu edit)
+export const deleteEmotion = async (id) => {
+    const response = await api.delete(`/api/v1/emotions/${id}`);
+    return response.data;
+};
+
 // Dapatkan statistik emosi untuk visualisasi
 export const getEmotionStats = async (period = 'week') => {
     try {
-        const response = await api.get(`/api/emotions/stats?period=${period}`);
+        const response = await api.get(`/api/v1/emotions/stats?period=${period}`);
         return response.data;
     } catch (error) {
         console.error('Error mengambil statistik emosi:', error);
@@ -31,10 +44,25 @@ export const getEmotionStats = async (period = 'week') => {
     }
 };
 
+// Dapatkan ringkasan dimensi (energi, stres, beban kerja, tidur) untuk visualisasi
+export const getDimensionStats = async (period = 'week', tag = '') => {
+    try {
+        const params = { period };
+        if (tag) {
+            params.tag = tag;
+        }
+        const response = await api.get('/api/v1/emotions/stats/dimensions', { params });
+        return response.data;
+    } catch (error) {
+        console.error('Error mengambil statistik dimensi:', error);
+        return [];
+    }
+};
+
 // Dapatkan riwayat emosi pengguna
 export const getUserEmotions = async (userId) => {
     try {
-        const response = await api.get(`/api/emotions/user/${userId}`);
+        const response = await api.get(`/api/v1/emotions/user/${userId}`);
         return response.data;
     } catch (error) {
         console.error('Error mengambil emosi pengguna:', error);
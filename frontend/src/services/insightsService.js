@@ -1,10 +1,19 @@
 import api from './api';
 
 export const insightsService = {
+    // Get the current user's personal insights over the last `days` days
+    getMyInsights: async (days) => {
+        const response = await api.get(`/api/v1/me/insights`, {
+            params: { days, timezone: Intl.DateTimeFormat().resolvedOptions().timeZone },
+            headers: { 'Authorization': `Bearer ${localStorage.getItem('token')}` }
+        });
+        return response.data;
+    },
+
     // Get emotion stats by period (today, week, month)
     getEmotionStats: async (period) => {
         try {
-            const response = await api.get(`/emotions/stats`, {
+            const response = await api.get(`/api/v1/emotions/stats`, {
                 params: { period },
                 headers: { 'Authorization': `Bearer ${localStorage.getItem('token')}` }
             });
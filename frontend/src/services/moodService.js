@@ -0,0 +1,27 @@
+import api from './api';
+
+// Mood yang dipakai jika daftar dari server tidak bisa dimuat
+const fallbackMoods = [
+    { key: 'happy', label: 'Senang', emoji: '😊', valence: 0.7 },
+    { key: 'neutral', label: 'Netral', emoji: '😐', valence: 0 },
+    { key: 'tired', label: 'Lelah', emoji: '😔', valence: -0.3 },
+    { key: 'stressed', label: 'Stres', emoji: '😠', valence: -0.7 },
+    { key: 'excited', label: 'Bersemangat', emoji: '😃', valence: 0.8 }
+];
+
+// Dapatkan daftar mood yang bisa dipilih, sesuai urutan tampilan
+export const getMoods = async () => {
+    try {
+        const response = await api.get('/api/v1/moods');
+        return response.data;
+    } catch (error) {
+        console.error('Error mengambil daftar mood:', error);
+        return fallbackMoods;
+    }
+};
+
+// Petakan probabilitas ekspresi face-api ke mood; hasilnya null jika tidak ada yang cukup yakin
+export const matchExpression = async (expressions) => {
+    const response = await api.post('/api/v1/moods/match', { expressions });
+    return response.data.mood;
+};
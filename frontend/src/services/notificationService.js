@@ -0,0 +1,83 @@
+import api from './api';
+
+const MAX_RECONNECT_DELAY = 30000;
+
+// One page of the current user's notifications, newest first, with the unread count
+export const getNotifications = async ({ limit = 10, cursor, unread } = {}) => {
+    const params = { limit };
+    if (cursor) params.cursor = cursor;
+    if (unread) params.unread = true;
+    const response = await api.get('/api/v1/notifications', { params });
+    return response.data;
+};
+
+export const markNotificationRead = async (id) => {
+    const response = await api.put(`/api/v1/notifications/${id}/read`);
+    return response.data;
+};
+
+export const markAllNotificationsRead = async () => {
+    const response = await api.put('/api/v1/notifications/read-all');
+    return response.data;
+};
+
+// Notify users that they were invited to, or mentioned in the chat of, a meeting
+export const inviteToMeeting = async (meetingId, userIds) => {
+    const response = await api.post(`/api/v1/meetings/${encodeURIComponent(meetingId)}/invites`, { userIds });
+    return response.data;
+};
+
+export const mentionInMeeting = async (meetingId, userIds, message) => {
+    const response = await api.post(`/api/v1/meetings/${encodeURIComponent(meetingId)}/mentions`, { userIds, message });
+    return response.data;
+};
+
+// Open the live notification feed. onSnapshot receives the unread count once per connection
+// (reload the list then, notifications may have arrived while disconnected), onNotification
+// every new notification and onRead the ids marked read elsewhere. Returns a function that closes the feed.
+export const subscribeNotifications = ({ onSnapshot, onNotification, onRead }) => {
+    let socket = null;
+    let reconnectTimer = null;
+    let reconnectDelay = 1000;
+    let closed = false;
+
+    const connect = () => {
+        const token = localStorage.getItem('token');
+        if (!token) return;
+
+        const url = new URL('/api/v1/notifications/ws', api.defaults.baseURL);
+        url.protocol = url.protocol === 'https:' ? 'wss:' : 'ws:';
+        url.searchParams.set('access_token', token);
+
+        socket = new WebSocket(url);
+
+        socket.onopen = () => {
+            reconnectDelay = 1000;
+        };
+
+        socket.onmessage = (event) => {
+            const data = JSON.parse(event.data);
+            if (data.type === 'snapshot') {
+                onSnapshot?.(data.unread);
+            } else if (data.type === 'notification') {
+                onNotification?.(data.notification, data.unread);
+            } else if (data.type === 'read') {
+                onRead?.(data.ids || [], data.unread);
+            }
+        };
+
+        socket.onclose = () => {
+            if (closed) return;
+            reconnectTimer = setTimeout(connect, reconnectDelay);
+            reconnectDelay = Math.min(reconnectDelay * 2, MAX_RECONNECT_DELAY);
+        };
+    };
+
+    connect();
+
+    return () => {
+        closed = true;
+        clearTimeout(reconnectTimer);
+        socket?.close();
+    };
+};
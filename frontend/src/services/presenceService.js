@@ -0,0 +1,71 @@
+import api from './api';
+
+const HEARTBEAT_INTERVAL = 30000;
+const MAX_RECONNECT_DELAY = 30000;
+
+// Set or clear the current user's status message
+export const setStatusMessage = async (status) => {
+    const response = await api.put('/api/v1/me/status', status);
+    return response.data;
+};
+
+export const clearStatusMessage = async () => {
+    const response = await api.delete('/api/v1/me/status');
+    return response.data;
+};
+
+// Open the live presence feed. onSnapshot receives every member once per connection,
+// onChange one member whenever their presence changes. Returns a function that closes the feed.
+export const subscribePresence = ({ onSnapshot, onChange }) => {
+    let socket = null;
+    let heartbeat = null;
+    let reconnectTimer = null;
+    let reconnectDelay = 1000;
+    let closed = false;
+
+    const connect = () => {
+        const token = localStorage.getItem('token');
+        if (!token) return;
+
+        const url = new URL('/api/v1/presence/ws', api.defaults.baseURL);
+        url.protocol = url.protocol === 'https:' ? 'wss:' : 'ws:';
+        url.searchParams.set('access_token', token);
+
+        socket = new WebSocket(url);
+
+        socket.onopen = () => {
+            reconnectDelay = 1000;
+            // Only report activity while the page is actually visible
+            heartbeat = setInterval(() => {
+                if (document.visibilityState === 'visible' && socket.readyState === WebSocket.OPEN) {
+                    socket.send(JSON.stringify({ type: 'heartbeat' }));
+                }
+            }, HEARTBEAT_INTERVAL);
+        };
+
+        socket.onmessage = (event) => {
+            const data = JSON.parse(event.data);
+            if (data.type === 'snapshot') {
+                onSnapshot?.(data.members);
+            } else if (data.type === 'presence') {
+                onChange?.(data);
+            }
+        };
+
+        socket.onclose = () => {
+            clearInterval(heartbeat);
+            if (closed) return;
+            reconnectTimer = setTimeout(connect, reconnectDelay);
+            reconnectDelay = Math.min(reconnectDelay * 2, MAX_RECONNECT_DELAY);
+        };
+    };
+
+    connect();
+
+    return () => {
+        closed = true;
+        clearInterval(heartbeat);
+        clearTimeout(reconnectTimer);
+        socket?.close();
+    };
+};
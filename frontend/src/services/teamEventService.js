@@ -0,0 +1,39 @@
+import api from './api';
+
+// Open the live event stream of a team (Server-Sent Events). handlers: onSnapshot({ today, live })
+// once per connection (refetch what is shown then, events may have been missed while disconnected),
+// onCheckins(data), onPresence(data), onMeetingStarted(data) and onMeetingEnded(data).
+// The browser reconnects on its own. Returns a function that closes the stream.
+export const subscribeTeamEvents = (team, { onSnapshot, onCheckins, onPresence, onMeetingStarted, onMeetingEnded }) => {
+    const token = localStorage.getItem('token');
+    if (!team || !token) return () => {};
+
+    const url = new URL(`/api/v1/teams/${encodeURIComponent(team)}/events`, api.defaults.baseURL);
+    // EventSource can't send an Authorization header
+    url.searchParams.set('access_token', token);
+
+    const source = new EventSource(url);
+    const listen = (type, handler) => {
+        source.addEventListener(type, (event) => {
+            const data = JSON.parse(event.data);
+            handler?.(type === 'snapshot' ? data : data.data);
+        });
+    };
+
+    listen('snapshot', onSnapshot);
+    listen('checkins', onCheckins);
+    listen('presence', onPresence);
+    listen('meeting.started', onMeetingStarted);
+    listen('meeting.ended', onMeetingEnded);
+
+    return () => source.close();
+};
+
+// Tell the team a meeting started or ended; meetings only exist in the browser
+export const startMeeting = async (meetingId) => {
+    await api.post(`/api/v1/meetings/${encodeURIComponent(meetingId)}/start`);
+};
+
+export const endMeeting = async (meetingId) => {
+    await api.post(`/api/v1/meetings/${encodeURIComponent(meetingId)}/end`);
+};
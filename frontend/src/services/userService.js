@@ -1,48 +1,25 @@
 import api from './api';
 
-// Get all users
-// Get all users
-export const getUsers = async () => {
-    try {
-        console.log('Fetching users from:', api.defaults.baseURL + '/users');
-        const response = await api.get('/users');
-        console.log('API response:', response);
-        
-        // Tambahkan baseURL ke profileImage jika ada
-        const users = response.data.map(user => {
-            if (user.profileImage && !user.profileImage.startsWith('http')) {
-                user.profileImage = api.defaults.baseURL + user.profileImage;
-            }
-            return user;
-        });
-        
-        return users;
-    } catch (error) {
-        console.error('Error fetching users:', error);
-        if (error.response) {
-            console.error('Response status:', error.response.status);
-            console.error('Response data:', error.response.data);
+// Get one page of users. params: { limit, cursor, q, role, team, status, sort }.
+// Resolves to { data, page: { limit, total, nextCursor } }.
+export const getUsers = async (params = {}) => {
+    const response = await api.get('/api/v1/users', { params });
+
+    // Tambahkan baseURL ke profileImage jika ada
+    const users = response.data.data.map(user => {
+        if (user.profileImage && !user.profileImage.startsWith('http')) {
+            user.profileImage = api.defaults.baseURL + user.profileImage;
         }
-        
-        // Fallback data jika API gagal
-        console.log('Providing fallback user data');
-        return [
-            {
-                id: '1',
-                nama: 'Reyhan Dwiyan',
-                email: 'reyhan@example.com',
-                role: 'Team Leader',
-                profileImage: null
-            },
-            // other fallback users...
-        ];
-    }
+        return user;
+    });
+
+    return { data: users, page: response.data.page };
 };
 
 // Get user by ID
 export const getUserById = async (userId) => {
     try {
-        const response = await api.get(`/users/${userId}`);
+        const response = await api.get(`/api/v1/users/${userId}`);
         
         // Tambahkan baseURL ke profileImage jika ada
         const user = response.data;
@@ -60,15 +37,8 @@ export const getUserById = async (userId) => {
 // Update user
 export const updateUser = async (userId, userData) => {
     try {
-        // Coba dengan format /api/users/:id
-        try {
-            const response = await api.put(`/api/users/${userId}`, userData);
-            return response.data;
-        } catch (error) {
-            // Jika gagal, coba dengan format /users/:id
-            const response = await api.put(`/users/${userId}`, userData);
-            return response.data;
-        }
+        const response = await api.put(`/api/v1/users/${userId}`, userData);
+        return response.data;
     } catch (error) {
         console.error(`Error updating user with ID ${userId}:`, error);
         throw error;
@@ -91,8 +61,8 @@ export const uploadProfileImage = async (formData) => {
             }
         });
         
-        console.log('Uploading to:', api.defaults.baseURL + '/api/upload-profile-image');
-        const response = await uploadInstance.post('/api/upload-profile-image', formData);
+        console.log('Uploading to:', api.defaults.baseURL + '/api/v1/me/profile-image');
+        const response = await uploadInstance.post('/api/v1/me/profile-image', formData);
         
         console.log('Upload response:', response.data);
         
@@ -109,4 +79,52 @@ export const uploadProfileImage = async (formData) => {
         console.error('Data:', error.response?.data);
         throw error;
     }
-};
\ No newline at end of file
+};
+// Download everything stored about the current user as a ZIP file
+export const exportMyData = async () => {
+    const response = await api.get('/api/v1/me/export', { responseType: 'blob' });
+
+    const disposition = response.headers['content-disposition'] || '';
+    const fileName = disposition.match(/filename="([^"]+)"/)?.[1] || 'my-data.zip';
+
+    const url = URL.createObjectURL(response.data);
+    const link = document.createElement('a');
+    link.href = url;
+    link.download = fileName;
+    link.click();
+    URL.revokeObjectURL(url);
+};
+
+// Schedule the current user's account for deletion; logging in again before purgeAt restores it
+export const deleteMyAccount = async (password) => {
+    const response = await api.delete('/api/v1/me', { data: { password } });
+    return response.data;
+};
+
+// Check-in reminder settings of the current user
+export const getReminderSettings = async () => {
+    const response = await api.get('/api/v1/me/reminders');
+    return response.data;
+};
+
+export const updateReminderSettings = async (settings) => {
+    const response = await api.put('/api/v1/me/reminders', settings);
+    return response.data;
+};
+
+// Hold reminders back for the given number of minutes
+export const snoozeReminder = async (minutes) => {
+    const response = await api.post('/api/v1/me/reminders/snooze', { minutes });
+    return response.data;
+};
+
+// Privacy preferences of the current user
+export const getPrivacyPreferences = async () => {
+    const response = await api.get('/api/v1/me/privacy');
+    return response.data;
+};
+
+export const updatePrivacyPreferences = async (preferences) => {
+    const response = await api.put('/api/v1/me/privacy', preferences);
+    return response.data;
+};